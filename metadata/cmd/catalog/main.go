@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/abhishek622/moviedock/metadata/internal/catalog"
+	"github.com/abhishek622/moviedock/metadata/internal/controller/metadata"
+	"github.com/abhishek622/moviedock/metadata/internal/repository/postgres"
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/joho/godotenv"
+)
+
+const usage = `usage:
+  catalog import [-format csv|jsonl] [-dry-run] [-upsert] FILE
+  catalog export [-format csv|jsonl] [-o FILE]
+
+Imports or exports the movie catalog directly against the metadata
+database, configured like the metadata service. The format defaults to the
+file extension. Use - to import from standard input.
+`
+
+// catalog bulk imports and exports the movie catalog.
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err := godotenv.Load(".env"); err != nil {
+		log.Println("Warning: unable to find .env file")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var err error
+	switch os.Args[1] {
+	case "import":
+		err = runImport(ctx, os.Args[2:])
+	case "export":
+		err = runExport(ctx, os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runImport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	formatName := fs.String("format", "", "File format, csv or jsonl")
+	dryRun := fs.Bool("dry-run", false, "Validate without writing anything")
+	upsert := fs.Bool("upsert", false, "Update movies matching the external IDs of a row")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	path := fs.Arg(0)
+	format, err := fileFormat(*formatName, path)
+	if err != nil {
+		return err
+	}
+
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	r, err := catalog.NewReader(in, format)
+	if err != nil {
+		return err
+	}
+	ctrl, err := newController()
+	if err != nil {
+		return err
+	}
+
+	// Imports run outside the API are recorded without an actor
+	report, err := ctrl.Import(ctx, "", r, model.ImportOptions{DryRun: *dryRun, Upsert: *upsert})
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+	return nil
+}
+
+func runExport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	formatName := fs.String("format", "", "File format, csv or jsonl (default jsonl)")
+	output := fs.String("o", "-", "Output file, - for standard output")
+	fs.Parse(args)
+
+	format, err := fileFormat(*formatName, *output)
+	if err != nil {
+		return err
+	}
+
+	ctrl, err := newController()
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if *output != "-" {
+		if out, err = os.Create(*output); err != nil {
+			return err
+		}
+	}
+
+	w, err := catalog.NewWriter(out, format)
+	if err != nil {
+		return err
+	}
+	if err := ctrl.Export(ctx, w); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// fileFormat returns the format named name, or else the format of the
+// extension of path, or else JSON Lines for standard input and output.
+func fileFormat(name, path string) (catalog.Format, error) {
+	if name != "" {
+		return catalog.ParseFormat(name)
+	}
+	if path == "-" {
+		return catalog.FormatJSONL, nil
+	}
+	return catalog.ParseFormat(strings.TrimPrefix(filepath.Ext(path), "."))
+}
+
+func newController() (*metadata.Controller, error) {
+	repo, err := postgres.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create repository: %w", err)
+	}
+	return metadata.New(repo, nil), nil
+}
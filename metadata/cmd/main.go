@@ -14,8 +14,13 @@ import (
 	"github.com/abhishek622/moviedock/metadata/internal/controller/metadata"
 	httphandler "github.com/abhishek622/moviedock/metadata/internal/handler/http"
 	"github.com/abhishek622/moviedock/metadata/internal/repository/postgres"
+	"github.com/abhishek622/moviedock/metadata/internal/storage"
+	"github.com/abhishek622/moviedock/metadata/internal/storage/local"
+	"github.com/abhishek622/moviedock/metadata/internal/storage/s3"
+	"github.com/abhishek622/moviedock/pkg/authz"
 	"github.com/abhishek622/moviedock/pkg/discovery"
 	"github.com/abhishek622/moviedock/pkg/discovery/consul"
+	"github.com/abhishek622/moviedock/pkg/locale"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"golang.org/x/sync/errgroup"
@@ -43,11 +48,25 @@ func main() {
 		log.Fatalf("Failed to create repository: %v", err)
 	}
 
+	// Token verification against the user service signing keys, and API
+	// keys when introspection is configured
+	validator, err := authz.NewValidatorFromEnv(serviceName)
+	if err != nil {
+		log.Fatalf("Failed to configure authentication: %v", err)
+	}
+
+	// Image storage, in a local directory unless S3 is configured
+	store, err := newImageStorage(*port)
+	if err != nil {
+		log.Fatalf("Failed to configure image storage: %v", err)
+	}
+
 	// Create controller
-	ctrl := metadata.New(repo)
+	ctrl := metadata.New(repo, store)
 
 	// Create HTTP handler with Gin
 	router := gin.Default()
+	router.Use(authz.Authenticate(validator), locale.Negotiate())
 	handler := httphandler.New(ctrl)
 	handler.RegisterRoutes(router)
 
@@ -126,3 +145,31 @@ func main() {
 
 	log.Println("Server stopped")
 }
+
+// newImageStorage configures where movie images are stored from the
+// environment. IMAGE_STORAGE selects local (the default) or s3.
+func newImageStorage(port int) (storage.Storage, error) {
+	switch backend := os.Getenv("IMAGE_STORAGE"); backend {
+	case "", "local":
+		dir := os.Getenv("IMAGE_DIR")
+		if dir == "" {
+			dir = "data/images"
+		}
+		baseURL := os.Getenv("IMAGE_BASE_URL")
+		if baseURL == "" {
+			baseURL = fmt.Sprintf("http://localhost:%d/images", port)
+		}
+		return local.New(dir, baseURL)
+	case "s3":
+		return s3.New(s3.Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PublicURL:       os.Getenv("S3_PUBLIC_URL"),
+		}, &http.Client{Timeout: 30 * time.Second})
+	default:
+		return nil, fmt.Errorf("unknown image storage %q", backend)
+	}
+}
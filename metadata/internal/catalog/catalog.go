@@ -0,0 +1,90 @@
+// Package catalog reads and writes movie metadata in the bulk import and
+// export formats, CSV and JSON Lines, one movie per record.
+package catalog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Format is a bulk catalog file format.
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+)
+
+// ParseFormat returns the format named s, case-insensitively. "ndjson" is
+// accepted for JSON Lines.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "csv":
+		return FormatCSV, nil
+	case "jsonl", "ndjson":
+		return FormatJSONL, nil
+	}
+	return "", fmt.Errorf("unsupported format %q", s)
+}
+
+// FormatOfContentType returns the format of a media type, if known.
+func FormatOfContentType(contentType string) (Format, bool) {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.ToLower(strings.TrimSpace(mediaType)) {
+	case "text/csv":
+		return FormatCSV, true
+	case "application/jsonl", "application/x-ndjson", "application/x-jsonlines":
+		return FormatJSONL, true
+	}
+	return "", false
+}
+
+// ContentType returns the media type of files in format f.
+func (f Format) ContentType() string {
+	if f == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// RowError is returned for a record that cannot be decoded. Reading may
+// continue with the next record.
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// CSV columns. The metadata_id column is written by exports and ignored by
+// imports so that exports can be imported again.
+const (
+	colMetadataID       = "metadata_id"
+	colExternalIDs      = "external_ids"
+	colTitle            = "title"
+	colDescription      = "description"
+	colDirector         = "director"
+	colRuntime          = "runtime"
+	colReleaseDate      = "release_date"
+	colGenres           = "genres"
+	colOriginalLanguage = "original_language"
+	colCountry          = "country"
+	colAgeCertification = "age_certification"
+	colPosterURL        = "poster_url"
+	colBackdropURL      = "backdrop_url"
+)
+
+var csvColumns = []string{
+	colMetadataID, colExternalIDs, colTitle, colDescription, colDirector, colRuntime, colReleaseDate, colGenres,
+	colOriginalLanguage, colCountry, colAgeCertification, colPosterURL, colBackdropURL,
+}
+
+// listSeparator separates the genres and the external IDs of a movie in a
+// CSV field. External IDs are written as provider:id.
+const listSeparator = "|"
@@ -0,0 +1,191 @@
+package catalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+)
+
+// Reader reads movies one at a time from a catalog file.
+type Reader interface {
+	// Read returns the next movie and the line it starts on, or io.EOF at
+	// the end of the file. A *RowError is returned for records that cannot
+	// be decoded; any other error ends reading.
+	Read() (*model.Metadata, int, error)
+}
+
+// NewReader returns a reader of the catalog in r in format f. CSV files
+// must start with a header naming their columns, of which title is
+// required.
+func NewReader(r io.Reader, f Format) (Reader, error) {
+	switch f {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatJSONL:
+		return &jsonlReader{r: bufio.NewReader(r)}, nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", f)
+}
+
+type csvReader struct {
+	r       *csv.Reader
+	columns []string
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("missing CSV header")
+	} else if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff") // byte order mark
+		}
+		if !slices.Contains(csvColumns, name) {
+			return nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+		if slices.Contains(columns[:i], name) {
+			return nil, fmt.Errorf("duplicate CSV column %q", name)
+		}
+		columns[i] = name
+	}
+	if !slices.Contains(columns, colTitle) {
+		return nil, fmt.Errorf("missing CSV column %q", colTitle)
+	}
+	return &csvReader{r: cr, columns: columns}, nil
+}
+
+func (r *csvReader) Read() (*model.Metadata, int, error) {
+	record, err := r.r.Read()
+	if errors.Is(err, csv.ErrFieldCount) {
+		line, _ := r.r.FieldPos(0)
+		return nil, line, &RowError{Line: line, Err: fmt.Errorf("expected %d fields, got %d", len(r.columns), len(record))}
+	} else if err != nil {
+		return nil, 0, err
+	}
+
+	line, _ := r.r.FieldPos(0)
+	m, err := r.decode(record)
+	if err != nil {
+		return nil, line, &RowError{Line: line, Err: err}
+	}
+	return m, line, nil
+}
+
+func (r *csvReader) decode(record []string) (*model.Metadata, error) {
+	var m model.Metadata
+	for i, v := range record {
+		v = strings.TrimSpace(v)
+		switch r.columns[i] {
+		case colExternalIDs:
+			if v == "" {
+				continue
+			}
+			m.ExternalIDs = map[string]string{}
+			for _, ref := range strings.Split(v, listSeparator) {
+				provider, id, ok := strings.Cut(strings.TrimSpace(ref), ":")
+				if !ok {
+					return nil, fmt.Errorf("invalid external id %q, expected provider:id", ref)
+				}
+				if _, dup := m.ExternalIDs[provider]; dup {
+					return nil, fmt.Errorf("more than one external id for provider %q", provider)
+				}
+				m.ExternalIDs[provider] = id
+			}
+		case colTitle:
+			m.Title = v
+		case colDescription:
+			m.Description = v
+		case colDirector:
+			m.Director = v
+		case colRuntime:
+			if v == "" {
+				continue
+			}
+			n, err := strconv.ParseInt(v, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid runtime %q", v)
+			}
+			m.Runtime = int32(n)
+		case colReleaseDate:
+			if v == "" {
+				continue
+			}
+			t, err := time.Parse(model.DateLayout, v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid release_date %q, expected YYYY-MM-DD", v)
+			}
+			d := model.NewDate(t)
+			m.ReleaseDate = &d
+		case colGenres:
+			for _, g := range strings.Split(v, listSeparator) {
+				if g = strings.TrimSpace(g); g != "" {
+					m.Genres = append(m.Genres, g)
+				}
+			}
+		case colOriginalLanguage:
+			m.OriginalLanguage = v
+		case colCountry:
+			m.Country = v
+		case colAgeCertification:
+			m.AgeCertification = v
+		case colPosterURL:
+			m.PosterURL = v
+		case colBackdropURL:
+			m.BackdropURL = v
+		}
+	}
+	return &m, nil
+}
+
+type jsonlReader struct {
+	r    *bufio.Reader
+	line int
+}
+
+func (r *jsonlReader) Read() (*model.Metadata, int, error) {
+	for {
+		b, err := r.r.ReadBytes('\n')
+		if len(b) == 0 && err != nil {
+			return nil, 0, err
+		} else if err != nil && !errors.Is(err, io.EOF) {
+			return nil, 0, err
+		}
+		r.line++
+
+		b = bytes.TrimSpace(b)
+		if len(b) == 0 {
+			continue
+		}
+
+		var m model.Metadata
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&m); err != nil {
+			return nil, r.line, &RowError{Line: r.line, Err: err}
+		}
+		if dec.More() {
+			return nil, r.line, &RowError{Line: r.line, Err: errors.New("more than one JSON value on the line")}
+		}
+		m.MetadataID = 0
+		return &m, r.line, nil
+	}
+}
@@ -0,0 +1,88 @@
+package catalog
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+)
+
+// Writer writes movies to a catalog file.
+type Writer interface {
+	Write(m *model.Metadata) error
+	// Flush writes any buffered data to the underlying writer.
+	Flush() error
+}
+
+// NewWriter returns a writer of a catalog in format f to w. CSV files start
+// with a header.
+func NewWriter(w io.Writer, f Format) (Writer, error) {
+	switch f {
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatJSONL:
+		bw := bufio.NewWriter(w)
+		return &jsonlWriter{w: bw, enc: json.NewEncoder(bw)}, nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", f)
+}
+
+type csvWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (w *csvWriter) Write(m *model.Metadata) error {
+	if !w.headerWritten {
+		if err := w.w.Write(csvColumns); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+
+	var releaseDate string
+	if m.ReleaseDate != nil {
+		releaseDate = m.ReleaseDate.String()
+	}
+	externalIDs := make([]string, 0, len(m.ExternalIDs))
+	for provider, id := range m.ExternalIDs {
+		externalIDs = append(externalIDs, provider+":"+id)
+	}
+	slices.Sort(externalIDs)
+	return w.w.Write([]string{
+		strconv.Itoa(int(m.MetadataID)), strings.Join(externalIDs, listSeparator), m.Title, m.Description, m.Director,
+		strconv.Itoa(int(m.Runtime)), releaseDate, strings.Join(m.Genres, listSeparator), m.OriginalLanguage,
+		m.Country, m.AgeCertification, m.PosterURL, m.BackdropURL,
+	})
+}
+
+func (w *csvWriter) Flush() error {
+	// An empty catalog still gets a header
+	if !w.headerWritten {
+		if err := w.w.Write(csvColumns); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
+
+type jsonlWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (w *jsonlWriter) Write(m *model.Metadata) error {
+	return w.enc.Encode(m)
+}
+
+func (w *jsonlWriter) Flush() error {
+	return w.w.Flush()
+}
@@ -4,46 +4,125 @@ import (
 	"context"
 	"errors"
 	"log"
+	"strings"
 
+	"github.com/abhishek622/moviedock/metadata/internal/repository"
+	"github.com/abhishek622/moviedock/metadata/internal/storage"
 	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/pagination"
 )
 
-// ErrNotFound is returned when a requested record is not found.
-var ErrNotFound = errors.New("not found")
+var (
+	// ErrNotFound is returned when a requested record is not found.
+	ErrNotFound = apperr.New(apperr.NotFound, "metadata not found")
+	// ErrNotDeleted is returned when restoring metadata that is not deleted.
+	ErrNotDeleted = apperr.New(apperr.Conflict, "metadata is not deleted")
+)
 
 type metadataRepository interface {
 	Get(ctx context.Context, id int32) (*model.Metadata, error)
-	Put(ctx context.Context, id int32, m *model.Metadata) error
-	Create(ctx context.Context, m *model.Metadata) (*model.Metadata, error)
-	Delete(ctx context.Context, id int32) error
-	List(ctx context.Context, limit, offset int) ([]*model.Metadata, error)
+	Put(ctx context.Context, id int32, m *model.Metadata, actorID string) error
+	Create(ctx context.Context, m *model.Metadata, actorID string) (*model.Metadata, error)
+	Delete(ctx context.Context, id int32, actorID string) error
+	Restore(ctx context.Context, id int32, actorID string) (*model.Metadata, error)
+	ListHistory(ctx context.Context, id int32, page pagination.Request) ([]*model.HistoryEntry, pagination.Info, error)
+	List(ctx context.Context, filter model.MetadataFilter) ([]*model.Metadata, pagination.Info, error)
+	ListGenres(ctx context.Context) ([]*model.Genre, error)
+	Import(ctx context.Context, actorID string, fn func(repository.Importer) (bool, error)) error
+	Export(ctx context.Context, fn func(*model.Metadata) error) error
+	GetByExternalID(ctx context.Context, provider, externalID string) (*model.Metadata, error)
+	LookupExternalIDs(ctx context.Context, provider string, externalIDs []string) ([]*model.ExternalID, error)
+	PutExternalID(ctx context.Context, id *model.ExternalID, actorID string) error
+	ListTranslations(ctx context.Context, metadataID int32) ([]*model.Translation, error)
+	FindTranslations(ctx context.Context, metadataIDs []int32, locales []string) ([]*model.Translation, error)
+	PutTranslation(ctx context.Context, t *model.Translation) error
+	DeleteTranslation(ctx context.Context, metadataID int32, locale string) error
+	DeleteExternalID(ctx context.Context, metadataID int32, provider, actorID string) error
+	CreateImage(ctx context.Context, img *model.Image) (*model.Image, error)
+	ListImages(ctx context.Context, metadataIDs []int32) ([]*model.Image, error)
+	DeleteImage(ctx context.Context, metadataID, imageID int32) (*model.Image, error)
+	GetPerson(ctx context.Context, id int32) (*model.Person, error)
+	ListPeople(ctx context.Context, filter model.PersonFilter) ([]*model.Person, pagination.Info, error)
+	CreatePerson(ctx context.Context, p *model.Person) (*model.Person, error)
+	UpdatePerson(ctx context.Context, id int32, p *model.Person) (*model.Person, error)
+	DeletePerson(ctx context.Context, id int32) error
+	ListMovieCredits(ctx context.Context, metadataID int32) ([]*model.Credit, error)
+	ListPersonCredits(ctx context.Context, personID int32) ([]*model.Credit, error)
+	CreateCredit(ctx context.Context, c *model.Credit) (*model.Credit, error)
+	UpdateCredit(ctx context.Context, c *model.Credit) (*model.Credit, error)
+	DeleteCredit(ctx context.Context, metadataID, creditID int32) error
+	GetSeries(ctx context.Context, id int32) (*model.Series, error)
+	ListSeries(ctx context.Context, page pagination.Request) ([]*model.Series, pagination.Info, error)
+	CreateSeries(ctx context.Context, s *model.Series) (*model.Series, error)
+	UpdateSeries(ctx context.Context, id int32, s *model.Series) (*model.Series, error)
+	DeleteSeries(ctx context.Context, id int32) error
+	GetSeason(ctx context.Context, id int32) (*model.Season, error)
+	ListSeasons(ctx context.Context, seriesID int32) ([]*model.Season, error)
+	CreateSeason(ctx context.Context, s *model.Season) (*model.Season, error)
+	UpdateSeason(ctx context.Context, id int32, s *model.Season) (*model.Season, error)
+	DeleteSeason(ctx context.Context, id int32) error
+	GetEpisode(ctx context.Context, id int32) (*model.Episode, error)
+	ListEpisodes(ctx context.Context, seasonID int32) ([]*model.Episode, error)
+	ListSeriesEpisodes(ctx context.Context, seriesID int32) ([]*model.Episode, error)
+	CreateEpisode(ctx context.Context, e *model.Episode) (*model.Episode, error)
+	UpdateEpisode(ctx context.Context, id int32, e *model.Episode) (*model.Episode, error)
+	DeleteEpisode(ctx context.Context, id int32) error
 }
 
 // Controller defines a metadata service controller.
 type Controller struct {
-	repo metadataRepository
+	repo  metadataRepository
+	store storage.Storage
 }
 
-// New creates a metadata service controller.
-func New(repo metadataRepository) *Controller {
-	return &Controller{repo}
+// New creates a metadata service controller. Images are stored in store,
+// which may be nil when images are not served.
+func New(repo metadataRepository, store storage.Storage) *Controller {
+	return &Controller{repo, store}
 }
 
-// Get returns movie metadata by id.
+// Get returns movie metadata by id with its images, translated for the
+// locale preferences of the request.
 func (c *Controller) Get(ctx context.Context, id int32) (*model.Metadata, error) {
-	res, err := c.repo.Get(ctx, id)
+	res, err := c.get(ctx, id)
 	if err != nil {
+		return nil, err
+	}
+	if err := c.present(ctx, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// present prepares movies for responses, translating them for the request
+// and attaching their images.
+func (c *Controller) present(ctx context.Context, movies ...*model.Metadata) error {
+	if err := c.localize(ctx, movies...); err != nil {
+		return err
+	}
+	return c.attachImages(ctx, movies...)
+}
+
+// get returns movie metadata by id as stored.
+func (c *Controller) get(ctx context.Context, id int32) (*model.Metadata, error) {
+	res, err := c.repo.Get(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrNotFound
+	} else if err != nil {
 		log.Printf("Failed to get metadata: %v", err)
 		return nil, err
 	}
 	return res, err
 }
 
-// Create creates new movie metadata.
-func (c *Controller) Create(ctx context.Context, metadata *model.Metadata) (*model.Metadata, error) {
-	// Add validation if needed
-	res, err := c.repo.Create(ctx, metadata)
-	if err != nil {
+// Create creates new movie metadata on behalf of the user actorID.
+func (c *Controller) Create(ctx context.Context, actorID string, metadata *model.Metadata) (*model.Metadata, error) {
+	normalize(metadata)
+	res, err := c.repo.Create(ctx, metadata, actorID)
+	if isExternalIDConflict(err) {
+		return nil, ErrExternalIDExists
+	} else if err != nil {
 		log.Printf("Failed to create metadata: %v", err)
 		return nil, err
 	}
@@ -52,29 +131,105 @@ func (c *Controller) Create(ctx context.Context, metadata *model.Metadata) (*mod
 	return res, nil
 }
 
-// Update updates movie metadata.
-func (c *Controller) Update(ctx context.Context, id int32, metadata *model.Metadata) (*model.Metadata, error) {
+// Update updates movie metadata on behalf of the user actorID.
+func (c *Controller) Update(ctx context.Context, actorID string, id int32, metadata *model.Metadata) (*model.Metadata, error) {
 	// Check if exists
-	if _, err := c.Get(ctx, id); err != nil {
+	if _, err := c.get(ctx, id); err != nil {
 		return nil, err
 	}
 
 	// Update
-	err := c.repo.Put(ctx, id, metadata)
-	if err != nil {
+	normalize(metadata)
+	err := c.repo.Put(ctx, id, metadata, actorID)
+	if errors.Is(err, repository.ErrNotFound) {
+		// Deleted since checked
+		return nil, ErrNotFound
+	} else if isExternalIDConflict(err) {
+		return nil, ErrExternalIDExists
+	} else if err != nil {
 		log.Printf("Failed to update metadata: %v", err)
 		return nil, err
 	}
 
-	return c.Get(ctx, id)
+	return c.get(ctx, id)
 }
 
-// Delete deletes movie metadata.
-func (c *Controller) Delete(ctx context.Context, id int32) error {
-	return c.repo.Delete(ctx, id)
+// Delete deletes movie metadata on behalf of the user actorID. Deleted
+// metadata is hidden from reads and listings until restored.
+func (c *Controller) Delete(ctx context.Context, actorID string, id int32) error {
+	if err := c.repo.Delete(ctx, id, actorID); errors.Is(err, repository.ErrNotFound) {
+		return ErrNotFound
+	} else if err != nil {
+		log.Printf("Failed to delete metadata: %v", err)
+		return err
+	}
+	return nil
 }
 
-// List returns all metadata.
-func (c *Controller) List(ctx context.Context, limit, offset int) ([]*model.Metadata, error) {
-	return c.repo.List(ctx, limit, offset)
+// Restore undeletes movie metadata on behalf of the user actorID.
+func (c *Controller) Restore(ctx context.Context, actorID string, id int32) (*model.Metadata, error) {
+	res, err := c.repo.Restore(ctx, id, actorID)
+	if errors.Is(err, repository.ErrNotFound) {
+		if _, err := c.repo.Get(ctx, id); err == nil {
+			return nil, ErrNotDeleted
+		}
+		return nil, ErrNotFound
+	} else if err != nil {
+		log.Printf("Failed to restore metadata: %v", err)
+		return nil, err
+	}
+	return res, nil
+}
+
+// History returns a page of the changes of movie metadata, including
+// deleted metadata.
+func (c *Controller) History(ctx context.Context, id int32, page pagination.Request) ([]*model.HistoryEntry, pagination.Info, error) {
+	entries, info, err := c.repo.ListHistory(ctx, id, page)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, pagination.Info{}, ErrNotFound
+	}
+	return entries, info, err
+}
+
+// List returns a page of metadata matching filter.
+func (c *Controller) List(ctx context.Context, filter model.MetadataFilter) ([]*model.Metadata, pagination.Info, error) {
+	filter.Language = strings.ToLower(filter.Language)
+	res, info, err := c.repo.List(ctx, filter)
+	if err != nil {
+		return nil, pagination.Info{}, err
+	}
+	if err := c.present(ctx, res...); err != nil {
+		return nil, pagination.Info{}, err
+	}
+	return res, info, nil
+}
+
+// ListGenres returns all genres movies are tagged with.
+func (c *Controller) ListGenres(ctx context.Context) ([]*model.Genre, error) {
+	return c.repo.ListGenres(ctx)
+}
+
+// normalize trims genres and drops duplicates differing only in case, brings
+// language and country codes to their usual case and providers of external
+// IDs to lower case.
+func normalize(m *model.Metadata) {
+	genres := make([]string, 0, len(m.Genres))
+	seen := map[string]bool{}
+	for _, g := range m.Genres {
+		g = strings.TrimSpace(g)
+		if key := strings.ToLower(g); g != "" && !seen[key] {
+			seen[key] = true
+			genres = append(genres, g)
+		}
+	}
+	m.Genres = genres
+	if m.ExternalIDs != nil {
+		externalIDs := make(map[string]string, len(m.ExternalIDs))
+		for provider, id := range m.ExternalIDs {
+			externalIDs[normalizeProvider(provider)] = strings.TrimSpace(id)
+		}
+		m.ExternalIDs = externalIDs
+	}
+	m.OriginalLanguage = strings.ToLower(m.OriginalLanguage)
+	m.Country = strings.ToUpper(m.Country)
 }
@@ -0,0 +1,106 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/abhishek622/moviedock/metadata/internal/repository"
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+)
+
+var (
+	// ErrExternalIDExists is returned when an external ID is already mapped
+	// to another movie.
+	ErrExternalIDExists = apperr.New(apperr.AlreadyExists, "external id already mapped to a movie")
+	// ErrExternalIDNotFound is returned when a movie has no ID at a provider.
+	ErrExternalIDNotFound = apperr.New(apperr.NotFound, "external id not found")
+	// ErrTooManyExternalIDs is returned when looking up too many IDs at once.
+	ErrTooManyExternalIDs = apperr.New(apperr.InvalidArgument, "too many external ids")
+)
+
+// maxLookupExternalIDs limits the IDs looked up in one request.
+const maxLookupExternalIDs = 500
+
+// GetByExternalID returns the movie with the given identifier at a
+// provider, translated for the locale preferences of the request.
+func (c *Controller) GetByExternalID(ctx context.Context, provider, externalID string) (*model.Metadata, error) {
+	res, err := c.repo.GetByExternalID(ctx, normalizeProvider(provider), normalizeExternalID(externalID))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if err := c.present(ctx, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// LookupExternalIDs resolves identifiers at a provider to metadata IDs.
+// Unknown and empty identifiers are left out.
+func (c *Controller) LookupExternalIDs(ctx context.Context, provider string, externalIDs []string) ([]*model.ExternalID, error) {
+	if len(externalIDs) > maxLookupExternalIDs {
+		return nil, ErrTooManyExternalIDs
+	}
+	ids := make([]string, 0, len(externalIDs))
+	for _, id := range externalIDs {
+		if id = normalizeExternalID(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return []*model.ExternalID{}, nil
+	}
+	return c.repo.LookupExternalIDs(ctx, normalizeProvider(provider), ids)
+}
+
+// PutExternalID sets the identifier of a movie at a provider on behalf of
+// the user actorID, replacing the one it had.
+func (c *Controller) PutExternalID(ctx context.Context, actorID string, metadataID int32, provider, externalID string) (*model.ExternalID, error) {
+	if _, err := c.get(ctx, metadataID); err != nil {
+		return nil, err
+	}
+
+	id := &model.ExternalID{
+		Provider:   normalizeProvider(provider),
+		ExternalID: normalizeExternalID(externalID),
+		MetadataID: metadataID,
+	}
+	err := c.repo.PutExternalID(ctx, id, actorID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrNotFound
+	} else if isExternalIDConflict(err) {
+		return nil, ErrExternalIDExists
+	} else if err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// DeleteExternalID removes the identifier of a movie at a provider on
+// behalf of the user actorID.
+func (c *Controller) DeleteExternalID(ctx context.Context, actorID string, metadataID int32, provider string) error {
+	err := c.repo.DeleteExternalID(ctx, metadataID, normalizeProvider(provider), actorID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrExternalIDNotFound
+	}
+	return err
+}
+
+func normalizeProvider(provider string) string {
+	return strings.ToLower(strings.TrimSpace(provider))
+}
+
+// normalizeExternalID strips the whitespace around an identifier. Rating
+// events name movies by identifiers normalized the same way.
+func normalizeExternalID(externalID string) string {
+	return strings.TrimSpace(externalID)
+}
+
+// isExternalIDConflict reports whether err is caused by an external ID
+// mapped to another movie.
+func isExternalIDConflict(err error) bool {
+	return apperr.Is(err, apperr.AlreadyExists) && apperr.ConstraintName(err) == "external_ids_pkey"
+}
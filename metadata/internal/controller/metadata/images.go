@@ -0,0 +1,227 @@
+package metadata
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/abhishek622/moviedock/metadata/internal/imaging"
+	"github.com/abhishek622/moviedock/metadata/internal/repository"
+	"github.com/abhishek622/moviedock/metadata/internal/storage"
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+)
+
+// MaxImageSize is the largest image upload accepted, in bytes.
+const MaxImageSize = 10 << 20
+
+var (
+	// ErrImageNotFound is returned when a requested image is not found.
+	ErrImageNotFound = apperr.New(apperr.NotFound, "image not found")
+	// ErrInvalidImageKind is returned for image kinds other than poster and
+	// backdrop.
+	ErrInvalidImageKind = apperr.New(apperr.InvalidArgument, "image kind must be poster or backdrop")
+	// ErrImageTooLarge is returned for uploads over MaxImageSize.
+	ErrImageTooLarge = apperr.New(apperr.InvalidArgument, fmt.Sprintf("image must not exceed %d MiB", MaxImageSize>>20))
+	// ErrUnsupportedImage is returned for uploads that are not JPEG, PNG or
+	// GIF images.
+	ErrUnsupportedImage = apperr.New(apperr.InvalidArgument, "image must be a JPEG, PNG or GIF")
+	// ErrInvalidImage is returned for uploads that cannot be decoded or
+	// whose dimensions are too large.
+	ErrInvalidImage = apperr.New(apperr.InvalidArgument, "invalid image")
+	// ErrNoImageStorage is returned for image operations when no image
+	// storage is configured.
+	ErrNoImageStorage = apperr.New(apperr.Internal, "image storage not configured")
+)
+
+// imageWidths lists the widths images of each kind are resized to.
+var imageWidths = map[model.ImageKind][]int{
+	model.ImageKindPoster:   {185, 342, 780},
+	model.ImageKindBackdrop: {300, 780, 1280},
+}
+
+// UploadImage stores an image of a movie along with variants resized to the
+// standard widths of its kind. The format is detected from the content.
+func (c *Controller) UploadImage(ctx context.Context, metadataID int32, kind model.ImageKind, data []byte) (*model.Image, error) {
+	if c.store == nil {
+		return nil, ErrNoImageStorage
+	}
+	if !kind.Valid() {
+		return nil, ErrInvalidImageKind
+	}
+	if len(data) > MaxImageSize {
+		return nil, ErrImageTooLarge
+	}
+	if _, err := c.get(ctx, metadataID); err != nil {
+		return nil, err
+	}
+
+	src, contentType, err := imaging.Decode(data)
+	if errors.Is(err, imaging.ErrUnsupportedFormat) {
+		return nil, ErrUnsupportedImage
+	} else if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	// Keys are unique per upload so that stored files can be cached forever.
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	prefix := fmt.Sprintf("movies/%d/%s/%s/", metadataID, kind, hex.EncodeToString(suffix))
+
+	bounds := src.Bounds()
+	img := &model.Image{
+		MetadataID:  metadataID,
+		Kind:        kind,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Original: model.ImageVariant{
+			Key:    prefix + "original" + imaging.Extension(contentType),
+			Width:  bounds.Dx(),
+			Height: bounds.Dy(),
+		},
+		Sizes: map[string]model.ImageVariant{},
+	}
+
+	var stored []string
+	cleanup := func() {
+		c.deleteFiles(context.WithoutCancel(ctx), stored...)
+	}
+
+	if err := c.store.Put(ctx, img.Original.Key, data, contentType); err != nil {
+		log.Printf("Failed to store image: %v", err)
+		return nil, err
+	}
+	stored = append(stored, img.Original.Key)
+
+	for _, width := range imageWidths[kind] {
+		resized := imaging.Resize(src, width)
+		encoded, encodedType, err := imaging.Encode(resized, contentType)
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+
+		name := fmt.Sprintf("w%d", width)
+		v := model.ImageVariant{
+			Key:    prefix + name + imaging.Extension(encodedType),
+			Width:  resized.Bounds().Dx(),
+			Height: resized.Bounds().Dy(),
+		}
+		if err := c.store.Put(ctx, v.Key, encoded, encodedType); err != nil {
+			log.Printf("Failed to store image: %v", err)
+			cleanup()
+			return nil, err
+		}
+		stored = append(stored, v.Key)
+		img.Sizes[name] = v
+	}
+
+	res, err := c.repo.CreateImage(ctx, img)
+	if err != nil {
+		log.Printf("Failed to create image: %v", err)
+		cleanup()
+		return nil, err
+	}
+	c.setImageURLs(res)
+	return res, nil
+}
+
+// ListImages returns the images of a movie.
+func (c *Controller) ListImages(ctx context.Context, metadataID int32) ([]*model.Image, error) {
+	if _, err := c.get(ctx, metadataID); err != nil {
+		return nil, err
+	}
+	images, err := c.repo.ListImages(ctx, []int32{metadataID})
+	if err != nil {
+		return nil, err
+	}
+	c.setImageURLs(images...)
+	return images, nil
+}
+
+// DeleteImage removes an image of a movie and its stored files.
+func (c *Controller) DeleteImage(ctx context.Context, metadataID, imageID int32) error {
+	if c.store == nil {
+		return ErrNoImageStorage
+	}
+	img, err := c.repo.DeleteImage(ctx, metadataID, imageID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrImageNotFound
+	} else if err != nil {
+		log.Printf("Failed to delete image: %v", err)
+		return err
+	}
+
+	keys := []string{img.Original.Key}
+	for _, v := range img.Sizes {
+		keys = append(keys, v.Key)
+	}
+	c.deleteFiles(ctx, keys...)
+	return nil
+}
+
+// OpenImage returns a stored image file by key.
+func (c *Controller) OpenImage(ctx context.Context, key string) (*storage.Object, error) {
+	if c.store == nil {
+		return nil, ErrNoImageStorage
+	}
+	obj, err := c.store.Open(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrImageNotFound
+	}
+	return obj, err
+}
+
+// deleteFiles removes stored image files. Failures only leave orphaned
+// files behind, so they are logged rather than returned.
+func (c *Controller) deleteFiles(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := c.store.Delete(ctx, key); err != nil {
+			log.Printf("Failed to delete image file %s: %v", key, err)
+		}
+	}
+}
+
+// attachImages sets the images of movies.
+func (c *Controller) attachImages(ctx context.Context, movies ...*model.Metadata) error {
+	if len(movies) == 0 {
+		return nil
+	}
+
+	ids := make([]int32, len(movies))
+	byID := make(map[int32]*model.Metadata, len(movies))
+	for i, m := range movies {
+		ids[i] = m.MetadataID
+		byID[m.MetadataID] = m
+	}
+	images, err := c.repo.ListImages(ctx, ids)
+	if err != nil {
+		return err
+	}
+	c.setImageURLs(images...)
+	for _, img := range images {
+		if m, ok := byID[img.MetadataID]; ok {
+			m.Images = append(m.Images, img)
+		}
+	}
+	return nil
+}
+
+// setImageURLs sets the URLs clients fetch images from.
+func (c *Controller) setImageURLs(images ...*model.Image) {
+	if c.store == nil {
+		return
+	}
+	for _, img := range images {
+		img.Original.URL = c.store.URL(img.Original.Key)
+		for name, v := range img.Sizes {
+			v.URL = c.store.URL(v.Key)
+			img.Sizes[name] = v
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+
+	"github.com/abhishek622/moviedock/metadata/internal/catalog"
+	"github.com/abhishek622/moviedock/metadata/internal/repository"
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	// ErrMovieExists is reported for imported rows matching a movie of the
+	// catalog by external ID when not upserting.
+	ErrMovieExists = apperr.New(apperr.AlreadyExists, "movie with these external ids already exists")
+	// ErrMovieDeleted is reported for imported rows matching a deleted movie
+	// by external ID, which has to be restored to be updated.
+	ErrMovieDeleted = apperr.New(apperr.Conflict, "movie with these external ids is deleted")
+)
+
+const (
+	importBatchSize = 500
+	// maxImportErrors caps the row errors listed in an import report.
+	maxImportErrors = 100
+)
+
+var validate = validator.New()
+
+// Import loads the movies read from r into the catalog in batches within
+// one transaction. Rows are matched to the movies of the catalog by any of
+// their external IDs. Rows failing validation are reported and the import
+// is rolled back if any row fails, as it is on dry runs. Changes are
+// recorded on behalf of the user actorID.
+func (c *Controller) Import(ctx context.Context, actorID string, r catalog.Reader, opts model.ImportOptions) (*model.ImportReport, error) {
+	report := &model.ImportReport{DryRun: opts.DryRun, Errors: []model.ImportRowError{}}
+	fail := func(line int, m *model.Metadata, err error) {
+		report.Failed++
+		if len(report.Errors) < maxImportErrors {
+			e := model.ImportRowError{Line: line, Error: err.Error()}
+			if m != nil {
+				e.ExternalIDs = m.ExternalIDs
+			}
+			report.Errors = append(report.Errors, e)
+		}
+	}
+
+	err := c.repo.Import(ctx, actorID, func(im repository.Importer) (bool, error) {
+		b := &importBatch{im: im, opts: opts, report: report, fail: fail, targets: map[int32]int{}}
+		seen := map[model.ExternalID]int{}
+	rows:
+		for {
+			m, line, err := r.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			var rowErr *catalog.RowError
+			if errors.As(err, &rowErr) {
+				report.Rows++
+				fail(rowErr.Line, nil, rowErr.Err)
+				continue
+			} else if err != nil {
+				return false, apperr.Wrap(apperr.InvalidArgument, "invalid import file: "+err.Error(), err)
+			}
+
+			report.Rows++
+			normalize(m)
+			if err := validate.Struct(m); err != nil {
+				fail(line, m, err)
+				continue
+			}
+			for provider, id := range m.ExternalIDs {
+				key := model.ExternalID{Provider: provider, ExternalID: id}
+				if first, ok := seen[key]; ok {
+					fail(line, m, fmt.Errorf("duplicate %s id %q, first seen on line %d", provider, id, first))
+					continue rows
+				}
+				seen[key] = line
+			}
+
+			if err := b.add(ctx, m, line); err != nil {
+				return false, err
+			}
+		}
+		if err := b.flush(ctx); err != nil {
+			return false, err
+		}
+		return !opts.DryRun && report.Failed == 0, nil
+	})
+	if err != nil {
+		log.Printf("Failed to import metadata: %v", err)
+		return nil, err
+	}
+	slices.SortStableFunc(report.Errors, func(a, b model.ImportRowError) int {
+		return a.Line - b.Line
+	})
+	return report, nil
+}
+
+// importBatch buffers the rows of an import until a batch is full.
+type importBatch struct {
+	im     repository.Importer
+	opts   model.ImportOptions
+	report *model.ImportReport
+	fail   func(line int, m *model.Metadata, err error)
+	movies []*model.Metadata
+	lines  []int
+	// targets maps the movies of the catalog updated by the import to the
+	// line updating them.
+	targets map[int32]int
+	// aborted is set once a write failed, after which the transaction only
+	// accepts a rollback and rows are only validated.
+	aborted bool
+}
+
+func (b *importBatch) add(ctx context.Context, m *model.Metadata, line int) error {
+	b.movies = append(b.movies, m)
+	b.lines = append(b.lines, line)
+	if len(b.movies) < importBatchSize {
+		return nil
+	}
+	return b.flush(ctx)
+}
+
+// flush matches the buffered rows to the movies already in the catalog and
+// writes them unless a row failed before.
+func (b *importBatch) flush(ctx context.Context) error {
+	defer func() {
+		b.movies, b.lines = b.movies[:0], b.lines[:0]
+	}()
+	if len(b.movies) == 0 || b.aborted {
+		return nil
+	}
+
+	var refs []*model.ExternalID
+	for _, m := range b.movies {
+		for provider, id := range m.ExternalIDs {
+			refs = append(refs, &model.ExternalID{Provider: provider, ExternalID: id})
+		}
+	}
+	existing, err := b.im.ExistingIDs(ctx, refs)
+	if err != nil {
+		return err
+	}
+	matches := make(map[model.ExternalID]int32, len(existing))
+	deleted := map[int32]bool{}
+	for _, e := range existing {
+		matches[model.ExternalID{Provider: e.Mapping.Provider, ExternalID: e.Mapping.ExternalID}] = e.Mapping.MetadataID
+		if e.Deleted {
+			deleted[e.Mapping.MetadataID] = true
+		}
+	}
+
+	batch := make([]*model.Metadata, 0, len(b.movies))
+	var created, updated int
+rows:
+	for i, m := range b.movies {
+		var match int32
+		for provider, id := range m.ExternalIDs {
+			mid, ok := matches[model.ExternalID{Provider: provider, ExternalID: id}]
+			if !ok {
+				continue
+			}
+			if match != 0 && mid != match {
+				b.fail(b.lines[i], m, errors.New("external ids match different movies"))
+				continue rows
+			}
+			match = mid
+		}
+
+		switch {
+		case match == 0:
+			created++
+		case deleted[match]:
+			b.fail(b.lines[i], m, ErrMovieDeleted)
+			continue
+		case !b.opts.Upsert:
+			b.fail(b.lines[i], m, ErrMovieExists)
+			continue
+		default:
+			if first, ok := b.targets[match]; ok {
+				b.fail(b.lines[i], m, fmt.Errorf("matches the same movie as line %d", first))
+				continue
+			}
+			b.targets[match] = b.lines[i]
+			m.MetadataID = match
+			updated++
+		}
+		batch = append(batch, m)
+	}
+	b.report.Created += created
+	b.report.Updated += updated
+	if b.report.Failed > 0 {
+		return nil
+	}
+
+	if err := b.im.Write(ctx, batch); err != nil {
+		if apperr.KindOf(err) == apperr.Internal {
+			return err
+		}
+		b.aborted = true
+		b.fail(b.lines[0], nil, fmt.Errorf("rows on lines %d to %d rejected: %s", b.lines[0], b.lines[len(b.lines)-1], apperr.Message(err)))
+	}
+	return nil
+}
+
+// Export writes every movie of the catalog to w.
+func (c *Controller) Export(ctx context.Context, w catalog.Writer) error {
+	if err := c.repo.Export(ctx, w.Write); err != nil {
+		log.Printf("Failed to export metadata: %v", err)
+		return err
+	}
+	return w.Flush()
+}
@@ -0,0 +1,151 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/abhishek622/moviedock/metadata/internal/repository"
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/pagination"
+)
+
+var (
+	// ErrPersonNotFound is returned when a requested person is not found.
+	ErrPersonNotFound = apperr.New(apperr.NotFound, "person not found")
+	// ErrCreditNotFound is returned when a requested credit is not found.
+	ErrCreditNotFound = apperr.New(apperr.NotFound, "credit not found")
+	// ErrCharacterNotActor is returned when a character is given for a credit other than an actor's.
+	ErrCharacterNotActor = apperr.New(apperr.InvalidArgument, "only actors can play a character")
+	// ErrCreditExists is returned when a person already has the same credit in a movie.
+	ErrCreditExists = apperr.New(apperr.AlreadyExists, "credit already exists")
+)
+
+// GetPerson returns a person by id.
+func (c *Controller) GetPerson(ctx context.Context, id int32) (*model.Person, error) {
+	p, err := c.repo.GetPerson(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrPersonNotFound
+	}
+	return p, err
+}
+
+// ListPeople returns a page of people matching filter.
+func (c *Controller) ListPeople(ctx context.Context, filter model.PersonFilter) ([]*model.Person, pagination.Info, error) {
+	filter.Query = strings.TrimSpace(filter.Query)
+	return c.repo.ListPeople(ctx, filter)
+}
+
+// CreatePerson adds a person.
+func (c *Controller) CreatePerson(ctx context.Context, p *model.Person) (*model.Person, error) {
+	p.Name = strings.TrimSpace(p.Name)
+	return c.repo.CreatePerson(ctx, p)
+}
+
+// UpdatePerson replaces the details of a person.
+func (c *Controller) UpdatePerson(ctx context.Context, id int32, p *model.Person) (*model.Person, error) {
+	p.Name = strings.TrimSpace(p.Name)
+	res, err := c.repo.UpdatePerson(ctx, id, p)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrPersonNotFound
+	}
+	return res, err
+}
+
+// DeletePerson removes a person and all their credits.
+func (c *Controller) DeletePerson(ctx context.Context, id int32) error {
+	if err := c.repo.DeletePerson(ctx, id); errors.Is(err, repository.ErrNotFound) {
+		return ErrPersonNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetMovieCredits returns the cast and crew of a movie.
+func (c *Controller) GetMovieCredits(ctx context.Context, metadataID int32) (*model.MovieCredits, error) {
+	if _, err := c.get(ctx, metadataID); err != nil {
+		return nil, err
+	}
+
+	credits, err := c.repo.ListMovieCredits(ctx, metadataID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &model.MovieCredits{Cast: []*model.Credit{}, Crew: []*model.Credit{}}
+	for _, credit := range credits {
+		if credit.Role == model.CreditRoleActor {
+			res.Cast = append(res.Cast, credit)
+		} else {
+			res.Crew = append(res.Crew, credit)
+		}
+	}
+	return res, nil
+}
+
+// GetFilmography returns the credits of a person across movies.
+func (c *Controller) GetFilmography(ctx context.Context, personID int32) ([]*model.Credit, error) {
+	if _, err := c.GetPerson(ctx, personID); err != nil {
+		return nil, err
+	}
+	return c.repo.ListPersonCredits(ctx, personID)
+}
+
+// AddCredit credits a person in a movie.
+func (c *Controller) AddCredit(ctx context.Context, metadataID int32, credit *model.Credit) (*model.Credit, error) {
+	if err := c.prepareCredit(ctx, metadataID, credit); err != nil {
+		return nil, err
+	}
+
+	res, err := c.repo.CreateCredit(ctx, credit)
+	if apperr.Is(err, apperr.AlreadyExists) {
+		return nil, ErrCreditExists
+	}
+	return res, err
+}
+
+// UpdateCredit replaces a credit of a movie.
+func (c *Controller) UpdateCredit(ctx context.Context, metadataID, creditID int32, credit *model.Credit) (*model.Credit, error) {
+	if err := c.prepareCredit(ctx, metadataID, credit); err != nil {
+		return nil, err
+	}
+
+	credit.CreditID = creditID
+	res, err := c.repo.UpdateCredit(ctx, credit)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrCreditNotFound
+	} else if apperr.Is(err, apperr.AlreadyExists) {
+		return nil, ErrCreditExists
+	}
+	return res, err
+}
+
+// DeleteCredit removes a credit from a movie.
+func (c *Controller) DeleteCredit(ctx context.Context, metadataID, creditID int32) error {
+	if err := c.repo.DeleteCredit(ctx, metadataID, creditID); errors.Is(err, repository.ErrNotFound) {
+		return ErrCreditNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// prepareCredit checks that the movie and person of a credit exist, so that
+// callers get a not found error rather than a constraint violation.
+func (c *Controller) prepareCredit(ctx context.Context, metadataID int32, credit *model.Credit) error {
+	credit.MetadataID = metadataID
+	credit.Character = strings.TrimSpace(credit.Character)
+	if credit.Character != "" && credit.Role != model.CreditRoleActor {
+		return ErrCharacterNotActor
+	}
+
+	if _, err := c.get(ctx, metadataID); err != nil {
+		return err
+	}
+	if _, err := c.GetPerson(ctx, credit.PersonID); err != nil {
+		return err
+	}
+	return nil
+}
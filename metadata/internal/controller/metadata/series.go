@@ -0,0 +1,190 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abhishek622/moviedock/metadata/internal/repository"
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/pagination"
+)
+
+var (
+	// ErrSeriesNotFound is returned when a requested series is not found.
+	ErrSeriesNotFound = apperr.New(apperr.NotFound, "series not found")
+	// ErrSeasonNotFound is returned when a requested season is not found.
+	ErrSeasonNotFound = apperr.New(apperr.NotFound, "season not found")
+	// ErrEpisodeNotFound is returned when a requested episode is not found.
+	ErrEpisodeNotFound = apperr.New(apperr.NotFound, "episode not found")
+	// ErrSeasonExists is returned when a series already has a season with the same number.
+	ErrSeasonExists = apperr.New(apperr.AlreadyExists, "season number already exists")
+	// ErrEpisodeExists is returned when a season already has an episode with the same number.
+	ErrEpisodeExists = apperr.New(apperr.AlreadyExists, "episode number already exists")
+)
+
+// GetSeries returns a series with its seasons and their episodes.
+func (c *Controller) GetSeries(ctx context.Context, id int32) (*model.Series, error) {
+	series, err := c.repo.GetSeries(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrSeriesNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	seasons, err := c.repo.ListSeasons(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	episodes, err := c.repo.ListSeriesEpisodes(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	bySeason := make(map[int32]*model.Season, len(seasons))
+	for _, s := range seasons {
+		s.Episodes = []*model.Episode{}
+		bySeason[s.SeasonID] = s
+	}
+	for _, e := range episodes {
+		if s, ok := bySeason[e.SeasonID]; ok {
+			s.Episodes = append(s.Episodes, e)
+		}
+	}
+	series.Seasons = seasons
+	return series, nil
+}
+
+// ListSeries returns a page of series without their seasons.
+func (c *Controller) ListSeries(ctx context.Context, page pagination.Request) ([]*model.Series, pagination.Info, error) {
+	return c.repo.ListSeries(ctx, page)
+}
+
+// CreateSeries adds a series.
+func (c *Controller) CreateSeries(ctx context.Context, s *model.Series) (*model.Series, error) {
+	if s.Status == "" {
+		s.Status = model.SeriesStatusReturning
+	}
+	return c.repo.CreateSeries(ctx, s)
+}
+
+// UpdateSeries replaces the details of a series.
+func (c *Controller) UpdateSeries(ctx context.Context, id int32, s *model.Series) (*model.Series, error) {
+	if s.Status == "" {
+		s.Status = model.SeriesStatusReturning
+	}
+	res, err := c.repo.UpdateSeries(ctx, id, s)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrSeriesNotFound
+	}
+	return res, err
+}
+
+// DeleteSeries removes a series with its seasons and episodes.
+func (c *Controller) DeleteSeries(ctx context.Context, id int32) error {
+	if err := c.repo.DeleteSeries(ctx, id); errors.Is(err, repository.ErrNotFound) {
+		return ErrSeriesNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetSeason returns a season with its episodes.
+func (c *Controller) GetSeason(ctx context.Context, id int32) (*model.Season, error) {
+	season, err := c.repo.GetSeason(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrSeasonNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	season.Episodes, err = c.repo.ListEpisodes(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return season, nil
+}
+
+// AddSeason adds a season to a series.
+func (c *Controller) AddSeason(ctx context.Context, seriesID int32, s *model.Season) (*model.Season, error) {
+	if _, err := c.repo.GetSeries(ctx, seriesID); errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrSeriesNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	s.SeriesID = seriesID
+	res, err := c.repo.CreateSeason(ctx, s)
+	if apperr.Is(err, apperr.AlreadyExists) {
+		return nil, ErrSeasonExists
+	}
+	return res, err
+}
+
+// UpdateSeason replaces the details of a season.
+func (c *Controller) UpdateSeason(ctx context.Context, id int32, s *model.Season) (*model.Season, error) {
+	res, err := c.repo.UpdateSeason(ctx, id, s)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrSeasonNotFound
+	} else if apperr.Is(err, apperr.AlreadyExists) {
+		return nil, ErrSeasonExists
+	}
+	return res, err
+}
+
+// DeleteSeason removes a season with its episodes.
+func (c *Controller) DeleteSeason(ctx context.Context, id int32) error {
+	if err := c.repo.DeleteSeason(ctx, id); errors.Is(err, repository.ErrNotFound) {
+		return ErrSeasonNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetEpisode returns an episode.
+func (c *Controller) GetEpisode(ctx context.Context, id int32) (*model.Episode, error) {
+	res, err := c.repo.GetEpisode(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrEpisodeNotFound
+	}
+	return res, err
+}
+
+// AddEpisode adds an episode to a season.
+func (c *Controller) AddEpisode(ctx context.Context, seasonID int32, e *model.Episode) (*model.Episode, error) {
+	if _, err := c.repo.GetSeason(ctx, seasonID); errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrSeasonNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	e.SeasonID = seasonID
+	res, err := c.repo.CreateEpisode(ctx, e)
+	if apperr.Is(err, apperr.AlreadyExists) {
+		return nil, ErrEpisodeExists
+	}
+	return res, err
+}
+
+// UpdateEpisode replaces the details of an episode.
+func (c *Controller) UpdateEpisode(ctx context.Context, id int32, e *model.Episode) (*model.Episode, error) {
+	res, err := c.repo.UpdateEpisode(ctx, id, e)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrEpisodeNotFound
+	} else if apperr.Is(err, apperr.AlreadyExists) {
+		return nil, ErrEpisodeExists
+	}
+	return res, err
+}
+
+// DeleteEpisode removes an episode.
+func (c *Controller) DeleteEpisode(ctx context.Context, id int32) error {
+	if err := c.repo.DeleteEpisode(ctx, id); errors.Is(err, repository.ErrNotFound) {
+		return ErrEpisodeNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
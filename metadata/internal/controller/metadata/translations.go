@@ -0,0 +1,113 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/abhishek622/moviedock/metadata/internal/repository"
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/locale"
+)
+
+var (
+	// ErrInvalidLocale is returned for malformed locales.
+	ErrInvalidLocale = apperr.New(apperr.InvalidArgument, "invalid locale")
+	// ErrTranslationNotFound is returned when a movie has no translation in
+	// a locale.
+	ErrTranslationNotFound = apperr.New(apperr.NotFound, "translation not found")
+)
+
+// ListTranslations returns the translations of a movie.
+func (c *Controller) ListTranslations(ctx context.Context, metadataID int32) ([]*model.Translation, error) {
+	if _, err := c.get(ctx, metadataID); err != nil {
+		return nil, err
+	}
+	return c.repo.ListTranslations(ctx, metadataID)
+}
+
+// PutTranslation sets the title and description of a movie in a locale.
+func (c *Controller) PutTranslation(ctx context.Context, metadataID int32, loc string, req *model.PutTranslationRequest) (*model.Translation, error) {
+	tag, ok := locale.Canonical(strings.TrimSpace(loc))
+	if !ok {
+		return nil, ErrInvalidLocale
+	}
+	if _, err := c.get(ctx, metadataID); err != nil {
+		return nil, err
+	}
+
+	t := &model.Translation{
+		MetadataID:  metadataID,
+		Locale:      tag,
+		Title:       strings.TrimSpace(req.Title),
+		Description: strings.TrimSpace(req.Description),
+	}
+	if err := c.repo.PutTranslation(ctx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// DeleteTranslation removes the translation of a movie in a locale.
+func (c *Controller) DeleteTranslation(ctx context.Context, metadataID int32, loc string) error {
+	tag, ok := locale.Canonical(strings.TrimSpace(loc))
+	if !ok {
+		return ErrInvalidLocale
+	}
+	err := c.repo.DeleteTranslation(ctx, metadataID, tag)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrTranslationNotFound
+	}
+	return err
+}
+
+// localize replaces the titles and descriptions of movies by translations
+// for the locale preferences of the request. Preferred locales are tried
+// in order, each followed by its less specific forms, and the original
+// text is kept once a locale in its language comes up. Translations
+// without a description keep the original one.
+func (c *Controller) localize(ctx context.Context, movies ...*model.Metadata) error {
+	prefs := locale.FromContext(ctx)
+	if len(prefs) == 0 || len(movies) == 0 {
+		return nil
+	}
+
+	chain := locale.Fallbacks(prefs)
+	ids := make([]int32, len(movies))
+	for i, m := range movies {
+		ids[i] = m.MetadataID
+	}
+	translations, err := c.repo.FindTranslations(ctx, ids, chain)
+	if err != nil {
+		return err
+	}
+	if len(translations) == 0 {
+		return nil
+	}
+
+	type key struct {
+		id     int32
+		locale string
+	}
+	byLocale := make(map[key]*model.Translation, len(translations))
+	for _, t := range translations {
+		byLocale[key{t.MetadataID, t.Locale}] = t
+	}
+
+	for _, m := range movies {
+		for _, tag := range chain {
+			if t, ok := byLocale[key{m.MetadataID, tag}]; ok {
+				m.Title, m.Locale = t.Title, t.Locale
+				if t.Description != "" {
+					m.Description = t.Description
+				}
+				break
+			}
+			if locale.Language(tag) == m.OriginalLanguage {
+				break
+			}
+		}
+	}
+	return nil
+}
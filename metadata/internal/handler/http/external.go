@@ -0,0 +1,71 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/authz"
+	"github.com/gin-gonic/gin"
+)
+
+// GetMetadataByExternalID returns the movie with an identifier at a
+// provider, e.g. /api/v1/metadata/external/imdb/tt0133093.
+func (h *Handler) GetMetadataByExternalID(c *gin.Context) {
+	m, err := h.ctrl.GetByExternalID(c.Request.Context(), c.Param("provider"), c.Param("external_id"))
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, m)
+}
+
+// LookupExternalIDs resolves identifiers at a provider to metadata IDs. The
+// identifiers are given as repeated ids query parameters, so that they may
+// contain any character, e.g. ?ids=tt0133093&ids=tt0111161.
+func (h *Handler) LookupExternalIDs(c *gin.Context) {
+	mappings, err := h.ctrl.LookupExternalIDs(c.Request.Context(), c.Param("provider"), c.QueryArray("ids"))
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"external_ids": mappings})
+}
+
+func (h *Handler) PutExternalID(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req model.PutExternalIDRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+	mapping, err := h.ctrl.PutExternalID(c.Request.Context(), claims.UserID, id, c.Param("provider"), req.ExternalID)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, mapping)
+}
+
+func (h *Handler) DeleteExternalID(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+	if err := h.ctrl.DeleteExternalID(c.Request.Context(), claims.UserID, id, c.Param("provider")); err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
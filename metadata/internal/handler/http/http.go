@@ -1,16 +1,22 @@
 package http
 
 import (
-	"errors"
-	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/abhishek622/moviedock/metadata/internal/controller/metadata"
 	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/authz"
+	"github.com/abhishek622/moviedock/pkg/pagination"
+	usermodel "github.com/abhishek622/moviedock/user/pkg/model"
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 )
 
+var validate = validator.New()
+
 // Handler defines a movie metadata HTTP handler.
 type Handler struct {
 	ctrl *metadata.Controller
@@ -31,11 +37,79 @@ func (h *Handler) RegisterRoutes(router *gin.Engine) {
 	// API v1 routes
 	v1 := router.Group("/api/v1/metadata")
 	{
-		v1.POST("", h.CreateMetadata)
 		v1.GET("", h.ListMetadata)
 		v1.GET("/:id", h.GetMetadata)
-		v1.PUT("/:id", h.UpdateMetadata)
-		v1.DELETE("/:id", h.DeleteMetadata)
+
+		// Catalog writes are restricted to admins
+		admin := authz.Require(authz.RequireRole(usermodel.RoleAdmin))
+		v1.POST("", admin, h.CreateMetadata)
+		v1.PUT("/:id", admin, h.UpdateMetadata)
+		v1.DELETE("/:id", admin, h.DeleteMetadata)
+		v1.POST("/:id/restore", admin, h.RestoreMetadata)
+		v1.GET("/:id/history", admin, h.GetMetadataHistory)
+
+		v1.POST("/import", admin, h.ImportMetadata)
+		v1.GET("/export", admin, h.ExportMetadata)
+
+		v1.GET("/external/:provider/:external_id", h.GetMetadataByExternalID)
+		v1.PUT("/:id/external_ids/:provider", admin, h.PutExternalID)
+		v1.DELETE("/:id/external_ids/:provider", admin, h.DeleteExternalID)
+
+		v1.GET("/:id/translations", h.ListTranslations)
+		v1.PUT("/:id/translations/:locale", admin, h.PutTranslation)
+		v1.DELETE("/:id/translations/:locale", admin, h.DeleteTranslation)
+
+		v1.GET("/:id/images", h.ListImages)
+		v1.POST("/:id/images", admin, h.UploadImage)
+		v1.DELETE("/:id/images/:image_id", admin, h.DeleteImage)
+
+		v1.GET("/:id/credits", h.GetMovieCredits)
+		v1.POST("/:id/credits", admin, h.AddCredit)
+		v1.PUT("/:id/credits/:credit_id", admin, h.UpdateCredit)
+		v1.DELETE("/:id/credits/:credit_id", admin, h.DeleteCredit)
+	}
+
+	router.GET("/api/v1/genres", h.ListGenres)
+	router.GET("/images/*key", h.ServeImage)
+	router.GET("/api/v1/external_ids/:provider", h.LookupExternalIDs)
+
+	people := router.Group("/api/v1/people")
+	{
+		people.GET("", h.ListPeople)
+		people.GET("/:id", h.GetPerson)
+		people.GET("/:id/credits", h.GetFilmography)
+
+		admin := authz.Require(authz.RequireRole(usermodel.RoleAdmin))
+		people.POST("", admin, h.CreatePerson)
+		people.PUT("/:id", admin, h.UpdatePerson)
+		people.DELETE("/:id", admin, h.DeletePerson)
+	}
+
+	admin := authz.Require(authz.RequireRole(usermodel.RoleAdmin))
+
+	series := router.Group("/api/v1/series")
+	{
+		series.GET("", h.ListSeries)
+		series.GET("/:id", h.GetSeries)
+		series.POST("", admin, h.CreateSeries)
+		series.PUT("/:id", admin, h.UpdateSeries)
+		series.DELETE("/:id", admin, h.DeleteSeries)
+		series.POST("/:id/seasons", admin, h.AddSeason)
+	}
+
+	seasons := router.Group("/api/v1/seasons")
+	{
+		seasons.GET("/:id", h.GetSeason)
+		seasons.PUT("/:id", admin, h.UpdateSeason)
+		seasons.DELETE("/:id", admin, h.DeleteSeason)
+		seasons.POST("/:id/episodes", admin, h.AddEpisode)
+	}
+
+	episodes := router.Group("/api/v1/episodes")
+	{
+		episodes.GET("/:id", h.GetEpisode)
+		episodes.PUT("/:id", admin, h.UpdateEpisode)
+		episodes.DELETE("/:id", admin, h.DeleteEpisode)
 	}
 }
 
@@ -48,28 +122,28 @@ func (h *Handler) GetMetadata(c *gin.Context) {
 
 	m, err := h.ctrl.Get(c.Request.Context(), int32(id))
 	if err != nil {
-		if errors.Is(err, metadata.ErrNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "metadata not found"})
-			return
-		}
-		log.Printf("Failed to get metadata: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		apperr.Respond(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, m)
 }
 
 func (h *Handler) CreateMetadata(c *gin.Context) {
-	var req *model.Metadata
+	var req model.Metadata
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	metadata, err := h.ctrl.Create(c.Request.Context(), req)
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+	metadata, err := h.ctrl.Create(c.Request.Context(), claims.UserID, &req)
 	if err != nil {
-		log.Printf("Failed to create metadata: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create metadata"})
+		apperr.Respond(c, err)
 		return
 	}
 
@@ -89,14 +163,15 @@ func (h *Handler) UpdateMetadata(c *gin.Context) {
 		return
 	}
 
-	m, err := h.ctrl.Update(c.Request.Context(), int32(id), &req)
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+	m, err := h.ctrl.Update(c.Request.Context(), claims.UserID, int32(id), &req)
 	if err != nil {
-		if errors.Is(err, metadata.ErrNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "metadata not found"})
-			return
-		}
-		log.Printf("Failed to update metadata: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update metadata"})
+		apperr.Respond(c, err)
 		return
 	}
 
@@ -110,36 +185,157 @@ func (h *Handler) DeleteMetadata(c *gin.Context) {
 		return
 	}
 
-	if err := h.ctrl.Delete(c.Request.Context(), int32(id)); err != nil {
-		if err == metadata.ErrNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "metadata not found"})
-			return
-		}
-		log.Printf("Failed to delete metadata: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete metadata"})
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+	if err := h.ctrl.Delete(c.Request.Context(), claims.UserID, int32(id)); err != nil {
+		apperr.Respond(c, err)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
-func (h *Handler) ListMetadata(c *gin.Context) {
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+// RestoreMetadata undeletes movie metadata.
+func (h *Handler) RestoreMetadata(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+	m, err := h.ctrl.Restore(c.Request.Context(), claims.UserID, id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, m)
+}
+
+// GetMetadataHistory returns a page of the changes of movie metadata, most
+// recent first.
+func (h *Handler) GetMetadataHistory(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
 		return
 	}
-	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	page, ok := pageRequest(c, "-created_at")
+	if !ok {
+		return
+	}
+
+	entries, info, err := h.ctrl.History(c.Request.Context(), id, page)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pageResponse("history", entries, info))
+}
+
+// ListMetadata returns a page of metadata sorted by id, title or release
+// date, optionally filtered by genre, release year range, original language
+// and a list of ids.
+func (h *Handler) ListMetadata(c *gin.Context) {
+	filter := model.MetadataFilter{
+		Genre:    c.Query("genre"),
+		Language: c.Query("language"),
+	}
+	var ok bool
+	if filter.Page, ok = pageRequest(c, "id", "title", "release_date"); !ok {
+		return
+	}
+	if filter.YearFrom, ok = queryInt(c, "year_from", 0); !ok {
 		return
 	}
-	metadata, err := h.ctrl.List(c.Request.Context(), limit, offset)
+	if filter.YearTo, ok = queryInt(c, "year_to", 0); !ok {
+		return
+	}
+	if filter.IDs, ok = queryIDs(c, "ids"); !ok {
+		return
+	}
+
+	metadata, info, err := h.ctrl.List(c.Request.Context(), filter)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pageResponse("metadata", metadata, info))
+}
+
+// ListGenres returns all genres.
+func (h *Handler) ListGenres(c *gin.Context) {
+	genres, err := h.ctrl.ListGenres(c.Request.Context())
 	if err != nil {
-		log.Printf("Failed to list metadata: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list metadata"})
+		apperr.Respond(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, metadata)
+	c.JSON(http.StatusOK, gin.H{"genres": genres})
+}
+
+// queryIDs parses a comma separated list of IDs from a query parameter,
+// responding with an error if it is malformed. It returns nil if the
+// parameter is absent.
+func queryIDs(c *gin.Context, name string) ([]int32, bool) {
+	v, ok := c.GetQuery(name)
+	if !ok {
+		return nil, true
+	}
+	ids := []int32{}
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+			return nil, false
+		}
+		ids = append(ids, int32(id))
+	}
+	if len(ids) > pagination.MaxLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many " + name})
+		return nil, false
+	}
+	return ids, true
+}
+
+// pageRequest parses the limit, sort and cursor query parameters of a
+// listing offering sorts, responding with an error if they are malformed.
+func pageRequest(c *gin.Context, sorts ...string) (pagination.Request, bool) {
+	page, err := pagination.FromQuery(c.Request.URL.Query(), 10, sorts...)
+	if err != nil {
+		apperr.Respond(c, err)
+		return pagination.Request{}, false
+	}
+	return page, true
+}
+
+// pageResponse returns the body of a page of a listing, with items under
+// name alongside the cursors of the pages around it.
+func pageResponse(name string, items any, info pagination.Info) gin.H {
+	res := gin.H{name: items}
+	if info.NextCursor != "" {
+		res["next_cursor"] = info.NextCursor
+	}
+	if info.PrevCursor != "" {
+		res["prev_cursor"] = info.PrevCursor
+	}
+	return res
+}
+
+// queryInt parses an integer query parameter, responding with an error if
+// it is malformed.
+func queryInt(c *gin.Context, name string, def int) (int, bool) {
+	v := c.Query(name)
+	if v == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+		return 0, false
+	}
+	return n, true
 }
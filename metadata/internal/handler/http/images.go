@@ -0,0 +1,109 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/abhishek622/moviedock/metadata/internal/controller/metadata"
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/gin-gonic/gin"
+)
+
+// maxUploadOverhead allows for the multipart framing and form fields sent
+// along with an uploaded image.
+const maxUploadOverhead = 1 << 20
+
+// UploadImage stores an image of a movie from the multipart form fields file
+// and kind, poster or backdrop.
+func (h *Handler) UploadImage(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, metadata.MaxImageSize+maxUploadOverhead)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": metadata.ErrImageTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "multipart form with an image file is required"})
+		return
+	}
+	defer file.Close()
+	if header.Size > metadata.MaxImageSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": metadata.ErrImageTooLarge.Error()})
+		return
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read image file"})
+		return
+	}
+
+	kind := model.ImageKind(c.PostForm("kind"))
+	img, err := h.ctrl.UploadImage(c.Request.Context(), id, kind, data)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, img)
+}
+
+// ListImages returns the images of a movie.
+func (h *Handler) ListImages(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	images, err := h.ctrl.ListImages(c.Request.Context(), id)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"images": images})
+}
+
+// DeleteImage removes an image of a movie.
+func (h *Handler) DeleteImage(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	imageID, ok := paramID(c, "image_id")
+	if !ok {
+		return
+	}
+
+	if err := h.ctrl.DeleteImage(c.Request.Context(), id, imageID); err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ServeImage streams a stored image file. Stored files never change, so
+// they may be cached indefinitely.
+func (h *Handler) ServeImage(c *gin.Context) {
+	obj, err := h.ctrl.OpenImage(c.Request.Context(), strings.TrimPrefix(c.Param("key"), "/"))
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+	defer obj.Body.Close()
+
+	c.DataFromReader(http.StatusOK, obj.Size, obj.ContentType, obj.Body, map[string]string{
+		"Cache-Control":          "public, max-age=31536000, immutable",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/abhishek622/moviedock/metadata/internal/catalog"
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/authz"
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize limits the size of an imported catalog file.
+const maxImportSize = 512 << 20
+
+// ImportMetadata loads a CSV or JSON Lines catalog from the request body.
+// The format is taken from the format query parameter or the content type.
+// Imports with rejected rows write nothing and are answered with 422 and
+// the report listing them.
+func (h *Handler) ImportMetadata(c *gin.Context) {
+	format, ok := requestFormat(c, "")
+	if !ok {
+		return
+	}
+	var opts model.ImportOptions
+	if opts.DryRun, ok = queryBool(c, "dry_run"); !ok {
+		return
+	}
+	if opts.Upsert, ok = queryBool(c, "upsert"); !ok {
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	r, err := catalog.NewReader(body, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+	report, err := h.ctrl.Import(c.Request.Context(), claims.UserID, r, opts)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	status := http.StatusOK
+	if report.Failed > 0 && !report.DryRun {
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, report)
+}
+
+// ExportMetadata streams the whole catalog as JSON Lines, or CSV with
+// format=csv.
+func (h *Handler) ExportMetadata(c *gin.Context) {
+	format, ok := requestFormat(c, catalog.FormatJSONL)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", `attachment; filename="catalog.`+string(format)+`"`)
+	c.Status(http.StatusOK)
+
+	w, _ := catalog.NewWriter(c.Writer, format)
+	if err := h.ctrl.Export(c.Request.Context(), w); err != nil {
+		// The status is already sent, the client sees a truncated file
+		log.Printf("Failed to export catalog: %v", err)
+		c.Abort()
+	}
+}
+
+// requestFormat returns the catalog format named by the format query
+// parameter, or else by the content type, or else def. It responds with an
+// error if there is none.
+func requestFormat(c *gin.Context, def catalog.Format) (catalog.Format, bool) {
+	if v := c.Query("format"); v != "" {
+		format, err := catalog.ParseFormat(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return "", false
+		}
+		return format, true
+	}
+	if format, ok := catalog.FormatOfContentType(c.ContentType()); ok {
+		return format, true
+	}
+	if def == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format parameter or a CSV or JSON Lines content type is required"})
+		return "", false
+	}
+	return def, true
+}
+
+func queryBool(c *gin.Context, name string) (bool, bool) {
+	v := c.Query(name)
+	if v == "" {
+		return false, true
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+		return false, false
+	}
+	return b, true
+}
@@ -0,0 +1,212 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/gin-gonic/gin"
+)
+
+// ListPeople returns a page of people sorted by name, optionally filtered
+// by name.
+func (h *Handler) ListPeople(c *gin.Context) {
+	filter := model.PersonFilter{Query: c.Query("q")}
+	var ok bool
+	if filter.Page, ok = pageRequest(c, "name"); !ok {
+		return
+	}
+
+	people, info, err := h.ctrl.ListPeople(c.Request.Context(), filter)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pageResponse("people", people, info))
+}
+
+func (h *Handler) GetPerson(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	p, err := h.ctrl.GetPerson(c.Request.Context(), id)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, p)
+}
+
+func (h *Handler) CreatePerson(c *gin.Context) {
+	var req model.Person
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	p, err := h.ctrl.CreatePerson(c.Request.Context(), &req)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, p)
+}
+
+func (h *Handler) UpdatePerson(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req model.Person
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	p, err := h.ctrl.UpdatePerson(c.Request.Context(), id, &req)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, p)
+}
+
+func (h *Handler) DeletePerson(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.ctrl.DeletePerson(c.Request.Context(), id); err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetFilmography returns the credits of a person across movies.
+func (h *Handler) GetFilmography(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	credits, err := h.ctrl.GetFilmography(c.Request.Context(), id)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"credits": credits})
+}
+
+// GetMovieCredits returns the cast and crew of a movie.
+func (h *Handler) GetMovieCredits(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	credits, err := h.ctrl.GetMovieCredits(c.Request.Context(), id)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, credits)
+}
+
+func (h *Handler) AddCredit(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req model.Credit
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	credit, err := h.ctrl.AddCredit(c.Request.Context(), id, &req)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, credit)
+}
+
+func (h *Handler) UpdateCredit(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	creditID, ok := paramID(c, "credit_id")
+	if !ok {
+		return
+	}
+
+	var req model.Credit
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	credit, err := h.ctrl.UpdateCredit(c.Request.Context(), id, creditID, &req)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, credit)
+}
+
+func (h *Handler) DeleteCredit(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	creditID, ok := paramID(c, "credit_id")
+	if !ok {
+		return
+	}
+
+	if err := h.ctrl.DeleteCredit(c.Request.Context(), id, creditID); err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// paramID parses an id path parameter, responding with an error if it is
+// malformed.
+func paramID(c *gin.Context, name string) (int32, bool) {
+	id, err := strconv.ParseInt(c.Param(name), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+		return 0, false
+	}
+	return int32(id), true
+}
+
+// bindJSON decodes and validates the request body into v, responding with
+// an error if it is invalid.
+func bindJSON(c *gin.Context, v any) bool {
+	if err := c.ShouldBindJSON(v); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	if err := validate.Struct(v); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return false
+	}
+	return true
+}
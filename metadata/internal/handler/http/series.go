@@ -0,0 +1,229 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/gin-gonic/gin"
+)
+
+// ListSeries returns a page of series sorted by id or title.
+func (h *Handler) ListSeries(c *gin.Context) {
+	page, ok := pageRequest(c, "id", "title")
+	if !ok {
+		return
+	}
+
+	series, info, err := h.ctrl.ListSeries(c.Request.Context(), page)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pageResponse("series", series, info))
+}
+
+// GetSeries returns a series with its seasons and episodes.
+func (h *Handler) GetSeries(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	s, err := h.ctrl.GetSeries(c.Request.Context(), id)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, s)
+}
+
+func (h *Handler) CreateSeries(c *gin.Context) {
+	var req model.Series
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	s, err := h.ctrl.CreateSeries(c.Request.Context(), &req)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, s)
+}
+
+func (h *Handler) UpdateSeries(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req model.Series
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	s, err := h.ctrl.UpdateSeries(c.Request.Context(), id, &req)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, s)
+}
+
+func (h *Handler) DeleteSeries(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.ctrl.DeleteSeries(c.Request.Context(), id); err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetSeason returns a season with its episodes.
+func (h *Handler) GetSeason(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	s, err := h.ctrl.GetSeason(c.Request.Context(), id)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, s)
+}
+
+func (h *Handler) AddSeason(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req model.Season
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	s, err := h.ctrl.AddSeason(c.Request.Context(), id, &req)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, s)
+}
+
+func (h *Handler) UpdateSeason(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req model.Season
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	s, err := h.ctrl.UpdateSeason(c.Request.Context(), id, &req)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, s)
+}
+
+func (h *Handler) DeleteSeason(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.ctrl.DeleteSeason(c.Request.Context(), id); err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) GetEpisode(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	e, err := h.ctrl.GetEpisode(c.Request.Context(), id)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, e)
+}
+
+func (h *Handler) AddEpisode(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req model.Episode
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	e, err := h.ctrl.AddEpisode(c.Request.Context(), id, &req)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, e)
+}
+
+func (h *Handler) UpdateEpisode(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req model.Episode
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	e, err := h.ctrl.UpdateEpisode(c.Request.Context(), id, &req)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, e)
+}
+
+func (h *Handler) DeleteEpisode(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.ctrl.DeleteEpisode(c.Request.Context(), id); err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
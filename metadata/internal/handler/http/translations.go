@@ -0,0 +1,61 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/gin-gonic/gin"
+)
+
+// ListTranslations returns the translations of a movie.
+func (h *Handler) ListTranslations(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	translations, err := h.ctrl.ListTranslations(c.Request.Context(), id)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"translations": translations})
+}
+
+// PutTranslation sets the title and description of a movie in a locale.
+func (h *Handler) PutTranslation(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req model.PutTranslationRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	t, err := h.ctrl.PutTranslation(c.Request.Context(), id, c.Param("locale"), &req)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, t)
+}
+
+// DeleteTranslation removes the translation of a movie in a locale.
+func (h *Handler) DeleteTranslation(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.ctrl.DeleteTranslation(c.Request.Context(), id, c.Param("locale")); err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
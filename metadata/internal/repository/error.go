@@ -1,5 +1,5 @@
 package repository
 
-import "errors"
+import "github.com/abhishek622/moviedock/pkg/apperr"
 
-var ErrNotFound = errors.New("not found")
+var ErrNotFound = apperr.New(apperr.NotFound, "not found")
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+)
+
+// Importer writes the batches of a bulk import within one transaction.
+type Importer interface {
+	// ExistingIDs returns the mappings of the given external IDs that are
+	// already in the catalog, including those of deleted movies.
+	ExistingIDs(ctx context.Context, ids []*model.ExternalID) ([]*ExistingID, error)
+	// Write inserts the movies of batch without an id and replaces the
+	// movies with one, including their genres. Their external IDs are added
+	// to the ones they have. The changes are recorded in the history of the
+	// movies.
+	Write(ctx context.Context, batch []*model.Metadata) error
+}
+
+// ExistingID is the mapping of an external ID already in the catalog.
+type ExistingID struct {
+	Mapping model.ExternalID
+	// Deleted is set when the movie it maps to is deleted.
+	Deleted bool
+}
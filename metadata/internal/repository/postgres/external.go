@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/abhishek622/moviedock/metadata/internal/repository"
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+)
+
+// setExternalIDs replaces the external IDs of a movie by ids, keyed by
+// provider.
+func setExternalIDs(ctx context.Context, tx *sql.Tx, id int32, ids map[string]string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM external_ids WHERE metadata_id = $1`, id); err != nil {
+		return fmt.Errorf("error clearing external ids: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	movies := make([]int32, 0, len(ids))
+	providers := make([]string, 0, len(ids))
+	externalIDs := make([]string, 0, len(ids))
+	for provider, externalID := range ids {
+		movies = append(movies, id)
+		providers = append(providers, provider)
+		externalIDs = append(externalIDs, externalID)
+	}
+	return upsertExternalIDs(ctx, tx, movies, providers, externalIDs)
+}
+
+// upsertExternalIDs sets the external IDs given as parallel arrays, replacing
+// the ID a movie had at the same provider.
+func upsertExternalIDs(ctx context.Context, tx *sql.Tx, movies []int32, providers, externalIDs []string) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO external_ids (metadata_id, provider, external_id)
+         SELECT * FROM unnest($1::int[], $2::text[], $3::text[])
+         ON CONFLICT (metadata_id, provider) DO UPDATE SET external_id = EXCLUDED.external_id`,
+		movies, providers, externalIDs,
+	)
+	if err != nil {
+		return fmt.Errorf("error setting external ids: %w", apperr.FromPostgres(err))
+	}
+	return nil
+}
+
+// GetByExternalID returns the movie with the given identifier at a provider.
+func (r *Repository) GetByExternalID(ctx context.Context, provider, externalID string) (*model.Metadata, error) {
+	return getOne(scanMetadata(r.db.QueryRowContext(ctx,
+		`SELECT `+metadataColumns+` FROM movies m
+         JOIN external_ids e ON e.metadata_id = m.metadata_id
+         WHERE e.provider = $1 AND e.external_id = $2 AND m.deleted_at IS NULL`,
+		provider, externalID,
+	)))
+}
+
+// LookupExternalIDs returns the mappings of the given identifiers at a
+// provider. Unknown identifiers and those of deleted movies are left out.
+func (r *Repository) LookupExternalIDs(ctx context.Context, provider string, externalIDs []string) ([]*model.ExternalID, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT e.provider, e.external_id, e.metadata_id FROM external_ids e
+         JOIN movies m ON m.metadata_id = e.metadata_id
+         WHERE e.provider = $1 AND e.external_id = ANY($2) AND m.deleted_at IS NULL
+         ORDER BY e.external_id`,
+		provider, externalIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanAll(rows, scanExternalID)
+}
+
+func scanExternalID(row rowScanner) (*model.ExternalID, error) {
+	var id model.ExternalID
+	if err := row.Scan(&id.Provider, &id.ExternalID, &id.MetadataID); err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// PutExternalID sets the identifier of a movie at a provider on behalf of
+// the user actorID, recording the change in its history.
+func (r *Repository) PutExternalID(ctx context.Context, id *model.ExternalID, actorID string) error {
+	return r.changeExternalIDs(ctx, id.MetadataID, actorID, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO external_ids (metadata_id, provider, external_id) VALUES ($1, $2, $3)
+             ON CONFLICT (metadata_id, provider) DO UPDATE SET external_id = EXCLUDED.external_id`,
+			id.MetadataID, id.Provider, id.ExternalID,
+		)
+		return apperr.FromPostgres(err)
+	})
+}
+
+// DeleteExternalID removes the identifier of a movie at a provider on
+// behalf of the user actorID, recording the change in its history.
+func (r *Repository) DeleteExternalID(ctx context.Context, metadataID int32, provider, actorID string) error {
+	return r.changeExternalIDs(ctx, metadataID, actorID, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			`DELETE FROM external_ids WHERE metadata_id = $1 AND provider = $2`, metadataID, provider)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return repository.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// changeExternalIDs runs change on the external IDs of a movie within a
+// transaction and records it as an update of the movie. Deleted movies are
+// not found.
+func (r *Repository) changeExternalIDs(ctx context.Context, id int32, actorID string, change func(*sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := snapshot(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return repository.ErrNotFound
+	}
+	if err := change(tx); err != nil {
+		return err
+	}
+
+	after, err := snapshot(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	entry := &model.HistoryEntry{MetadataID: id, Action: model.HistoryActionUpdate, Before: before, After: after}
+	if err := recordHistory(ctx, tx, actorID, entry); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
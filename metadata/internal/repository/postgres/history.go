@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/abhishek622/moviedock/metadata/internal/repository"
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/pagination"
+)
+
+// historySorts are the orders of the history of a movie.
+var historySorts = map[string]sortOrder[model.HistoryEntry]{
+	"created_at": {
+		exprs: []string{"h.created_at", "h.history_id"},
+		key:   func(e *model.HistoryEntry) []any { return []any{e.CreatedAt, e.HistoryID} },
+		kinds: []keyKind{timeKey, bigIDKey},
+	},
+}
+
+// snapshots reads the movies with the given ids within tx for recording
+// their history, locking them until tx ends. Missing and deleted movies are
+// left out.
+func snapshots(ctx context.Context, tx *sql.Tx, ids []int32) (map[int32]*model.Metadata, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT `+metadataColumns+` FROM movies m
+         WHERE m.metadata_id = ANY($1) AND m.deleted_at IS NULL
+         FOR UPDATE OF m`,
+		ids,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error reading snapshots: %w", err)
+	}
+	movies, err := scanAll(rows, scanMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[int32]*model.Metadata, len(movies))
+	for _, m := range movies {
+		res[m.MetadataID] = m
+	}
+	return res, nil
+}
+
+// snapshot reads a movie like snapshots, returning nil if it is missing or
+// deleted.
+func snapshot(ctx context.Context, tx *sql.Tx, id int32) (*model.Metadata, error) {
+	s, err := snapshots(ctx, tx, []int32{id})
+	if err != nil {
+		return nil, err
+	}
+	return s[id], nil
+}
+
+// recordHistory appends entries to the history of their movies on behalf
+// of the user actorID, if any.
+func recordHistory(ctx context.Context, tx *sql.Tx, actorID string, entries ...*model.HistoryEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ids := make([]int32, len(entries))
+	actions := make([]string, len(entries))
+	befores := make([]*string, len(entries))
+	afters := make([]*string, len(entries))
+	for i, e := range entries {
+		ids[i], actions[i] = e.MetadataID, string(e.Action)
+		var err error
+		if befores[i], err = snapshotJSON(e.Before); err != nil {
+			return err
+		}
+		if afters[i], err = snapshotJSON(e.After); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO metadata_history (metadata_id, action, actor_id, before, after)
+         SELECT id, action, NULLIF($1, ''), before::jsonb, after::jsonb
+         FROM unnest($2::int[], $3::text[], $4::text[], $5::text[]) AS t(id, action, before, after)`,
+		actorID, ids, actions, befores, afters,
+	)
+	if err != nil {
+		return fmt.Errorf("error recording history: %w", err)
+	}
+	return nil
+}
+
+func snapshotJSON(m *model.Metadata) (*string, error) {
+	if m == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding snapshot: %w", err)
+	}
+	s := string(b)
+	return &s, nil
+}
+
+func scanHistoryEntry(row rowScanner) (*model.HistoryEntry, error) {
+	var e model.HistoryEntry
+	var before, after []byte
+	if err := row.Scan(&e.HistoryID, &e.MetadataID, &e.Action, &e.ActorID, &before, &after, &e.CreatedAt); err != nil {
+		return nil, err
+	}
+	if before != nil {
+		if err := json.Unmarshal(before, &e.Before); err != nil {
+			return nil, fmt.Errorf("error decoding snapshot: %w", err)
+		}
+	}
+	if after != nil {
+		if err := json.Unmarshal(after, &e.After); err != nil {
+			return nil, fmt.Errorf("error decoding snapshot: %w", err)
+		}
+	}
+	return &e, nil
+}
+
+// ListHistory returns the requested page of the history of a movie,
+// including deleted ones.
+func (r *Repository) ListHistory(ctx context.Context, id int32, page pagination.Request) ([]*model.HistoryEntry, pagination.Info, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM movies WHERE metadata_id = $1)`, id).Scan(&exists)
+	if err != nil {
+		return nil, pagination.Info{}, err
+	}
+	if !exists {
+		return nil, pagination.Info{}, repository.ErrNotFound
+	}
+
+	return queryPage(ctx, r, historySorts, page,
+		`SELECT h.history_id, h.metadata_id, h.action, COALESCE(h.actor_id, ''), h.before, h.after, h.created_at
+         FROM metadata_history h`,
+		[]string{"h.metadata_id = $1"}, []any{id}, scanHistoryEntry)
+}
@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+)
+
+const imageColumns = `image_id, metadata_id, kind, content_type, size_bytes, storage_key, width, height, sizes, created_at`
+
+// storedVariant is a resized variant as stored in movie_images.sizes.
+type storedVariant struct {
+	Key    string `json:"key"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+func scanImage(row rowScanner) (*model.Image, error) {
+	var img model.Image
+	var sizes []byte
+	err := row.Scan(
+		&img.ImageID, &img.MetadataID, &img.Kind, &img.ContentType, &img.Size,
+		&img.Original.Key, &img.Original.Width, &img.Original.Height, &sizes, &img.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var stored map[string]storedVariant
+	if err := json.Unmarshal(sizes, &stored); err != nil {
+		return nil, fmt.Errorf("error decoding image sizes: %w", err)
+	}
+	img.Sizes = make(map[string]model.ImageVariant, len(stored))
+	for name, v := range stored {
+		img.Sizes[name] = model.ImageVariant{Key: v.Key, Width: v.Width, Height: v.Height}
+	}
+	return &img, nil
+}
+
+// CreateImage records an image stored for a movie.
+func (r *Repository) CreateImage(ctx context.Context, img *model.Image) (*model.Image, error) {
+	stored := make(map[string]storedVariant, len(img.Sizes))
+	for name, v := range img.Sizes {
+		stored[name] = storedVariant{Key: v.Key, Width: v.Width, Height: v.Height}
+	}
+	sizes, err := json.Marshal(stored)
+	if err != nil {
+		return nil, err
+	}
+
+	return getOne(scanImage(r.db.QueryRowContext(ctx,
+		`INSERT INTO movie_images (metadata_id, kind, content_type, size_bytes, storage_key, width, height, sizes)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+         RETURNING `+imageColumns,
+		img.MetadataID, img.Kind, img.ContentType, img.Size,
+		img.Original.Key, img.Original.Width, img.Original.Height, sizes,
+	)))
+}
+
+// ListImages returns the images of the given movies, oldest first.
+func (r *Repository) ListImages(ctx context.Context, metadataIDs []int32) ([]*model.Image, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+imageColumns+` FROM movie_images
+         WHERE metadata_id = ANY($1) ORDER BY metadata_id, kind, image_id`,
+		metadataIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanAll(rows, scanImage)
+}
+
+// DeleteImage removes an image of a movie, returning it so that its stored
+// files can be removed.
+func (r *Repository) DeleteImage(ctx context.Context, metadataID, imageID int32) (*model.Image, error) {
+	return getOne(scanImage(r.db.QueryRowContext(ctx,
+		`DELETE FROM movie_images WHERE image_id = $1 AND metadata_id = $2 RETURNING `+imageColumns,
+		imageID, metadataID,
+	)))
+}
@@ -0,0 +1,256 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/abhishek622/moviedock/metadata/internal/repository"
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+)
+
+// Import runs fn with an importer writing within a transaction on behalf
+// of the user actorID, committed if fn succeeds and asks for it.
+func (r *Repository) Import(ctx context.Context, actorID string, fn func(repository.Importer) (bool, error)) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	commit, err := fn(&importer{tx, actorID})
+	if err != nil || !commit {
+		return err
+	}
+	return tx.Commit()
+}
+
+type importer struct {
+	tx      *sql.Tx
+	actorID string
+}
+
+func (i *importer) ExistingIDs(ctx context.Context, ids []*model.ExternalID) ([]*repository.ExistingID, error) {
+	if len(ids) == 0 {
+		return []*repository.ExistingID{}, nil
+	}
+
+	providers := make([]string, len(ids))
+	externalIDs := make([]string, len(ids))
+	for j, id := range ids {
+		providers[j], externalIDs[j] = id.Provider, id.ExternalID
+	}
+	rows, err := i.tx.QueryContext(ctx,
+		`SELECT e.provider, e.external_id, e.metadata_id, m.deleted_at IS NOT NULL
+         FROM external_ids e JOIN unnest($1::text[], $2::text[]) AS t(provider, external_id) USING (provider, external_id)
+         JOIN movies m ON m.metadata_id = e.metadata_id`,
+		providers, externalIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanAll(rows, func(row rowScanner) (*repository.ExistingID, error) {
+		var id repository.ExistingID
+		if err := row.Scan(&id.Mapping.Provider, &id.Mapping.ExternalID, &id.Mapping.MetadataID, &id.Deleted); err != nil {
+			return nil, err
+		}
+		return &id, nil
+	})
+}
+
+func (i *importer) Write(ctx context.Context, batch []*model.Metadata) error {
+	var replaced []int32
+	for _, m := range batch {
+		if m.MetadataID != 0 {
+			replaced = append(replaced, m.MetadataID)
+		}
+	}
+	befores, err := snapshots(ctx, i.tx, replaced)
+	if err != nil {
+		return err
+	}
+	if err := i.allocateIDs(ctx, batch); err != nil {
+		return err
+	}
+
+	// Columns are passed as arrays with one element per movie
+	n := len(batch)
+	var (
+		ids          = make([]int32, n)
+		runtimes     = make([]int32, n)
+		titles       = make([]string, n)
+		descriptions = make([]string, n)
+		directors    = make([]string, n)
+		releaseDates = make([]string, n)
+		languages    = make([]string, n)
+		countries    = make([]string, n)
+		certs        = make([]string, n)
+		posters      = make([]string, n)
+		backdrops    = make([]string, n)
+		genreMovies  []int32
+		genreNames   []string
+		idMovies     []int32
+		idProviders  []string
+		externalIDs  []string
+	)
+	for j, m := range batch {
+		ids[j] = m.MetadataID
+		runtimes[j] = m.Runtime
+		titles[j] = m.Title
+		descriptions[j] = m.Description
+		directors[j] = m.Director
+		if m.ReleaseDate != nil {
+			releaseDates[j] = m.ReleaseDate.String()
+		}
+		languages[j] = m.OriginalLanguage
+		countries[j] = m.Country
+		certs[j] = m.AgeCertification
+		posters[j] = m.PosterURL
+		backdrops[j] = m.BackdropURL
+		for _, g := range m.Genres {
+			genreMovies = append(genreMovies, m.MetadataID)
+			genreNames = append(genreNames, g)
+		}
+		for provider, externalID := range m.ExternalIDs {
+			idMovies = append(idMovies, m.MetadataID)
+			idProviders = append(idProviders, provider)
+			externalIDs = append(externalIDs, externalID)
+		}
+	}
+
+	_, err = i.tx.ExecContext(ctx,
+		`INSERT INTO movies (metadata_id, title, description, director, runtime, release_date, original_language,
+                             country, age_certification, poster_url, backdrop_url)
+         SELECT id, title, description, director, runtime, NULLIF(release_date, '')::date,
+                NULLIF(original_language, ''), NULLIF(country, ''), NULLIF(age_certification, ''),
+                NULLIF(poster_url, ''), NULLIF(backdrop_url, '')
+         FROM unnest($1::int[], $2::int[], $3::text[], $4::text[], $5::text[], $6::text[], $7::text[], $8::text[],
+                     $9::text[], $10::text[], $11::text[])
+           AS t(id, runtime, title, description, director, release_date, original_language, country,
+                age_certification, poster_url, backdrop_url)
+         ON CONFLICT (metadata_id) DO UPDATE
+           SET title = EXCLUDED.title,
+               description = EXCLUDED.description,
+               director = EXCLUDED.director,
+               runtime = EXCLUDED.runtime,
+               release_date = EXCLUDED.release_date,
+               original_language = EXCLUDED.original_language,
+               country = EXCLUDED.country,
+               age_certification = EXCLUDED.age_certification,
+               poster_url = EXCLUDED.poster_url,
+               backdrop_url = EXCLUDED.backdrop_url
+         WHERE movies.deleted_at IS NULL`,
+		ids, runtimes, titles, descriptions, directors, releaseDates, languages, countries, certs, posters, backdrops,
+	)
+	if err != nil {
+		return apperr.FromPostgres(err)
+	}
+
+	if err := i.setGenres(ctx, ids, genreMovies, genreNames); err != nil {
+		return err
+	}
+	// External IDs of the movies at other providers are kept
+	if err := upsertExternalIDs(ctx, i.tx, idMovies, idProviders, externalIDs); err != nil {
+		return err
+	}
+
+	afters, err := snapshots(ctx, i.tx, ids)
+	if err != nil {
+		return err
+	}
+	entries := make([]*model.HistoryEntry, len(ids))
+	for j, id := range ids {
+		entries[j] = &model.HistoryEntry{MetadataID: id, Action: model.HistoryActionCreate, After: afters[id]}
+		if before, ok := befores[id]; ok {
+			entries[j].Action, entries[j].Before = model.HistoryActionUpdate, before
+		}
+	}
+	return recordHistory(ctx, i.tx, i.actorID, entries...)
+}
+
+// allocateIDs assigns ids from the movies sequence to the movies of batch
+// without one.
+func (i *importer) allocateIDs(ctx context.Context, batch []*model.Metadata) error {
+	var missing []*model.Metadata
+	for _, m := range batch {
+		if m.MetadataID == 0 {
+			missing = append(missing, m)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	rows, err := i.tx.QueryContext(ctx,
+		`SELECT nextval(pg_get_serial_sequence('movies', 'metadata_id')) FROM generate_series(1, $1)`, len(missing))
+	if err != nil {
+		return fmt.Errorf("error allocating ids: %w", err)
+	}
+	defer rows.Close()
+
+	j := 0
+	for rows.Next() {
+		if err := rows.Scan(&missing[j].MetadataID); err != nil {
+			return err
+		}
+		j++
+	}
+	return rows.Err()
+}
+
+// setGenres replaces the genres of the movies with the given ids by the
+// genres listed in pairs of movie id and genre name, creating unknown ones.
+func (i *importer) setGenres(ctx context.Context, ids, movies []int32, names []string) error {
+	if _, err := i.tx.ExecContext(ctx, `DELETE FROM movie_genres WHERE metadata_id = ANY($1)`, ids); err != nil {
+		return fmt.Errorf("error clearing genres: %w", err)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	_, err := i.tx.ExecContext(ctx,
+		`INSERT INTO genres (name) SELECT unnest($1::text[]) ON CONFLICT ((lower(name))) DO NOTHING`, names)
+	if err != nil {
+		return fmt.Errorf("error creating genres: %w", err)
+	}
+
+	lower := make([]string, len(names))
+	for j, name := range names {
+		lower[j] = strings.ToLower(name)
+	}
+	_, err = i.tx.ExecContext(ctx,
+		`INSERT INTO movie_genres (metadata_id, genre_id)
+         SELECT t.metadata_id, g.genre_id
+         FROM unnest($1::int[], $2::text[]) AS t(metadata_id, name)
+           JOIN genres g ON lower(g.name) = t.name
+         ON CONFLICT DO NOTHING`,
+		movies, lower,
+	)
+	if err != nil {
+		return fmt.Errorf("error setting genres: %w", apperr.FromPostgres(err))
+	}
+	return nil
+}
+
+// Export calls fn with every movie of the catalog by id, leaving out
+// deleted movies.
+func (r *Repository) Export(ctx context.Context, fn func(*model.Metadata) error) error {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+metadataColumns+` FROM movies m WHERE m.deleted_at IS NULL ORDER BY m.metadata_id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		m, err := scanMetadata(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/pagination"
+)
+
+// sortOrder is an order of a listing. exprs end with the primary key so
+// that the order is total, key returns their values for a row and kinds
+// are the kinds of those values.
+type sortOrder[T any] struct {
+	exprs []string
+	key   func(*T) []any
+	kinds []keyKind
+}
+
+// keyKind is the kind of a sort key value, which cursors read back from
+// clients have to match before being compared to the sort expression.
+type keyKind int
+
+const (
+	// idKey is a serial primary key.
+	idKey keyKind = iota
+	// bigIDKey is a bigserial primary key.
+	bigIDKey
+	// textKey is a text column.
+	textKey
+	// dateKey is a date formatted with model.DateLayout, or "infinity".
+	dateKey
+	// timeKey is a timestamp formatted as RFC 3339.
+	timeKey
+)
+
+// valid reports whether v, decoded by pagination.Request.Values, is a value
+// of kind k.
+func (k keyKind) valid(v any) bool {
+	switch k {
+	case idKey:
+		n, ok := v.(int64)
+		return ok && n >= math.MinInt32 && n <= math.MaxInt32
+	case bigIDKey:
+		_, ok := v.(int64)
+		return ok
+	case textKey:
+		_, ok := v.(string)
+		return ok
+	case dateKey:
+		s, ok := v.(string)
+		if !ok {
+			return false
+		}
+		if s == "infinity" {
+			return true
+		}
+		_, err := time.Parse(model.DateLayout, s)
+		return err == nil
+	case timeKey:
+		s, ok := v.(string)
+		if !ok {
+			return false
+		}
+		_, err := time.Parse(time.RFC3339Nano, s)
+		return err == nil
+	}
+	return false
+}
+
+// keyValues returns the sort key of the cursor of page, checking that it
+// matches the kinds of order.
+func keyValues[T any](page pagination.Request, order sortOrder[T]) ([]any, error) {
+	values, err := page.Values()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != len(order.kinds) {
+		return nil, pagination.ErrInvalidCursor
+	}
+	for i, v := range values {
+		if !order.kinds[i].valid(v) {
+			return nil, pagination.ErrInvalidCursor
+		}
+	}
+	return values, nil
+}
+
+// metadataSorts are the orders of movie listings.
+var metadataSorts = map[string]sortOrder[model.Metadata]{
+	"id": {
+		exprs: []string{"m.metadata_id"},
+		key:   func(m *model.Metadata) []any { return []any{m.MetadataID} },
+		kinds: []keyKind{idKey},
+	},
+	"title": {
+		exprs: []string{"m.title", "m.metadata_id"},
+		key:   func(m *model.Metadata) []any { return []any{m.Title, m.MetadataID} },
+		kinds: []keyKind{textKey, idKey},
+	},
+	"release_date": {
+		exprs: []string{"COALESCE(m.release_date, 'infinity')", "m.metadata_id"},
+		key:   func(m *model.Metadata) []any { return []any{releaseDateKey(m.ReleaseDate), m.MetadataID} },
+		kinds: []keyKind{dateKey, idKey},
+	},
+}
+
+// seriesSorts are the orders of series listings.
+var seriesSorts = map[string]sortOrder[model.Series]{
+	"id": {
+		exprs: []string{"series_id"},
+		key:   func(s *model.Series) []any { return []any{s.SeriesID} },
+		kinds: []keyKind{idKey},
+	},
+	"title": {
+		exprs: []string{"title", "series_id"},
+		key:   func(s *model.Series) []any { return []any{s.Title, s.SeriesID} },
+		kinds: []keyKind{textKey, idKey},
+	},
+}
+
+// personSorts are the orders of people listings.
+var personSorts = map[string]sortOrder[model.Person]{
+	"name": {
+		exprs: []string{"name", "person_id"},
+		key:   func(p *model.Person) []any { return []any{p.Name, p.PersonID} },
+		kinds: []keyKind{textKey, idKey},
+	},
+}
+
+// releaseDateKey returns the sort key of a date sorted after all others
+// when missing.
+func releaseDateKey(d *model.Date) string {
+	if d == nil {
+		return "infinity"
+	}
+	return d.Format(model.DateLayout)
+}
+
+// queryPage reads the page of rows selected by query and where that page
+// asks for. args are the arguments of where.
+func queryPage[T any](ctx context.Context, r *Repository, sorts map[string]sortOrder[T], page pagination.Request,
+	query string, where []string, args []any, scan func(rowScanner) (*T, error),
+) ([]*T, pagination.Info, error) {
+	order, ok := sorts[page.Sort]
+	if !ok {
+		return nil, pagination.Info{}, pagination.ErrInvalidSort
+	}
+
+	cond, orderBy := page.SQL(order.exprs, len(args)+1)
+	if cond != "" {
+		values, err := keyValues(page, order)
+		if err != nil {
+			return nil, pagination.Info{}, err
+		}
+		args = append(args, values...)
+		where = append(where, cond)
+	}
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, page.Limit+1)
+	query += fmt.Sprintf(" ORDER BY %s LIMIT $%d", orderBy, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, pagination.Info{}, apperr.FromPostgres(err)
+	}
+	res, err := scanAll(rows, scan)
+	if err != nil {
+		return nil, pagination.Info{}, apperr.FromPostgres(err)
+	}
+	return pagination.Page(page, res, order.key)
+}
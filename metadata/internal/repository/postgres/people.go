@@ -0,0 +1,241 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/abhishek622/moviedock/metadata/internal/repository"
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/pagination"
+)
+
+// personColumns lists the columns read by scanPerson.
+const personColumns = `person_id, name, COALESCE(biography, ''), birth_date, COALESCE(profile_url, '')`
+
+func scanPerson(row rowScanner) (*model.Person, error) {
+	var p model.Person
+	var birthDate sql.NullTime
+	if err := row.Scan(&p.PersonID, &p.Name, &p.Biography, &birthDate, &p.ProfileURL); err != nil {
+		return nil, err
+	}
+	if birthDate.Valid {
+		d := model.NewDate(birthDate.Time)
+		p.BirthDate = &d
+	}
+	return &p, nil
+}
+
+// GetPerson retrieves a person by id.
+func (r *Repository) GetPerson(ctx context.Context, id int32) (*model.Person, error) {
+	p, err := scanPerson(r.db.QueryRowContext(ctx, `SELECT `+personColumns+` FROM persons WHERE person_id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns and the backslash
+// escaping them.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike returns s as a LIKE pattern matching s literally.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// ListPeople returns the page of people matching filter it asks for.
+func (r *Repository) ListPeople(ctx context.Context, filter model.PersonFilter) ([]*model.Person, pagination.Info, error) {
+	var where []string
+	var args []any
+	if filter.Query != "" {
+		args = append(args, "%"+escapeLike(filter.Query)+"%")
+		where = append(where, "name ILIKE $1")
+	}
+	return queryPage(ctx, r, personSorts, filter.Page, `SELECT `+personColumns+` FROM persons`, where, args, scanPerson)
+}
+
+// CreatePerson adds a person.
+func (r *Repository) CreatePerson(ctx context.Context, p *model.Person) (*model.Person, error) {
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO persons (name, biography, birth_date, profile_url)
+         VALUES ($1, NULLIF($2, ''), $3, NULLIF($4, ''))
+         RETURNING person_id`,
+		p.Name, p.Biography, dateArg(p.BirthDate), p.ProfileURL,
+	).Scan(&p.PersonID)
+	if err != nil {
+		return nil, apperr.FromPostgres(err)
+	}
+	return p, nil
+}
+
+// UpdatePerson replaces the details of a person.
+func (r *Repository) UpdatePerson(ctx context.Context, id int32, p *model.Person) (*model.Person, error) {
+	res, err := scanPerson(r.db.QueryRowContext(ctx,
+		`UPDATE persons
+         SET name = $2, biography = NULLIF($3, ''), birth_date = $4, profile_url = NULLIF($5, '')
+         WHERE person_id = $1
+         RETURNING `+personColumns,
+		id, p.Name, p.Biography, dateArg(p.BirthDate), p.ProfileURL,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, apperr.FromPostgres(err)
+	}
+	return res, nil
+}
+
+// DeletePerson removes a person together with their credits.
+func (r *Repository) DeletePerson(ctx context.Context, id int32) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM persons WHERE person_id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// creditColumns lists the columns read by scanCredit from credits c joined
+// with persons p and movies m.
+const creditColumns = `c.credit_id, c.metadata_id, c.person_id, c.role, COALESCE(c.character, ''), c.billing_order,
+             p.name, m.title, m.release_date`
+
+const creditJoins = ` FROM credits c
+         JOIN persons p ON p.person_id = c.person_id
+         JOIN movies m ON m.metadata_id = c.metadata_id`
+
+func scanCredit(row rowScanner) (*model.Credit, error) {
+	var c model.Credit
+	var releaseDate sql.NullTime
+	err := row.Scan(
+		&c.CreditID, &c.MetadataID, &c.PersonID, &c.Role, &c.Character, &c.BillingOrder,
+		&c.PersonName, &c.MovieTitle, &releaseDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if releaseDate.Valid {
+		d := model.NewDate(releaseDate.Time)
+		c.ReleaseDate = &d
+	}
+	return &c, nil
+}
+
+func (r *Repository) listCredits(ctx context.Context, query string, args ...any) ([]*model.Credit, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	credits := []*model.Credit{}
+	for rows.Next() {
+		c, err := scanCredit(rows)
+		if err != nil {
+			return nil, err
+		}
+		credits = append(credits, c)
+	}
+	return credits, rows.Err()
+}
+
+// ListMovieCredits returns the credits of a movie by role and billing order.
+// Deleted movies have no credits until they are restored.
+func (r *Repository) ListMovieCredits(ctx context.Context, metadataID int32) ([]*model.Credit, error) {
+	return r.listCredits(ctx,
+		`SELECT `+creditColumns+creditJoins+`
+         WHERE c.metadata_id = $1 AND m.deleted_at IS NULL
+         ORDER BY c.role, c.billing_order, p.name`,
+		metadataID,
+	)
+}
+
+// ListPersonCredits returns the filmography of a person, newest first.
+func (r *Repository) ListPersonCredits(ctx context.Context, personID int32) ([]*model.Credit, error) {
+	return r.listCredits(ctx,
+		`SELECT `+creditColumns+creditJoins+`
+         WHERE c.person_id = $1 AND m.deleted_at IS NULL
+         ORDER BY m.release_date DESC NULLS LAST, m.title, c.role`,
+		personID,
+	)
+}
+
+// CreateCredit adds a credit to a movie. It returns an InvalidArgument error
+// if the movie or person does not exist.
+func (r *Repository) CreateCredit(ctx context.Context, c *model.Credit) (*model.Credit, error) {
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO credits (metadata_id, person_id, role, character, billing_order)
+         VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+         RETURNING credit_id`,
+		c.MetadataID, c.PersonID, c.Role, c.Character, c.BillingOrder,
+	).Scan(&c.CreditID)
+	if err != nil {
+		return nil, apperr.FromPostgres(err)
+	}
+	return r.getCredit(ctx, c.MetadataID, c.CreditID)
+}
+
+// UpdateCredit replaces a credit of a movie.
+func (r *Repository) UpdateCredit(ctx context.Context, c *model.Credit) (*model.Credit, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE credits c SET person_id = $3, role = $4, character = NULLIF($5, ''), billing_order = $6
+         FROM movies m
+         WHERE c.credit_id = $1 AND c.metadata_id = $2
+           AND m.metadata_id = c.metadata_id AND m.deleted_at IS NULL`,
+		c.CreditID, c.MetadataID, c.PersonID, c.Role, c.Character, c.BillingOrder,
+	)
+	if err != nil {
+		return nil, apperr.FromPostgres(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return r.getCredit(ctx, c.MetadataID, c.CreditID)
+}
+
+func (r *Repository) getCredit(ctx context.Context, metadataID, creditID int32) (*model.Credit, error) {
+	c, err := scanCredit(r.db.QueryRowContext(ctx,
+		`SELECT `+creditColumns+creditJoins+` WHERE c.credit_id = $1 AND c.metadata_id = $2 AND m.deleted_at IS NULL`,
+		creditID, metadataID,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("error getting credit: %w", err)
+	}
+	return c, nil
+}
+
+// DeleteCredit removes a credit from a movie. Credits of deleted movies are
+// kept for a restore.
+func (r *Repository) DeleteCredit(ctx context.Context, metadataID, creditID int32) error {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM credits c USING movies m
+         WHERE c.credit_id = $1 AND c.metadata_id = $2
+           AND m.metadata_id = c.metadata_id AND m.deleted_at IS NULL`,
+		creditID, metadataID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
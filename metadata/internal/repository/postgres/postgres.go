@@ -3,12 +3,17 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/abhishek622/moviedock/metadata/internal/repository"
 	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/pagination"
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
@@ -50,78 +55,311 @@ func New() (*Repository, error) {
 	return &Repository{db: db}, nil
 }
 
-// Get retrieves movie metadata for by movie id.
+// metadataColumns lists the columns read by scanMetadata from movies m.
+const metadataColumns = `m.metadata_id, m.title, COALESCE(m.description, ''), COALESCE(m.director, ''),
+             COALESCE(m.runtime, 0), m.release_date, COALESCE(m.original_language, ''), COALESCE(m.country, ''),
+             COALESCE(m.age_certification, ''), COALESCE(m.poster_url, ''), COALESCE(m.backdrop_url, ''),
+             COALESCE((SELECT json_agg(g.name ORDER BY g.name)
+                       FROM movie_genres mg JOIN genres g USING (genre_id)
+                       WHERE mg.metadata_id = m.metadata_id), '[]'),
+             COALESCE((SELECT json_object_agg(e.provider, e.external_id)
+                       FROM external_ids e WHERE e.metadata_id = m.metadata_id), '{}')`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMetadata(row rowScanner) (*model.Metadata, error) {
+	var m model.Metadata
+	var releaseDate sql.NullTime
+	var genres, externalIDs []byte
+
+	err := row.Scan(
+		&m.MetadataID, &m.Title, &m.Description, &m.Director, &m.Runtime, &releaseDate,
+		&m.OriginalLanguage, &m.Country, &m.AgeCertification, &m.PosterURL, &m.BackdropURL, &genres, &externalIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if releaseDate.Valid {
+		d := model.NewDate(releaseDate.Time)
+		m.ReleaseDate = &d
+	}
+	if err := json.Unmarshal(genres, &m.Genres); err != nil {
+		return nil, fmt.Errorf("error decoding genres: %w", err)
+	}
+	if err := json.Unmarshal(externalIDs, &m.ExternalIDs); err != nil {
+		return nil, fmt.Errorf("error decoding external ids: %w", err)
+	}
+	return &m, nil
+}
+
+// Get retrieves movie metadata for by movie id. Deleted movies are not
+// found.
 func (r *Repository) Get(ctx context.Context, id int32) (*model.Metadata, error) {
-	var title, description, director string
-	var runtime int32
-	// Postgres uses $1 style placeholders
-	row := r.db.QueryRowContext(ctx, "SELECT title, description, director, runtime FROM movies WHERE metadata_id = $1", id)
-	if err := row.Scan(&title, &description, &director, &runtime); err != nil {
-		if err == sql.ErrNoRows {
+	m, err := scanMetadata(r.db.QueryRowContext(ctx,
+		`SELECT `+metadataColumns+` FROM movies m WHERE m.metadata_id = $1 AND m.deleted_at IS NULL`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
 			return nil, repository.ErrNotFound
 		}
 		return nil, err
 	}
-	return &model.Metadata{
-		MetadataID:  id,
-		Title:       title,
-		Description: description,
-		Director:    director,
-		Runtime:     runtime,
-	}, nil
+	return m, nil
 }
 
-// Put adds or updates movie metadata for a given movie id.
-func (r *Repository) Put(ctx context.Context, id int32, metadata *model.Metadata) error {
-	_, err := r.db.ExecContext(ctx,
-		`INSERT INTO movies (metadata_id, title, description, director, runtime)
-         VALUES ($1, $2, $3, $4, $5)
+// Put adds or updates movie metadata for a given movie id on behalf of the
+// user actorID, recording the change in its history. Deleted movies are not
+// found.
+func (r *Repository) Put(ctx context.Context, id int32, metadata *model.Metadata, actorID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := snapshot(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO movies (metadata_id, title, description, director, runtime, release_date, original_language,
+                             country, age_certification, poster_url, backdrop_url)
+         VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''), NULLIF($10, ''), NULLIF($11, ''))
          ON CONFLICT (metadata_id) DO UPDATE
            SET title = EXCLUDED.title,
                description = EXCLUDED.description,
                director = EXCLUDED.director,
-               runtime = EXCLUDED.runtime`,
-		id, metadata.Title, metadata.Description, metadata.Director, metadata.Runtime,
+               runtime = EXCLUDED.runtime,
+               release_date = EXCLUDED.release_date,
+               original_language = EXCLUDED.original_language,
+               country = EXCLUDED.country,
+               age_certification = EXCLUDED.age_certification,
+               poster_url = EXCLUDED.poster_url,
+               backdrop_url = EXCLUDED.backdrop_url
+         WHERE movies.deleted_at IS NULL`,
+		id, metadata.Title, metadata.Description, metadata.Director, metadata.Runtime, dateArg(metadata.ReleaseDate),
+		metadata.OriginalLanguage, metadata.Country, metadata.AgeCertification, metadata.PosterURL, metadata.BackdropURL,
 	)
-	return err
+	if err != nil {
+		return apperr.FromPostgres(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return repository.ErrNotFound
+	}
+	if err := setGenres(ctx, tx, id, metadata.Genres); err != nil {
+		return err
+	}
+	// External IDs are kept unless given
+	if metadata.ExternalIDs != nil {
+		if err := setExternalIDs(ctx, tx, id, metadata.ExternalIDs); err != nil {
+			return err
+		}
+	}
+
+	after, err := snapshot(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	action := model.HistoryActionUpdate
+	if before == nil {
+		action = model.HistoryActionCreate
+	}
+	entry := &model.HistoryEntry{MetadataID: id, Action: action, Before: before, After: after}
+	if err := recordHistory(ctx, tx, actorID, entry); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete marks movie metadata as deleted on behalf of the user actorID,
+// recording the change in its history. Deleted movies are kept with their
+// genres, credits and external IDs until restored.
+func (r *Repository) Delete(ctx context.Context, id int32, actorID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := snapshot(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return repository.ErrNotFound
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE movies SET deleted_at = now() WHERE metadata_id = $1`, id); err != nil {
+		return err
+	}
+
+	entry := &model.HistoryEntry{MetadataID: id, Action: model.HistoryActionDelete, Before: before}
+	if err := recordHistory(ctx, tx, actorID, entry); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (r *Repository) Delete(ctx context.Context, id int32) error {
-	_, err := r.db.ExecContext(ctx, "DELETE FROM movies WHERE metadata_id = $1", id)
-	return err
+// Restore undeletes movie metadata on behalf of the user actorID, recording
+// the change in its history. Movies that are not deleted are not found.
+func (r *Repository) Restore(ctx context.Context, id int32, actorID string) (*model.Metadata, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE movies SET deleted_at = NULL WHERE metadata_id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, repository.ErrNotFound
+	}
+
+	after, err := snapshot(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	entry := &model.HistoryEntry{MetadataID: id, Action: model.HistoryActionRestore, After: after}
+	if err := recordHistory(ctx, tx, actorID, entry); err != nil {
+		return nil, err
+	}
+	return after, tx.Commit()
 }
 
-func (r *Repository) Create(ctx context.Context, metadata *model.Metadata) (*model.Metadata, error) {
-	err := r.db.QueryRowContext(ctx,
-		`INSERT INTO movies (title, description, director, runtime) 
-         VALUES ($1, $2, $3, $4) 
+// Create adds movie metadata on behalf of the user actorID, recording the
+// change in its history.
+func (r *Repository) Create(ctx context.Context, metadata *model.Metadata, actorID string) (*model.Metadata, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO movies (title, description, director, runtime, release_date, original_language, country,
+                             age_certification, poster_url, backdrop_url)
+         VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''), NULLIF($10, ''))
          RETURNING metadata_id`,
-		metadata.Title, metadata.Description, metadata.Director, metadata.Runtime).
+		metadata.Title, metadata.Description, metadata.Director, metadata.Runtime, dateArg(metadata.ReleaseDate),
+		metadata.OriginalLanguage, metadata.Country, metadata.AgeCertification, metadata.PosterURL, metadata.BackdropURL).
 		Scan(&metadata.MetadataID)
+	if err != nil {
+		return nil, apperr.FromPostgres(err)
+	}
+	if err := setGenres(ctx, tx, metadata.MetadataID, metadata.Genres); err != nil {
+		return nil, err
+	}
+	if err := setExternalIDs(ctx, tx, metadata.MetadataID, metadata.ExternalIDs); err != nil {
+		return nil, err
+	}
+
+	after, err := snapshot(ctx, tx, metadata.MetadataID)
 	if err != nil {
 		return nil, err
 	}
-	return metadata, nil
+	entry := &model.HistoryEntry{MetadataID: metadata.MetadataID, Action: model.HistoryActionCreate, After: after}
+	if err := recordHistory(ctx, tx, actorID, entry); err != nil {
+		return nil, err
+	}
+	return after, tx.Commit()
+}
+
+// setGenres replaces the genres of a movie, creating unknown ones.
+func setGenres(ctx context.Context, tx *sql.Tx, id int32, genres []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM movie_genres WHERE metadata_id = $1`, id); err != nil {
+		return fmt.Errorf("error clearing genres: %w", err)
+	}
+	if len(genres) == 0 {
+		return nil
+	}
+
+	lower := make([]string, len(genres))
+	for i, g := range genres {
+		lower[i] = strings.ToLower(g)
+	}
+
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO genres (name) SELECT unnest($1::text[]) ON CONFLICT ((lower(name))) DO NOTHING`,
+		genres,
+	)
+	if err != nil {
+		return fmt.Errorf("error creating genres: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO movie_genres (metadata_id, genre_id)
+         SELECT $1, genre_id FROM genres WHERE lower(name) = ANY($2)`,
+		id, lower,
+	)
+	if err != nil {
+		return fmt.Errorf("error setting genres: %w", apperr.FromPostgres(err))
+	}
+	return nil
+}
+
+// dateArg returns d as a query argument.
+func dateArg(d *model.Date) any {
+	if d == nil {
+		return nil
+	}
+	return d.Time
+}
+
+// List returns the page of metadata matching filter it asks for, leaving
+// out deleted movies.
+func (r *Repository) List(ctx context.Context, filter model.MetadataFilter) ([]*model.Metadata, pagination.Info, error) {
+	where := []string{"m.deleted_at IS NULL"}
+	var args []any
+	if filter.Genre != "" {
+		args = append(args, filter.Genre)
+		where = append(where, fmt.Sprintf(`EXISTS (SELECT 1 FROM movie_genres mg JOIN genres g USING (genre_id)
+                 WHERE mg.metadata_id = m.metadata_id AND lower(g.name) = lower($%d))`, len(args)))
+	}
+	if filter.YearFrom != 0 {
+		args = append(args, time.Date(filter.YearFrom, time.January, 1, 0, 0, 0, 0, time.UTC))
+		where = append(where, fmt.Sprintf("m.release_date >= $%d", len(args)))
+	}
+	if filter.YearTo != 0 {
+		args = append(args, time.Date(filter.YearTo+1, time.January, 1, 0, 0, 0, 0, time.UTC))
+		where = append(where, fmt.Sprintf("m.release_date < $%d", len(args)))
+	}
+	if filter.Language != "" {
+		args = append(args, filter.Language)
+		where = append(where, fmt.Sprintf("m.original_language = $%d", len(args)))
+	}
+	if filter.IDs != nil {
+		args = append(args, filter.IDs)
+		where = append(where, fmt.Sprintf("m.metadata_id = ANY($%d)", len(args)))
+	}
+
+	return queryPage(ctx, r, metadataSorts, filter.Page,
+		`SELECT `+metadataColumns+` FROM movies m`, where, args, scanMetadata)
 }
 
-func (r *Repository) List(ctx context.Context, limit, offset int) ([]*model.Metadata, error) {
-	rows, err := r.db.QueryContext(ctx,
-		`SELECT metadata_id, title, description, director, runtime
-         FROM movies
-         LIMIT $1 OFFSET $2`,
-		limit, offset)
+// ListGenres returns all genres by name.
+func (r *Repository) ListGenres(ctx context.Context) ([]*model.Genre, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT genre_id, name FROM genres ORDER BY name`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var metadatas []*model.Metadata
+	genres := []*model.Genre{}
 	for rows.Next() {
-		var metadata model.Metadata
-		if err := rows.Scan(&metadata.MetadataID, &metadata.Title, &metadata.Description, &metadata.Director, &metadata.Runtime); err != nil {
+		var genre model.Genre
+		if err := rows.Scan(&genre.GenreID, &genre.Name); err != nil {
 			return nil, err
 		}
-		metadatas = append(metadatas, &metadata)
+		genres = append(genres, &genre)
 	}
-	return metadatas, nil
+	return genres, rows.Err()
 }
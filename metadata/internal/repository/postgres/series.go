@@ -0,0 +1,266 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/abhishek622/moviedock/metadata/internal/repository"
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/pagination"
+)
+
+// seriesColumns lists the columns read by scanSeries.
+const seriesColumns = `series_id, title, COALESCE(description, ''), status, first_air_date,
+             COALESCE(original_language, ''), COALESCE(country, ''), COALESCE(age_certification, ''),
+             COALESCE(poster_url, '')`
+
+func scanSeries(row rowScanner) (*model.Series, error) {
+	var s model.Series
+	var firstAirDate sql.NullTime
+	err := row.Scan(
+		&s.SeriesID, &s.Title, &s.Description, &s.Status, &firstAirDate,
+		&s.OriginalLanguage, &s.Country, &s.AgeCertification, &s.PosterURL,
+	)
+	if err != nil {
+		return nil, err
+	}
+	s.FirstAirDate = dateOf(firstAirDate)
+	return &s, nil
+}
+
+// seasonColumns lists the columns read by scanSeason.
+const seasonColumns = `season_id, series_id, season_number, COALESCE(title, ''), COALESCE(overview, ''), air_date,
+             COALESCE(poster_url, '')`
+
+func scanSeason(row rowScanner) (*model.Season, error) {
+	var s model.Season
+	var airDate sql.NullTime
+	err := row.Scan(&s.SeasonID, &s.SeriesID, &s.SeasonNumber, &s.Title, &s.Overview, &airDate, &s.PosterURL)
+	if err != nil {
+		return nil, err
+	}
+	s.AirDate = dateOf(airDate)
+	return &s, nil
+}
+
+// episodeColumns lists the columns read by scanEpisode from episodes e
+// joined with seasons s.
+const episodeColumns = `e.episode_id, e.season_id, s.series_id, s.season_number, e.episode_number, e.title,
+             COALESCE(e.overview, ''), e.air_date, COALESCE(e.runtime, 0)`
+
+const episodeJoins = ` FROM episodes e JOIN seasons s ON s.season_id = e.season_id`
+
+func scanEpisode(row rowScanner) (*model.Episode, error) {
+	var e model.Episode
+	var airDate sql.NullTime
+	err := row.Scan(
+		&e.EpisodeID, &e.SeasonID, &e.SeriesID, &e.SeasonNumber, &e.EpisodeNumber, &e.Title,
+		&e.Overview, &airDate, &e.Runtime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	e.AirDate = dateOf(airDate)
+	return &e, nil
+}
+
+func dateOf(t sql.NullTime) *model.Date {
+	if !t.Valid {
+		return nil
+	}
+	d := model.NewDate(t.Time)
+	return &d
+}
+
+// GetSeries retrieves a series by id, without its seasons.
+func (r *Repository) GetSeries(ctx context.Context, id int32) (*model.Series, error) {
+	return getOne(scanSeries(r.db.QueryRowContext(ctx, `SELECT `+seriesColumns+` FROM series WHERE series_id = $1`, id)))
+}
+
+// ListSeries returns the requested page of series, without their seasons.
+func (r *Repository) ListSeries(ctx context.Context, page pagination.Request) ([]*model.Series, pagination.Info, error) {
+	return queryPage(ctx, r, seriesSorts, page, `SELECT `+seriesColumns+` FROM series`, nil, nil, scanSeries)
+}
+
+// CreateSeries adds a series.
+func (r *Repository) CreateSeries(ctx context.Context, s *model.Series) (*model.Series, error) {
+	return getOne(scanSeries(r.db.QueryRowContext(ctx,
+		`INSERT INTO series (title, description, status, first_air_date, original_language, country,
+                             age_certification, poster_url)
+         VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''))
+         RETURNING `+seriesColumns,
+		s.Title, s.Description, s.Status, dateArg(s.FirstAirDate), s.OriginalLanguage, s.Country,
+		s.AgeCertification, s.PosterURL,
+	)))
+}
+
+// UpdateSeries replaces the details of a series.
+func (r *Repository) UpdateSeries(ctx context.Context, id int32, s *model.Series) (*model.Series, error) {
+	return getOne(scanSeries(r.db.QueryRowContext(ctx,
+		`UPDATE series
+         SET title = $2, description = $3, status = $4, first_air_date = $5, original_language = NULLIF($6, ''),
+             country = NULLIF($7, ''), age_certification = NULLIF($8, ''), poster_url = NULLIF($9, '')
+         WHERE series_id = $1
+         RETURNING `+seriesColumns,
+		id, s.Title, s.Description, s.Status, dateArg(s.FirstAirDate), s.OriginalLanguage, s.Country,
+		s.AgeCertification, s.PosterURL,
+	)))
+}
+
+// DeleteSeries removes a series with its seasons and episodes.
+func (r *Repository) DeleteSeries(ctx context.Context, id int32) error {
+	return r.deleteRow(ctx, "DELETE FROM series WHERE series_id = $1", id)
+}
+
+// GetSeason retrieves a season by id, without its episodes.
+func (r *Repository) GetSeason(ctx context.Context, id int32) (*model.Season, error) {
+	return getOne(scanSeason(r.db.QueryRowContext(ctx, `SELECT `+seasonColumns+` FROM seasons WHERE season_id = $1`, id)))
+}
+
+// ListSeasons returns the seasons of a series by number.
+func (r *Repository) ListSeasons(ctx context.Context, seriesID int32) ([]*model.Season, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+seasonColumns+` FROM seasons WHERE series_id = $1 ORDER BY season_number`,
+		seriesID)
+	if err != nil {
+		return nil, err
+	}
+	return scanAll(rows, scanSeason)
+}
+
+// CreateSeason adds a season to a series.
+func (r *Repository) CreateSeason(ctx context.Context, s *model.Season) (*model.Season, error) {
+	return getOne(scanSeason(r.db.QueryRowContext(ctx,
+		`INSERT INTO seasons (series_id, season_number, title, overview, air_date, poster_url)
+         VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, NULLIF($6, ''))
+         RETURNING `+seasonColumns,
+		s.SeriesID, s.SeasonNumber, s.Title, s.Overview, dateArg(s.AirDate), s.PosterURL,
+	)))
+}
+
+// UpdateSeason replaces the details of a season.
+func (r *Repository) UpdateSeason(ctx context.Context, id int32, s *model.Season) (*model.Season, error) {
+	return getOne(scanSeason(r.db.QueryRowContext(ctx,
+		`UPDATE seasons
+         SET season_number = $2, title = NULLIF($3, ''), overview = NULLIF($4, ''), air_date = $5,
+             poster_url = NULLIF($6, '')
+         WHERE season_id = $1
+         RETURNING `+seasonColumns,
+		id, s.SeasonNumber, s.Title, s.Overview, dateArg(s.AirDate), s.PosterURL,
+	)))
+}
+
+// DeleteSeason removes a season with its episodes.
+func (r *Repository) DeleteSeason(ctx context.Context, id int32) error {
+	return r.deleteRow(ctx, "DELETE FROM seasons WHERE season_id = $1", id)
+}
+
+// GetEpisode retrieves an episode by id.
+func (r *Repository) GetEpisode(ctx context.Context, id int32) (*model.Episode, error) {
+	return getOne(scanEpisode(r.db.QueryRowContext(ctx,
+		`SELECT `+episodeColumns+episodeJoins+` WHERE e.episode_id = $1`, id)))
+}
+
+// ListEpisodes returns the episodes of a season by number.
+func (r *Repository) ListEpisodes(ctx context.Context, seasonID int32) ([]*model.Episode, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+episodeColumns+episodeJoins+` WHERE e.season_id = $1 ORDER BY e.episode_number`,
+		seasonID)
+	if err != nil {
+		return nil, err
+	}
+	return scanAll(rows, scanEpisode)
+}
+
+// ListSeriesEpisodes returns the episodes of all seasons of a series by
+// season and episode number.
+func (r *Repository) ListSeriesEpisodes(ctx context.Context, seriesID int32) ([]*model.Episode, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+episodeColumns+episodeJoins+` WHERE s.series_id = $1 ORDER BY s.season_number, e.episode_number`,
+		seriesID)
+	if err != nil {
+		return nil, err
+	}
+	return scanAll(rows, scanEpisode)
+}
+
+// CreateEpisode adds an episode to a season.
+func (r *Repository) CreateEpisode(ctx context.Context, e *model.Episode) (*model.Episode, error) {
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO episodes (season_id, episode_number, title, overview, air_date, runtime)
+         VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+         RETURNING episode_id`,
+		e.SeasonID, e.EpisodeNumber, e.Title, e.Overview, dateArg(e.AirDate), e.Runtime,
+	).Scan(&e.EpisodeID)
+	if err != nil {
+		return nil, apperr.FromPostgres(err)
+	}
+	return r.GetEpisode(ctx, e.EpisodeID)
+}
+
+// UpdateEpisode replaces the details of an episode.
+func (r *Repository) UpdateEpisode(ctx context.Context, id int32, e *model.Episode) (*model.Episode, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE episodes
+         SET episode_number = $2, title = $3, overview = NULLIF($4, ''), air_date = $5, runtime = $6
+         WHERE episode_id = $1`,
+		id, e.EpisodeNumber, e.Title, e.Overview, dateArg(e.AirDate), e.Runtime,
+	)
+	if err != nil {
+		return nil, apperr.FromPostgres(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return r.GetEpisode(ctx, id)
+}
+
+// DeleteEpisode removes an episode.
+func (r *Repository) DeleteEpisode(ctx context.Context, id int32) error {
+	return r.deleteRow(ctx, "DELETE FROM episodes WHERE episode_id = $1", id)
+}
+
+// getOne translates the result of scanning a single row.
+func getOne[T any](v *T, err error) (*T, error) {
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, apperr.FromPostgres(err)
+	}
+	return v, nil
+}
+
+// scanAll scans and closes rows.
+func scanAll[T any](rows *sql.Rows, scan func(rowScanner) (*T, error)) ([]*T, error) {
+	defer rows.Close()
+
+	res := []*T{}
+	for rows.Next() {
+		v, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+	return res, rows.Err()
+}
+
+// deleteRow runs a DELETE statement, returning repository.ErrNotFound if it
+// removed nothing.
+func (r *Repository) deleteRow(ctx context.Context, query string, args ...any) error {
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
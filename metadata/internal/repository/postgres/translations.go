@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+)
+
+func scanTranslation(row rowScanner) (*model.Translation, error) {
+	var t model.Translation
+	if err := row.Scan(&t.MetadataID, &t.Locale, &t.Title, &t.Description); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ListTranslations returns the translations of a movie by locale.
+func (r *Repository) ListTranslations(ctx context.Context, metadataID int32) ([]*model.Translation, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT metadata_id, locale, title, COALESCE(description, '') FROM movie_translations
+         WHERE metadata_id = $1 ORDER BY locale`,
+		metadataID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanAll(rows, scanTranslation)
+}
+
+// FindTranslations returns the translations of the given movies in any of
+// the given locales.
+func (r *Repository) FindTranslations(ctx context.Context, metadataIDs []int32, locales []string) ([]*model.Translation, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT metadata_id, locale, title, COALESCE(description, '') FROM movie_translations
+         WHERE metadata_id = ANY($1) AND locale = ANY($2)`,
+		metadataIDs, locales,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanAll(rows, scanTranslation)
+}
+
+// PutTranslation adds or replaces the translation of a movie in a locale.
+func (r *Repository) PutTranslation(ctx context.Context, t *model.Translation) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO movie_translations (metadata_id, locale, title, description)
+         VALUES ($1, $2, $3, NULLIF($4, ''))
+         ON CONFLICT (metadata_id, locale) DO UPDATE
+           SET title = EXCLUDED.title, description = EXCLUDED.description, updated_at = now()`,
+		t.MetadataID, t.Locale, t.Title, t.Description,
+	)
+	return apperr.FromPostgres(err)
+}
+
+// DeleteTranslation removes the translation of a movie in a locale.
+func (r *Repository) DeleteTranslation(ctx context.Context, metadataID int32, locale string) error {
+	return r.deleteRow(ctx, `DELETE FROM movie_translations WHERE metadata_id = $1 AND locale = $2`, metadataID, locale)
+}
@@ -0,0 +1,113 @@
+// Package local implements object storage in a directory of the local
+// filesystem.
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/abhishek622/moviedock/metadata/internal/storage"
+)
+
+// Storage stores objects as files below a root directory. Objects are
+// served from baseURL by the service itself.
+type Storage struct {
+	root    string
+	baseURL string
+}
+
+// New creates a storage in root, creating the directory if needed.
+func New(root, baseURL string) (*Storage, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating storage directory: %w", err)
+	}
+	return &Storage{root: root, baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+// path returns the file of key, which cannot be outside root.
+func (s *Storage) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" {
+		return "", storage.ErrNotFound
+	}
+	return filepath.Join(s.root, filepath.FromSlash(clean)), nil
+}
+
+// Put writes data to a temporary file renamed to the file of key, so that
+// readers never see partial objects.
+func (s *Storage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+// Open opens the file of key. Content types are derived from file
+// extensions.
+func (s *Storage) Open(ctx context.Context, key string) (*storage.Object, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, storage.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, storage.ErrNotFound
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return &storage.Object{Body: f, ContentType: contentType, Size: info.Size()}, nil
+}
+
+func (s *Storage) Delete(ctx context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) URL(key string) string {
+	return s.baseURL + "/" + key
+}
@@ -0,0 +1,31 @@
+// Package storage defines the object storage holding movie images.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned when an object does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// Storage stores objects under slash separated keys.
+type Storage interface {
+	// Put stores data under key, replacing any object stored there.
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Open returns the object stored under key. Callers close its body.
+	Open(ctx context.Context, key string) (*Object, error)
+	// Delete removes the object stored under key. Deleting a missing
+	// object is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the URL clients fetch the object stored under key from.
+	URL(key string) string
+}
+
+// Object is a stored object being read.
+type Object struct {
+	Body        io.ReadCloser
+	ContentType string
+	Size        int64
+}
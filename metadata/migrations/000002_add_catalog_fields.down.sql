@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS movie_genres;
+DROP TABLE IF EXISTS genres;
+
+ALTER TABLE movies
+  DROP COLUMN IF EXISTS backdrop_url,
+  DROP COLUMN IF EXISTS poster_url,
+  DROP COLUMN IF EXISTS age_certification,
+  DROP COLUMN IF EXISTS country,
+  DROP COLUMN IF EXISTS original_language,
+  DROP COLUMN IF EXISTS release_date;
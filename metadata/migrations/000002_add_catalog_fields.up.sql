@@ -0,0 +1,32 @@
+ALTER TABLE movies
+  ADD COLUMN IF NOT EXISTS release_date DATE,
+  ADD COLUMN IF NOT EXISTS original_language TEXT,   -- ISO 639-1 code, e.g. "en"
+  ADD COLUMN IF NOT EXISTS country TEXT,             -- ISO 3166-1 alpha-2 code, e.g. "US"
+  ADD COLUMN IF NOT EXISTS age_certification TEXT,   -- e.g. "PG-13"
+  ADD COLUMN IF NOT EXISTS poster_url TEXT,
+  ADD COLUMN IF NOT EXISTS backdrop_url TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_movies_release_date ON movies (release_date);
+CREATE INDEX IF NOT EXISTS idx_movies_original_language ON movies (original_language);
+
+-- genres are matched case-insensitively, the name keeps its first spelling
+CREATE TABLE IF NOT EXISTS genres (
+  genre_id SERIAL PRIMARY KEY,
+  name TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_genres_name ON genres (lower(name));
+
+CREATE TABLE IF NOT EXISTS movie_genres (
+  metadata_id INT NOT NULL REFERENCES movies(metadata_id) ON DELETE CASCADE,
+  genre_id INT NOT NULL REFERENCES genres(genre_id) ON DELETE CASCADE,
+  PRIMARY KEY (metadata_id, genre_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_movie_genres_genre_id ON movie_genres (genre_id);
+
+INSERT INTO genres (name) VALUES
+  ('Action'), ('Adventure'), ('Animation'), ('Comedy'), ('Crime'), ('Documentary'),
+  ('Drama'), ('Family'), ('Fantasy'), ('History'), ('Horror'), ('Music'), ('Mystery'),
+  ('Romance'), ('Science Fiction'), ('Thriller'), ('War'), ('Western')
+ON CONFLICT DO NOTHING;
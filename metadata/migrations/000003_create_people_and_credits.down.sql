@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS credits;
+DROP TRIGGER IF EXISTS update_persons_updated_at ON persons;
+DROP TABLE IF EXISTS persons;
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS persons (
+  person_id SERIAL PRIMARY KEY,
+  name TEXT NOT NULL,
+  biography TEXT,
+  birth_date DATE,
+  profile_url TEXT,
+  created_at TIMESTAMPTZ DEFAULT now(),
+  updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_persons_name ON persons (lower(name));
+
+CREATE TRIGGER update_persons_updated_at
+BEFORE UPDATE ON persons
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- a person's part in a movie; character is only set for actors
+CREATE TABLE IF NOT EXISTS credits (
+  credit_id SERIAL PRIMARY KEY,
+  metadata_id INT NOT NULL REFERENCES movies(metadata_id) ON DELETE CASCADE,
+  person_id INT NOT NULL REFERENCES persons(person_id) ON DELETE CASCADE,
+  role TEXT NOT NULL CHECK (role IN ('director', 'writer', 'actor')),
+  character TEXT,
+  billing_order INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_credits_unique ON credits (metadata_id, person_id, role, COALESCE(character, ''));
+CREATE INDEX IF NOT EXISTS idx_credits_person_id ON credits (person_id);
+
+-- turn the free-text directors into people
+INSERT INTO persons (name)
+SELECT DISTINCT trim(director) FROM movies WHERE trim(director) <> '';
+
+INSERT INTO credits (metadata_id, person_id, role)
+SELECT m.metadata_id, min(p.person_id), 'director'
+FROM movies m JOIN persons p ON p.name = trim(m.director)
+GROUP BY m.metadata_id;
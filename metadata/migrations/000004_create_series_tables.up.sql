@@ -0,0 +1,55 @@
+CREATE TABLE IF NOT EXISTS series (
+  series_id SERIAL PRIMARY KEY,
+  title TEXT NOT NULL,
+  description TEXT,
+  status TEXT NOT NULL DEFAULT 'returning' CHECK (status IN ('returning', 'ended', 'canceled')),
+  first_air_date DATE,
+  original_language TEXT,
+  country TEXT,
+  age_certification TEXT,
+  poster_url TEXT,
+  created_at TIMESTAMPTZ DEFAULT now(),
+  updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+-- season 0 holds specials
+CREATE TABLE IF NOT EXISTS seasons (
+  season_id SERIAL PRIMARY KEY,
+  series_id INT NOT NULL REFERENCES series(series_id) ON DELETE CASCADE,
+  season_number INT NOT NULL CHECK (season_number >= 0),
+  title TEXT,
+  overview TEXT,
+  air_date DATE,
+  poster_url TEXT,
+  created_at TIMESTAMPTZ DEFAULT now(),
+  updated_at TIMESTAMPTZ DEFAULT now(),
+  UNIQUE (series_id, season_number)
+);
+
+CREATE TABLE IF NOT EXISTS episodes (
+  episode_id SERIAL PRIMARY KEY,
+  season_id INT NOT NULL REFERENCES seasons(season_id) ON DELETE CASCADE,
+  episode_number INT NOT NULL CHECK (episode_number > 0),
+  title TEXT NOT NULL,
+  overview TEXT,
+  air_date DATE,
+  runtime INT,
+  created_at TIMESTAMPTZ DEFAULT now(),
+  updated_at TIMESTAMPTZ DEFAULT now(),
+  UNIQUE (season_id, episode_number)
+);
+
+CREATE TRIGGER update_series_updated_at
+BEFORE UPDATE ON series
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_seasons_updated_at
+BEFORE UPDATE ON seasons
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_episodes_updated_at
+BEFORE UPDATE ON episodes
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
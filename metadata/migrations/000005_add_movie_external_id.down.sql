@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_movies_external_id;
+
+ALTER TABLE movies DROP COLUMN IF EXISTS external_id;
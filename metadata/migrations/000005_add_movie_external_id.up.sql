@@ -0,0 +1,5 @@
+-- identifier of a movie in the catalog it was imported from, used to update
+-- it on later imports
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS external_id TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_movies_external_id ON movies (external_id);
@@ -0,0 +1,9 @@
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS external_id TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_movies_external_id ON movies (external_id);
+
+UPDATE movies m SET external_id = e.external_id
+FROM external_ids e
+WHERE e.metadata_id = m.metadata_id AND e.provider = 'catalog';
+
+DROP TABLE IF EXISTS external_ids;
@@ -0,0 +1,19 @@
+-- identifiers of movies at data providers such as IMDb or TMDB, one per
+-- provider and movie
+CREATE TABLE IF NOT EXISTS external_ids (
+  provider TEXT NOT NULL,
+  external_id TEXT NOT NULL,
+  metadata_id INT NOT NULL REFERENCES movies(metadata_id) ON DELETE CASCADE,
+  created_at TIMESTAMPTZ DEFAULT now(),
+  PRIMARY KEY (provider, external_id),
+  UNIQUE (metadata_id, provider)
+);
+
+-- identifiers of earlier imports move to the catalog provider
+INSERT INTO external_ids (provider, external_id, metadata_id)
+SELECT 'catalog', external_id, metadata_id FROM movies WHERE external_id IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+DROP INDEX IF EXISTS idx_movies_external_id;
+
+ALTER TABLE movies DROP COLUMN IF EXISTS external_id;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_persons_name_id;
+CREATE INDEX IF NOT EXISTS idx_persons_name ON persons (lower(name));
+
+DROP INDEX IF EXISTS idx_series_title;
+DROP INDEX IF EXISTS idx_movies_release_date_id;
+DROP INDEX IF EXISTS idx_movies_title;
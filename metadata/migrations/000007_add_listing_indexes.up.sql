@@ -0,0 +1,6 @@
+CREATE INDEX IF NOT EXISTS idx_movies_title ON movies (title, metadata_id);
+CREATE INDEX IF NOT EXISTS idx_movies_release_date_id ON movies (COALESCE(release_date, 'infinity'), metadata_id);
+CREATE INDEX IF NOT EXISTS idx_series_title ON series (title, series_id);
+
+DROP INDEX IF EXISTS idx_persons_name;
+CREATE INDEX IF NOT EXISTS idx_persons_name_id ON persons (name, person_id);
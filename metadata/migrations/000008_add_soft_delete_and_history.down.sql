@@ -0,0 +1,13 @@
+-- without deleted_at, deleted movies would be listed again, so they have to
+-- be restored or purged by hand before rolling back
+DO $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM movies WHERE deleted_at IS NOT NULL) THEN
+    RAISE EXCEPTION 'movies are soft deleted: restore or purge them before rolling back';
+  END IF;
+END
+$$;
+
+DROP TABLE IF EXISTS metadata_history;
+
+ALTER TABLE movies DROP COLUMN IF EXISTS deleted_at;
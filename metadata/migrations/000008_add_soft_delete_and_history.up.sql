@@ -0,0 +1,13 @@
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS metadata_history (
+  history_id BIGSERIAL PRIMARY KEY,
+  metadata_id INT NOT NULL REFERENCES movies (metadata_id) ON DELETE CASCADE,
+  action TEXT NOT NULL CHECK (action IN ('create', 'update', 'delete', 'restore')),
+  actor_id TEXT, -- user ID, NULL for changes made outside the API
+  before JSONB,
+  after JSONB,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_metadata_history_metadata_id ON metadata_history (metadata_id, created_at, history_id);
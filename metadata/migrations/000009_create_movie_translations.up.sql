@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS movie_translations (
+  metadata_id INT NOT NULL REFERENCES movies (metadata_id) ON DELETE CASCADE,
+  locale TEXT NOT NULL, -- BCP 47 language tag in canonical case, e.g. pt-BR
+  title TEXT NOT NULL,
+  description TEXT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (metadata_id, locale)
+);
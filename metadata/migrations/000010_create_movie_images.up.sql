@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS movie_images (
+  image_id SERIAL PRIMARY KEY,
+  metadata_id INT NOT NULL REFERENCES movies (metadata_id) ON DELETE CASCADE,
+  kind TEXT NOT NULL CHECK (kind IN ('poster', 'backdrop')),
+  content_type TEXT NOT NULL,
+  size_bytes BIGINT NOT NULL,
+  storage_key TEXT NOT NULL, -- key of the original in the image storage
+  width INT NOT NULL,
+  height INT NOT NULL,
+  sizes JSONB NOT NULL DEFAULT '{}', -- resized variants by name
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_movie_images_metadata_id ON movie_images (metadata_id, kind, image_id);
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// HistoryAction is the kind of change recorded in the history of a movie.
+type HistoryAction string
+
+const (
+	HistoryActionCreate  HistoryAction = "create"
+	HistoryActionUpdate  HistoryAction = "update"
+	HistoryActionDelete  HistoryAction = "delete"
+	HistoryActionRestore HistoryAction = "restore"
+)
+
+// HistoryEntry is a change of a movie with snapshots of the movie before
+// and after it. Before is nil for creations and restores and After is nil
+// for deletions. ActorID is the user making the change, empty for changes
+// made outside the API.
+type HistoryEntry struct {
+	HistoryID  int64         `json:"history_id"`
+	MetadataID int32         `json:"metadata_id"`
+	Action     HistoryAction `json:"action"`
+	ActorID    string        `json:"actor_id,omitempty"`
+	Before     *Metadata     `json:"before,omitempty"`
+	After      *Metadata     `json:"after,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// ImageKind is the role of an image of a movie.
+type ImageKind string
+
+const (
+	ImageKindPoster   ImageKind = "poster"
+	ImageKindBackdrop ImageKind = "backdrop"
+)
+
+// Valid reports whether k is a known image kind.
+func (k ImageKind) Valid() bool {
+	return k == ImageKindPoster || k == ImageKindBackdrop
+}
+
+// Image is artwork uploaded for a movie. The original is kept as uploaded
+// and Sizes holds variants resized to standard widths, named like "w342".
+type Image struct {
+	ImageID     int32                   `json:"image_id"`
+	MetadataID  int32                   `json:"metadata_id"`
+	Kind        ImageKind               `json:"kind"`
+	ContentType string                  `json:"content_type"`
+	Size        int64                   `json:"size"`
+	Original    ImageVariant            `json:"original"`
+	Sizes       map[string]ImageVariant `json:"sizes"`
+	CreatedAt   time.Time               `json:"created_at"`
+}
+
+// ImageVariant is a stored rendition of an image. Key locates it in the
+// image storage and URL is where clients fetch it from.
+type ImageVariant struct {
+	Key    string `json:"-"`
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
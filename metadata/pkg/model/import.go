@@ -0,0 +1,30 @@
+package model
+
+// ImportOptions controls a bulk catalog import.
+type ImportOptions struct {
+	// DryRun validates and applies the import in a transaction that is
+	// always rolled back.
+	DryRun bool
+	// Upsert updates the movies matching one of the external IDs of a row
+	// instead of rejecting the row.
+	Upsert bool
+}
+
+// ImportRowError reports why a row of an import was rejected. Line is the
+// line of the row in the imported file.
+type ImportRowError struct {
+	Line        int               `json:"line"`
+	ExternalIDs map[string]string `json:"external_ids,omitempty"`
+	Error       string            `json:"error"`
+}
+
+// ImportReport is the outcome of a bulk catalog import. Nothing is written
+// unless the import is not a dry run and no row failed.
+type ImportReport struct {
+	DryRun  bool             `json:"dry_run"`
+	Rows    int              `json:"rows"`
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Failed  int              `json:"failed"`
+	Errors  []ImportRowError `json:"errors"`
+}
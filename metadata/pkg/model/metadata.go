@@ -1,9 +1,68 @@
 package model
 
+import "github.com/abhishek622/moviedock/pkg/pagination"
+
+// Metadata describes a movie. Locale is set when Title and Description
+// were translated for the request; it and Images are ignored when writing.
 type Metadata struct {
+	MetadataID       int32             `json:"metadata_id"`
+	ExternalIDs      map[string]string `json:"external_ids,omitempty" validate:"max=20,dive,keys,min=1,max=32,alphanum,endkeys,required,max=100"`
+	Title            string            `json:"title" validate:"required,max=500"`
+	Description      string            `json:"description"`
+	Director         string            `json:"director"`
+	Runtime          int32             `json:"runtime" validate:"gte=0"`
+	ReleaseDate      *Date             `json:"release_date,omitempty"`
+	Genres           []string          `json:"genres" validate:"max=10,dive,required,max=50"`
+	OriginalLanguage string            `json:"original_language,omitempty" validate:"omitempty,len=2,alpha"`
+	Country          string            `json:"country,omitempty" validate:"omitempty,iso3166_1_alpha2"`
+	AgeCertification string            `json:"age_certification,omitempty" validate:"max=16"`
+	PosterURL        string            `json:"poster_url,omitempty" validate:"omitempty,url"`
+	BackdropURL      string            `json:"backdrop_url,omitempty" validate:"omitempty,url"`
+	Locale           string            `json:"locale,omitempty"`
+	Images           []*Image          `json:"images,omitempty"`
+}
+
+// MetadataFilter selects the metadata returned by a listing. Zero values
+// do not filter.
+type MetadataFilter struct {
+	Genre    string  // matched case-insensitively
+	YearFrom int     // release year, inclusive
+	YearTo   int     // release year, inclusive
+	Language string  // original language
+	IDs      []int32 // metadata IDs; only nil does not filter
+	Page     pagination.Request
+}
+
+// Genre is a genre movies can be tagged with.
+type Genre struct {
+	GenreID int32  `json:"genre_id"`
+	Name    string `json:"name"`
+}
+
+// ExternalID maps the identifier of a movie at a data provider, such as
+// IMDb or TMDB, to its metadata ID.
+type ExternalID struct {
+	Provider   string `json:"provider"`
+	ExternalID string `json:"external_id"`
+	MetadataID int32  `json:"metadata_id"`
+}
+
+// PutExternalIDRequest sets the identifier of a movie at a provider.
+type PutExternalIDRequest struct {
+	ExternalID string `json:"external_id" validate:"required,max=100"`
+}
+
+// Translation is the title and description of a movie in a locale, a BCP
+// 47 language tag such as "pt-BR".
+type Translation struct {
 	MetadataID  int32  `json:"metadata_id"`
+	Locale      string `json:"locale"`
 	Title       string `json:"title"`
-	Description string `json:"description"`
-	Director    string `json:"director"`
-	Runtime     int32  `json:"runtime"`
+	Description string `json:"description,omitempty"`
+}
+
+// PutTranslationRequest sets the translation of a movie in a locale.
+type PutTranslationRequest struct {
+	Title       string `json:"title" validate:"required,max=500"`
+	Description string `json:"description" validate:"max=10000"`
 }
@@ -0,0 +1,48 @@
+package model
+
+import "github.com/abhishek622/moviedock/pkg/pagination"
+
+// CreditRole is the part a person had in making a movie.
+type CreditRole string
+
+const (
+	CreditRoleDirector CreditRole = "director"
+	CreditRoleWriter   CreditRole = "writer"
+	CreditRoleActor    CreditRole = "actor"
+)
+
+// Person is someone credited in movies.
+type Person struct {
+	PersonID   int32  `json:"person_id"`
+	Name       string `json:"name" validate:"required,max=200"`
+	Biography  string `json:"biography,omitempty" validate:"max=10000"`
+	BirthDate  *Date  `json:"birth_date,omitempty"`
+	ProfileURL string `json:"profile_url,omitempty" validate:"omitempty,url"`
+}
+
+// PersonFilter selects the people returned by a listing.
+type PersonFilter struct {
+	Query string // matched against the name
+	Page  pagination.Request
+}
+
+// Credit links a person to a movie. PersonName, MovieTitle and ReleaseDate
+// are filled in when reading credits and ignored when writing them.
+type Credit struct {
+	CreditID     int32      `json:"credit_id"`
+	MetadataID   int32      `json:"metadata_id"`
+	PersonID     int32      `json:"person_id" validate:"required"`
+	Role         CreditRole `json:"role" validate:"required,oneof=director writer actor"`
+	Character    string     `json:"character,omitempty" validate:"max=200"`
+	BillingOrder int32      `json:"billing_order" validate:"gte=0"`
+	PersonName   string     `json:"person_name,omitempty"`
+	MovieTitle   string     `json:"movie_title,omitempty"`
+	ReleaseDate  *Date      `json:"release_date,omitempty"`
+}
+
+// MovieCredits are the credits of a movie: actors in billing order, and
+// everyone else.
+type MovieCredits struct {
+	Cast []*Credit `json:"cast"`
+	Crew []*Credit `json:"crew"`
+}
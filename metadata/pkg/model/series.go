@@ -0,0 +1,52 @@
+package model
+
+// SeriesStatus tells whether new seasons of a series are expected.
+type SeriesStatus string
+
+const (
+	SeriesStatusReturning SeriesStatus = "returning"
+	SeriesStatusEnded     SeriesStatus = "ended"
+	SeriesStatusCanceled  SeriesStatus = "canceled"
+)
+
+// Series is a TV series. Seasons are filled in when reading a single series
+// and ignored when writing it.
+type Series struct {
+	SeriesID         int32        `json:"series_id"`
+	Title            string       `json:"title" validate:"required,max=500"`
+	Description      string       `json:"description"`
+	Status           SeriesStatus `json:"status" validate:"omitempty,oneof=returning ended canceled"`
+	FirstAirDate     *Date        `json:"first_air_date,omitempty"`
+	OriginalLanguage string       `json:"original_language,omitempty" validate:"omitempty,len=2,alpha"`
+	Country          string       `json:"country,omitempty" validate:"omitempty,iso3166_1_alpha2"`
+	AgeCertification string       `json:"age_certification,omitempty" validate:"max=16"`
+	PosterURL        string       `json:"poster_url,omitempty" validate:"omitempty,url"`
+	Seasons          []*Season    `json:"seasons,omitempty" validate:"-"`
+}
+
+// Season is a season of a series. Episodes are filled in when reading
+// seasons and ignored when writing them.
+type Season struct {
+	SeasonID     int32      `json:"season_id"`
+	SeriesID     int32      `json:"series_id"`
+	SeasonNumber int32      `json:"season_number" validate:"gte=0"`
+	Title        string     `json:"title,omitempty" validate:"max=500"`
+	Overview     string     `json:"overview,omitempty"`
+	AirDate      *Date      `json:"air_date,omitempty"`
+	PosterURL    string     `json:"poster_url,omitempty" validate:"omitempty,url"`
+	Episodes     []*Episode `json:"episodes,omitempty" validate:"-"`
+}
+
+// Episode is an episode of a season. SeriesID and SeasonNumber are filled
+// in when reading episodes and ignored when writing them.
+type Episode struct {
+	EpisodeID     int32  `json:"episode_id"`
+	SeasonID      int32  `json:"season_id"`
+	SeriesID      int32  `json:"series_id"`
+	SeasonNumber  int32  `json:"season_number"`
+	EpisodeNumber int32  `json:"episode_number" validate:"gte=1"`
+	Title         string `json:"title" validate:"required,max=500"`
+	Overview      string `json:"overview,omitempty"`
+	AirDate       *Date  `json:"air_date,omitempty"`
+	Runtime       int32  `json:"runtime" validate:"gte=0"`
+}
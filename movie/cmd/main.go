@@ -15,8 +15,11 @@ import (
 	metadatagateway "github.com/abhishek622/moviedock/movie/internal/gateway/metadata/http"
 	ratinggateway "github.com/abhishek622/moviedock/movie/internal/gateway/rating/http"
 	httphandler "github.com/abhishek622/moviedock/movie/internal/handler/http"
+	"github.com/abhishek622/moviedock/pkg/authz"
 	"github.com/abhishek622/moviedock/pkg/discovery"
 	"github.com/abhishek622/moviedock/pkg/discovery/consul"
+	"github.com/abhishek622/moviedock/pkg/interceptor"
+	"github.com/abhishek622/moviedock/pkg/locale"
 	"github.com/gin-gonic/gin"
 )
 
@@ -33,15 +36,31 @@ func main() {
 		log.Fatalf("Failed to create service registry: %v", err)
 	}
 
-	// Initialize gateways
-	metadataGateway := metadatagateway.New(registry)
-	ratingGateway := ratinggateway.New(registry)
+	// Token verification against the user service signing keys, and API
+	// keys when introspection is configured
+	validator, err := authz.NewValidatorFromEnv(serviceName)
+	if err != nil {
+		log.Fatalf("Failed to configure authentication: %v", err)
+	}
+
+	// Initialize gateways, forwarding the caller's token downstream. There is
+	// no service token to fall back to: the movie service holds no signing
+	// keys, and the calls it makes for anonymous callers (metadata, ratings,
+	// similar movies) only reach public endpoints, while ratings and
+	// recommendations must be fetched as the user rather than the service.
+	client := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &interceptor.Transport{Source: interceptor.ForwardToken()},
+	}
+	metadataGateway := metadatagateway.New(registry, client)
+	ratingGateway := ratinggateway.New(registry, client)
 
 	// Initialize service and controller
 	svc := movie.New(ratingGateway, metadataGateway)
 
 	// Create Gin router
 	router := gin.Default()
+	router.Use(authz.Authenticate(validator), locale.Negotiate())
 
 	// Initialize and register movie handler
 	handler := httphandler.New(svc)
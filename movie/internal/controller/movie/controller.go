@@ -3,22 +3,30 @@ package movie
 import (
 	"context"
 	"errors"
+	"log"
 
 	metadatamodel "github.com/abhishek622/moviedock/metadata/pkg/model"
 	"github.com/abhishek622/moviedock/movie/internal/gateway"
 	"github.com/abhishek622/moviedock/movie/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
 	ratingmodel "github.com/abhishek622/moviedock/rating/pkg/model"
 )
 
 // ErrNotFound is returned when the movie metadata is not found.
-var ErrNotFound = errors.New("movie metadata not found")
+var ErrNotFound = apperr.New(apperr.NotFound, "movie metadata not found")
 
 type ratingGateway interface {
 	GetAggregatedRating(ctx context.Context, recordID ratingmodel.RecordID, recordType ratingmodel.RecordType) (float64, error)
+	GetAggregatedRatings(ctx context.Context, recordType ratingmodel.RecordType, recordIDs []ratingmodel.RecordID) ([]ratingmodel.AggregatedRating, error)
+	GetSimilar(ctx context.Context, recordID ratingmodel.RecordID, limit int) ([]ratingmodel.Similarity, error)
+	GetRecommendations(ctx context.Context, userID ratingmodel.UserID, limit int) ([]ratingmodel.Recommendation, error)
 }
 
 type metadataGateway interface {
 	GetMovieDetails(ctx context.Context, id int32) (*metadatamodel.Metadata, error)
+	GetMovieCredits(ctx context.Context, id int32) (*metadatamodel.MovieCredits, error)
+	ListMovies(ctx context.Context, ids []int32) ([]*metadatamodel.Metadata, error)
+	GetSeries(ctx context.Context, id int32) (*metadatamodel.Series, error)
 }
 
 // Controller defines a movie service controller.
@@ -32,7 +40,9 @@ func New(ratingGateway ratingGateway, metadataGateway metadataGateway) *Controll
 	return &Controller{ratingGateway, metadataGateway}
 }
 
-// Get returns the movie details including the aggregated rating and movie metadata.
+// Get returns the movie details including the aggregated rating, movie
+// metadata and credits. Details are returned without credits if they cannot
+// be fetched.
 func (c *Controller) Get(ctx context.Context, id int32) (*model.MovieDetails, error) {
 	metadata, err := c.metadataGateway.GetMovieDetails(ctx, id)
 	if err != nil && errors.Is(err, gateway.ErrNotFound) {
@@ -41,8 +51,13 @@ func (c *Controller) Get(ctx context.Context, id int32) (*model.MovieDetails, er
 		return nil, err
 	}
 	details := &model.MovieDetails{Metadata: *metadata}
+	if credits, err := c.metadataGateway.GetMovieCredits(ctx, id); err != nil {
+		log.Printf("Failed to get credits of movie %d: %v", id, err)
+	} else {
+		details.Credits = credits
+	}
 	rating, err := c.ratingGateway.GetAggregatedRating(ctx, ratingmodel.RecordID(id), ratingmodel.RecordTypeMovie)
-	if err != nil && !errors.Is(err, gateway.ErrNotFound) {
+	if err != nil && errors.Is(err, gateway.ErrNotFound) {
 		// Just proceed in this case, it's ok not to have ratings yet.
 	} else if err != nil {
 		return nil, err
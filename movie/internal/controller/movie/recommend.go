@@ -0,0 +1,95 @@
+package movie
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abhishek622/moviedock/movie/internal/gateway"
+	"github.com/abhishek622/moviedock/movie/pkg/model"
+	ratingmodel "github.com/abhishek622/moviedock/rating/pkg/model"
+)
+
+// Similar returns the movies most similar to a movie with their aggregated
+// ratings, best first. Similarities are computed periodically, so new
+// movies may have none yet.
+func (c *Controller) Similar(ctx context.Context, id int32, limit int) ([]model.RecommendedMovie, error) {
+	sims, err := c.ratingGateway.GetSimilar(ctx, ratingmodel.RecordID(id), limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(sims) == 0 {
+		// Tell unknown movies apart from movies without similar ones
+		if _, err := c.metadataGateway.GetMovieDetails(ctx, id); errors.Is(err, gateway.ErrNotFound) {
+			return nil, ErrNotFound
+		} else if err != nil {
+			return nil, err
+		}
+		return []model.RecommendedMovie{}, nil
+	}
+
+	ids := make([]int32, len(sims))
+	movies := make([]model.RecommendedMovie, len(sims))
+	for i, s := range sims {
+		ids[i] = int32(s.SimilarID)
+		movies[i] = model.RecommendedMovie{Score: s.Score}
+	}
+	return c.describe(ctx, ids, movies)
+}
+
+// Recommend returns the movies recommended to a user with their aggregated
+// ratings, best first.
+func (c *Controller) Recommend(ctx context.Context, userID string, limit int) ([]model.RecommendedMovie, error) {
+	recs, err := c.ratingGateway.GetRecommendations(ctx, ratingmodel.UserID(userID), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int32, len(recs))
+	movies := make([]model.RecommendedMovie, len(recs))
+	for i, r := range recs {
+		ids[i] = int32(r.RecordID)
+		movies[i] = model.RecommendedMovie{Score: r.Score, PredictedRating: &r.PredictedRating}
+	}
+	return c.describe(ctx, ids, movies)
+}
+
+// describe fills in the metadata and aggregated rating of the movies ids,
+// keeping their order. Movies no longer in the catalog are left out.
+func (c *Controller) describe(ctx context.Context, ids []int32, movies []model.RecommendedMovie) ([]model.RecommendedMovie, error) {
+	metadata, err := c.metadataGateway.ListMovies(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	recordIDs := make([]ratingmodel.RecordID, len(ids))
+	for i, id := range ids {
+		recordIDs[i] = ratingmodel.RecordID(id)
+	}
+	ratings, err := c.ratingGateway.GetAggregatedRatings(ctx, ratingmodel.RecordTypeMovie, recordIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int32]int, len(metadata))
+	for i, m := range metadata {
+		byID[m.MetadataID] = i
+	}
+	byRecord := make(map[int32]float64, len(ratings))
+	for _, r := range ratings {
+		byRecord[int32(r.RecordID)] = r.Rating
+	}
+
+	res := []model.RecommendedMovie{}
+	for i, id := range ids {
+		j, ok := byID[id]
+		if !ok {
+			continue
+		}
+		movie := movies[i]
+		movie.Metadata = *metadata[j]
+		if r, ok := byRecord[id]; ok {
+			movie.Rating = &r
+		}
+		res = append(res, movie)
+	}
+	return res, nil
+}
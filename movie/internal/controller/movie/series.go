@@ -0,0 +1,75 @@
+package movie
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abhishek622/moviedock/movie/internal/gateway"
+	"github.com/abhishek622/moviedock/movie/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	ratingmodel "github.com/abhishek622/moviedock/rating/pkg/model"
+)
+
+// ErrSeriesNotFound is returned when the series metadata is not found.
+var ErrSeriesNotFound = apperr.New(apperr.NotFound, "series metadata not found")
+
+// GetSeries returns a series with the ratings of the series, its episodes
+// and its seasons. A season is rated with the average of all its episode
+// ratings, so episodes with more ratings weigh more.
+func (c *Controller) GetSeries(ctx context.Context, id int32) (*model.SeriesDetails, error) {
+	series, err := c.metadataGateway.GetSeries(ctx, id)
+	if err != nil && errors.Is(err, gateway.ErrNotFound) {
+		return nil, ErrSeriesNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	details := &model.SeriesDetails{Series: *series, Seasons: []model.SeasonDetails{}}
+	details.Series.Seasons = nil
+	rating, err := c.ratingGateway.GetAggregatedRating(ctx, ratingmodel.RecordID(id), ratingmodel.RecordTypeSeries)
+	if err != nil && errors.Is(err, gateway.ErrNotFound) {
+		// Just proceed in this case, it's ok not to have ratings yet.
+	} else if err != nil {
+		return nil, err
+	} else {
+		details.Rating = &rating
+	}
+
+	var episodeIDs []ratingmodel.RecordID
+	for _, s := range series.Seasons {
+		for _, e := range s.Episodes {
+			episodeIDs = append(episodeIDs, ratingmodel.RecordID(e.EpisodeID))
+		}
+	}
+	ratings, err := c.ratingGateway.GetAggregatedRatings(ctx, ratingmodel.RecordTypeEpisode, episodeIDs)
+	if err != nil {
+		return nil, err
+	}
+	byEpisode := make(map[int32]ratingmodel.AggregatedRating, len(ratings))
+	for _, r := range ratings {
+		byEpisode[int32(r.RecordID)] = r
+	}
+
+	for _, s := range series.Seasons {
+		season := model.SeasonDetails{Season: *s, Episodes: []model.EpisodeDetails{}}
+		season.Season.Episodes = nil
+
+		var sum float64
+		for _, e := range s.Episodes {
+			episode := model.EpisodeDetails{Episode: *e}
+			if r, ok := byEpisode[e.EpisodeID]; ok {
+				episode.Rating = &r.Rating
+				episode.RatingCount = r.Count
+				sum += r.Rating * float64(r.Count)
+				season.RatingCount += r.Count
+			}
+			season.Episodes = append(season.Episodes, episode)
+		}
+		if season.RatingCount > 0 {
+			avg := sum / float64(season.RatingCount)
+			season.Rating = &avg
+		}
+		details.Seasons = append(details.Seasons, season)
+	}
+	return details, nil
+}
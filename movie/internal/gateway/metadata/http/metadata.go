@@ -7,61 +7,104 @@ import (
 	"log"
 	"math/rand"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/abhishek622/moviedock/metadata/pkg/model"
 	"github.com/abhishek622/moviedock/movie/internal/gateway"
 	"github.com/abhishek622/moviedock/pkg/discovery"
+	"github.com/abhishek622/moviedock/pkg/locale"
 )
 
 type Gateway struct {
 	registry discovery.Registry
+	client   *http.Client
 }
 
-func New(registry discovery.Registry) *Gateway {
-	return &Gateway{registry}
+func New(registry discovery.Registry, client *http.Client) *Gateway {
+	return &Gateway{registry, client}
 }
 
 func (g *Gateway) GetMovieDetails(ctx context.Context, id int32) (*model.Metadata, error) {
-	addrs, err := g.registry.ServiceAddresses(ctx, "metadata")
-	if err != nil {
+	var v *model.Metadata
+	if err := g.get(ctx, fmt.Sprintf("/api/v1/metadata/%d", id), &v); err != nil {
 		return nil, err
 	}
+	return v, nil
+}
 
-	// Use HTTP port (gRPC port + 1000)
-	addr := addrs[rand.Intn(len(addrs))]
-	// Extract port from address and add 1000
-	port := "9081" // 8081 + 1000 for metadata service
-	if len(addr) > 0 {
-		// Parse the address to get port and add 1000
-		port = "9081" // 8081 + 1000
+// ListMovies returns the metadata of the given movies. Unknown movies are
+// left out.
+func (g *Gateway) ListMovies(ctx context.Context, ids []int32) ([]*model.Metadata, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = strconv.Itoa(int(id))
+	}
+	query := url.Values{"ids": {strings.Join(s, ",")}, "limit": {strconv.Itoa(len(ids))}}
+
+	var v struct {
+		Metadata []*model.Metadata `json:"metadata"`
+	}
+	if err := g.get(ctx, "/api/v1/metadata?"+query.Encode(), &v); err != nil {
+		return nil, err
 	}
+	return v.Metadata, nil
+}
+
+// GetMovieCredits returns the cast and crew of a movie.
+func (g *Gateway) GetMovieCredits(ctx context.Context, id int32) (*model.MovieCredits, error) {
+	var v *model.MovieCredits
+	if err := g.get(ctx, fmt.Sprintf("/api/v1/metadata/%d/credits", id), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
 
-	url := "http://localhost:" + port + "/metadata"
+// GetSeries returns a series with its seasons and episodes.
+func (g *Gateway) GetSeries(ctx context.Context, id int32) (*model.Series, error) {
+	var v *model.Series
+	if err := g.get(ctx, fmt.Sprintf("/api/v1/series/%d", id), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// get decodes the JSON response to a GET request for path on a metadata
+// service instance into v, forwarding the locale preferences of ctx.
+func (g *Gateway) get(ctx context.Context, path string, v any) error {
+	addrs, err := g.registry.ServiceAddresses(ctx, "metadata")
+	if err != nil {
+		return err
+	}
+
+	addr := addrs[rand.Intn(len(addrs))]
+	url := "http://" + addr + path
 	log.Printf("Calling metadata service. Request: GET %s", url)
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	// Metadata is translated for the caller of the movie service
+	if prefs := locale.FromContext(ctx); len(prefs) > 0 {
+		req.Header.Set("Accept-Language", locale.FormatAcceptLanguage(prefs))
 	}
 
-	req = req.WithContext(ctx)
-	values := req.URL.Query()
-	values.Add("id", fmt.Sprintf("%v", id))
-	req.URL.RawQuery = values.Encode()
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := g.client.Do(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	defer resp.Body.Close()
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, gateway.ErrNotFound
+		return gateway.ErrNotFound
 	} else if resp.StatusCode/100 != 2 {
-		return nil, fmt.Errorf("non-2xx response: %v", resp)
+		return fmt.Errorf("non-2xx response: %v", resp.Status)
 	}
 
-	var v *model.Metadata
-	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
-		return nil, err
-	}
-	return v, nil
+	return json.NewDecoder(resp.Body).Decode(v)
 }
@@ -1,111 +1,143 @@
 package http
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"math/rand"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/abhishek622/moviedock/movie/internal/gateway"
 	"github.com/abhishek622/moviedock/pkg/discovery"
 	"github.com/abhishek622/moviedock/rating/pkg/model"
 )
 
+// aggregateBatchSize is how many records are aggregated per request to the
+// rating service, which refuses more.
+const aggregateBatchSize = 500
+
 type Gateway struct {
 	registry discovery.Registry
+	client   *http.Client
 }
 
-func New(registry discovery.Registry) *Gateway {
-	return &Gateway{registry}
+func New(registry discovery.Registry, client *http.Client) *Gateway {
+	return &Gateway{registry, client}
 }
 
 func (g *Gateway) GetAggregatedRating(ctx context.Context, recordID model.RecordID, recordType model.RecordType) (float64, error) {
-	addrs, err := g.registry.ServiceAddresses(ctx, "rating")
-	if err != nil {
+	var v struct {
+		Rating float64 `json:"rating"`
+	}
+	path := fmt.Sprintf("/api/v1/rating/%s/%d", url.PathEscape(string(recordType)), recordID)
+	if err := g.do(ctx, http.MethodGet, path, nil, &v); err != nil {
 		return 0, err
 	}
+	return v.Rating, nil
+}
 
-	// Use HTTP port (gRPC port + 1000)
-	addr := addrs[rand.Intn(len(addrs))]
-	// Extract port from address and add 1000
-	port := "8082" // Default rating port
-	if len(addr) > 0 {
-		// Parse the address to get port and add 1000
-		port = "9082" // 8082 + 1000
+// GetAggregatedRatings returns the aggregated ratings of the given records
+// of one type. Records without ratings are left out.
+func (g *Gateway) GetAggregatedRatings(ctx context.Context, recordType model.RecordType, recordIDs []model.RecordID) ([]model.AggregatedRating, error) {
+	var res []model.AggregatedRating
+	for start := 0; start < len(recordIDs); start += aggregateBatchSize {
+		batch := recordIDs[start:min(start+aggregateBatchSize, len(recordIDs))]
+		ids := make([]string, len(batch))
+		for i, id := range batch {
+			ids[i] = strconv.Itoa(int(id))
+		}
+
+		var v struct {
+			Ratings []model.AggregatedRating `json:"ratings"`
+		}
+		path := fmt.Sprintf("/api/v1/rating/%s?ids=%s", url.PathEscape(string(recordType)), strings.Join(ids, ","))
+		if err := g.do(ctx, http.MethodGet, path, nil, &v); err != nil {
+			return nil, err
+		}
+		res = append(res, v.Ratings...)
 	}
+	return res, nil
+}
 
-	url := "http://localhost:" + port + "/rating"
-	log.Printf("Calling rating service. Request: GET %s", url)
-	req, err := http.NewRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return 0, err
+// GetSimilar returns the movies most similar to a movie, best first.
+func (g *Gateway) GetSimilar(ctx context.Context, recordID model.RecordID, limit int) ([]model.Similarity, error) {
+	var v struct {
+		Similar []model.Similarity `json:"similar"`
 	}
-
-	req = req.WithContext(ctx)
-	values := req.URL.Query()
-	values.Add("id", fmt.Sprintf("%v", recordID))
-	values.Add("type", fmt.Sprintf("%v", recordType))
-	req.URL.RawQuery = values.Encode()
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return 0, err
+	path := fmt.Sprintf("/api/v1/rating/%s/%d/similar?limit=%d", model.RecordTypeMovie, recordID, limit)
+	if err := g.do(ctx, http.MethodGet, path, nil, &v); err != nil {
+		return nil, err
 	}
+	return v.Similar, nil
+}
 
-	defer resp.Body.Close()
-	if resp.StatusCode == http.StatusNotFound {
-		return 0, gateway.ErrNotFound
-	} else if resp.StatusCode/100 != 2 {
-		return 0, fmt.Errorf("non-2xx response: %v", resp)
+// GetRecommendations returns the movies recommended to a user on behalf of
+// the caller whose token is forwarded with the request.
+func (g *Gateway) GetRecommendations(ctx context.Context, userID model.UserID, limit int) ([]model.Recommendation, error) {
+	var v struct {
+		Recommendations []model.Recommendation `json:"recommendations"`
 	}
-
-	var v float64
-	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
-		return 0, err
+	path := fmt.Sprintf("/api/v1/rating/user/%s/recommendations?limit=%d", url.PathEscape(string(userID)), limit)
+	if err := g.do(ctx, http.MethodGet, path, nil, &v); err != nil {
+		return nil, err
 	}
-
-	return v, nil
+	return v.Recommendations, nil
 }
 
+// PutRating writes a rating on behalf of the caller whose token is
+// forwarded with the request.
 func (g *Gateway) PutRating(ctx context.Context, recordID model.RecordID, recordType model.RecordType, rating *model.Rating) error {
+	body, err := json.Marshal(map[string]model.RatingValue{"value": rating.Value})
+	if err != nil {
+		return err
+	}
+	path := fmt.Sprintf("/api/v1/rating/%s/%d", url.PathEscape(string(recordType)), recordID)
+	return g.do(ctx, http.MethodPut, path, body, nil)
+}
+
+// do sends a request for path to a rating service instance and decodes the
+// JSON response into v unless it is nil.
+func (g *Gateway) do(ctx context.Context, method, path string, body []byte, v any) error {
 	addrs, err := g.registry.ServiceAddresses(ctx, "rating")
 	if err != nil {
 		return err
 	}
 
-	// Use HTTP port (gRPC port + 1000)
 	addr := addrs[rand.Intn(len(addrs))]
-	// Extract port from address and add 1000
-	port := "8082" // Default rating port
-	if len(addr) > 0 {
-		// Parse the address to get port and add 1000
-		port = "9082" // 8082 + 1000
+	endpoint := "http://" + addr + path
+	log.Printf("Calling rating service. Request: %s %s", method, endpoint)
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
 	}
-
-	url := "http://localhost:" + port + "/rating"
-	log.Printf("Calling rating service. Request: PUT %s", url)
-	req, err := http.NewRequest(http.MethodPut, url, nil)
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, r)
 	if err != nil {
 		return err
 	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 
-	req = req.WithContext(ctx)
-	values := req.URL.Query()
-	values.Add("id", fmt.Sprintf("%v", recordID))
-	values.Add("type", fmt.Sprintf("%v", recordType))
-	values.Add("userId", fmt.Sprintf("%v", rating.UserID))
-	values.Add("value", fmt.Sprintf("%v", rating.Value))
-	req.URL.RawQuery = values.Encode()
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := g.client.Do(req)
 	if err != nil {
 		return err
 	}
 
 	defer resp.Body.Close()
-	if resp.StatusCode/100 != 2 {
-		return fmt.Errorf("non-2xx response: %v", resp)
+	if resp.StatusCode == http.StatusNotFound {
+		return gateway.ErrNotFound
+	} else if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("non-2xx response: %v", resp.Status)
 	}
 
-	return nil
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
 }
@@ -5,6 +5,8 @@ import (
 	"strconv"
 
 	"github.com/abhishek622/moviedock/movie/internal/controller/movie"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/authz"
 	"github.com/gin-gonic/gin"
 )
 
@@ -31,17 +33,93 @@ func (h *Handler) GetMovieDetails(c *gin.Context) {
 
 	details, err := h.ctrl.Get(c.Request.Context(), int32(id))
 	if err != nil {
-		if err == movie.ErrNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "movie not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
-		}
+		apperr.Respond(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, details)
 }
 
+// GetSeriesDetails returns a series with its seasons, episodes and ratings.
+func (h *Handler) GetSeriesDetails(c *gin.Context) {
+	idStr := c.Query("id")
+	if idStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id parameter is required"})
+		return
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+		return
+	}
+
+	details, err := h.ctrl.GetSeries(c.Request.Context(), int32(id))
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, details)
+}
+
+// GetSimilarMovies returns the movies most similar to a movie.
+func (h *Handler) GetSimilarMovies(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+		return
+	}
+	limit, ok := queryLimit(c)
+	if !ok {
+		return
+	}
+
+	movies, err := h.ctrl.Similar(c.Request.Context(), int32(id), limit)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"movies": movies})
+}
+
+// GetRecommendations returns the movies recommended to the caller from the
+// movies they rated.
+func (h *Handler) GetRecommendations(c *gin.Context) {
+	limit, ok := queryLimit(c)
+	if !ok {
+		return
+	}
+
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+	movies, err := h.ctrl.Recommend(c.Request.Context(), claims.UserID, limit)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"movies": movies})
+}
+
+// queryLimit parses the limit query parameter, defaulting to 10, responding
+// with an error if it is malformed.
+func queryLimit(c *gin.Context) (int, bool) {
+	v := c.Query("limit")
+	if v == "" {
+		return 10, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return 0, false
+	}
+	return n, true
+}
+
 func (h *Handler) RegisterRoutes(router *gin.Engine) {
 	router.GET("/api/v1/movie", h.GetMovieDetails)
+	router.GET("/api/v1/series", h.GetSeriesDetails)
+	router.GET("/api/v1/movies/:id/similar", h.GetSimilarMovies)
+	router.GET("/api/v1/recommendations", authz.Require(authz.APIKeyScope(authz.ScopeRatingsRead)), h.GetRecommendations)
 }
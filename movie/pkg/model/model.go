@@ -3,6 +3,17 @@ package model
 import "github.com/abhishek622/moviedock/metadata/pkg/model"
 
 type MovieDetails struct {
-	Rating   *float64       `json:"rating,omitempty"`
-	Metadata model.Metadata `json:"metadata"`
+	Rating   *float64            `json:"rating,omitempty"`
+	Metadata model.Metadata      `json:"metadata"`
+	Credits  *model.MovieCredits `json:"credits,omitempty"`
+}
+
+// RecommendedMovie is a movie recommended for being similar to another
+// movie or to the movies a user rated, ranked by Score. PredictedRating is
+// the rating the user is expected to give it.
+type RecommendedMovie struct {
+	Score           float64        `json:"score"`
+	PredictedRating *float64       `json:"predicted_rating,omitempty"`
+	Rating          *float64       `json:"rating,omitempty"`
+	Metadata        model.Metadata `json:"metadata"`
 }
@@ -0,0 +1,27 @@
+package model
+
+import "github.com/abhishek622/moviedock/metadata/pkg/model"
+
+// SeriesDetails is a series with the ratings of the series itself, its
+// seasons and their episodes.
+type SeriesDetails struct {
+	Rating  *float64        `json:"rating,omitempty"`
+	Series  model.Series    `json:"series"`
+	Seasons []SeasonDetails `json:"seasons"`
+}
+
+// SeasonDetails is a season with its episodes. Rating is the average of all
+// the episode ratings of the season and RatingCount the number of them.
+type SeasonDetails struct {
+	Rating      *float64         `json:"rating,omitempty"`
+	RatingCount int              `json:"rating_count"`
+	Season      model.Season     `json:"season"`
+	Episodes    []EpisodeDetails `json:"episodes"`
+}
+
+// EpisodeDetails is an episode with its aggregated rating.
+type EpisodeDetails struct {
+	Rating      *float64      `json:"rating,omitempty"`
+	RatingCount int           `json:"rating_count"`
+	Episode     model.Episode `json:"episode"`
+}
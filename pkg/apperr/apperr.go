@@ -0,0 +1,140 @@
+// Package apperr defines the error kinds shared by all services and their
+// translation to HTTP status codes and gRPC codes.
+package apperr
+
+import (
+	"errors"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Kind classifies an error by how it should be reported to clients.
+type Kind int
+
+const (
+	Internal Kind = iota
+	NotFound
+	AlreadyExists
+	InvalidArgument
+	Unauthenticated
+	PermissionDenied
+	Conflict
+	TooManyRequests
+)
+
+var kindNames = map[Kind]string{
+	Internal:         "internal",
+	NotFound:         "not_found",
+	AlreadyExists:    "already_exists",
+	InvalidArgument:  "invalid_argument",
+	Unauthenticated:  "unauthenticated",
+	PermissionDenied: "permission_denied",
+	Conflict:         "conflict",
+	TooManyRequests:  "too_many_requests",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// HTTPStatus returns the HTTP status code reported for errors of kind k.
+func (k Kind) HTTPStatus() int {
+	switch k {
+	case NotFound:
+		return http.StatusNotFound
+	case AlreadyExists, Conflict:
+		return http.StatusConflict
+	case InvalidArgument:
+		return http.StatusBadRequest
+	case Unauthenticated:
+		return http.StatusUnauthorized
+	case PermissionDenied:
+		return http.StatusForbidden
+	case TooManyRequests:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GRPCCode returns the gRPC code reported for errors of kind k.
+func (k Kind) GRPCCode() codes.Code {
+	switch k {
+	case NotFound:
+		return codes.NotFound
+	case AlreadyExists:
+		return codes.AlreadyExists
+	case InvalidArgument:
+		return codes.InvalidArgument
+	case Unauthenticated:
+		return codes.Unauthenticated
+	case PermissionDenied:
+		return codes.PermissionDenied
+	case Conflict:
+		return codes.Aborted
+	case TooManyRequests:
+		return codes.ResourceExhausted
+	default:
+		return codes.Internal
+	}
+}
+
+// Error is an error of a known kind. Message is safe to return to clients;
+// the wrapped error is only logged.
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error
+}
+
+// New returns an error of the given kind, typically used for sentinels.
+func New(kind Kind, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// Wrap returns an error of the given kind wrapping err.
+func Wrap(kind Kind, message string, err error) *Error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// KindOf returns the kind of the outermost *Error in err's chain, or
+// Internal if there is none.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return Internal
+}
+
+// Is reports whether err is of the given kind.
+func Is(err error, kind Kind) bool {
+	return err != nil && KindOf(err) == kind
+}
+
+// Message returns the client-facing message of err. Internal errors are
+// reported with a generic message so that details do not leak.
+func Message(err error) string {
+	var e *Error
+	if errors.As(err, &e) && e.Kind != Internal {
+		return e.Message
+	}
+	return "internal server error"
+}
+
+// HTTPStatus returns the HTTP status code for err.
+func HTTPStatus(err error) int {
+	return KindOf(err).HTTPStatus()
+}
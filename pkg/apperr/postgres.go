@@ -0,0 +1,52 @@
+package apperr
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Postgres error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation      = "23505"
+	pgForeignKeyViolation  = "23503"
+	pgCheckViolation       = "23514"
+	pgNotNullViolation     = "23502"
+	pgInvalidTextRep       = "22P02"
+	pgNumericOutOfRange    = "22003"
+	pgInvalidDatetime      = "22007"
+	pgDatetimeOutOfRange   = "22008"
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+)
+
+// FromPostgres classifies a Postgres constraint or transaction error.
+// Other errors, including nil, are returned unchanged.
+func FromPostgres(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case pgUniqueViolation:
+		return Wrap(AlreadyExists, "already exists", err)
+	case pgForeignKeyViolation:
+		return Wrap(InvalidArgument, "referenced record does not exist", err)
+	case pgCheckViolation, pgNotNullViolation, pgInvalidTextRep,
+		pgNumericOutOfRange, pgInvalidDatetime, pgDatetimeOutOfRange:
+		return Wrap(InvalidArgument, "invalid value", err)
+	case pgSerializationFailure, pgDeadlockDetected:
+		return Wrap(Conflict, "concurrent update, please retry", err)
+	}
+	return err
+}
+
+// ConstraintName returns the name of the constraint violated by err, if any.
+func ConstraintName(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.ConstraintName
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package apperr
+
+import (
+	"context"
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Respond aborts a Gin request with the status and message for err.
+// Internal errors are logged.
+func Respond(c *gin.Context, err error) {
+	if KindOf(err) == Internal {
+		log.Printf("%s %s: %v", c.Request.Method, c.FullPath(), err)
+	}
+	c.AbortWithStatusJSON(HTTPStatus(err), gin.H{"error": Message(err)})
+}
+
+// GRPCStatus converts err to a gRPC status error. Errors that already carry
+// a gRPC status are returned unchanged.
+func GRPCStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(KindOf(err).GRPCCode(), Message(err))
+}
+
+// UnaryServerInterceptor converts errors returned by handlers to gRPC status
+// errors, logging internal ones.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err != nil && KindOf(err) == Internal {
+			log.Printf("%s: %v", info.FullMethod, err)
+		}
+		return resp, GRPCStatus(err)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := handler(srv, ss)
+		if err != nil && KindOf(err) == Internal {
+			log.Printf("%s: %v", info.FullMethod, err)
+		}
+		return GRPCStatus(err)
+	}
+}
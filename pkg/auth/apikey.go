@@ -0,0 +1,12 @@
+package auth
+
+import "strings"
+
+// APIKeyPrefix starts every personal API key. It tells API keys apart from
+// JWTs in the Authorization header and makes leaked keys easy to search for.
+const APIKeyPrefix = "mdk_"
+
+// IsAPIKey reports whether a bearer credential is an API key.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
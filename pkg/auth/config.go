@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultIssuer   = "moviedock-user"
+	DefaultAudience = "moviedock"
+	DefaultLeeway   = 30 * time.Second
+)
+
+// IssuerConfig configures the registered claims set on issued tokens.
+type IssuerConfig struct {
+	// Issuer is the iss claim.
+	Issuer string
+	// Audience is the aud claim of user access tokens.
+	Audience []string
+}
+
+// VerifierConfig configures which tokens a service accepts.
+type VerifierConfig struct {
+	// Issuer is the required iss claim.
+	Issuer string
+	// Audience lists accepted aud values; a token must match at least one.
+	Audience []string
+	// Leeway is the allowed clock skew when checking exp, nbf and iat.
+	Leeway time.Duration
+}
+
+// IssuerConfigFromEnv reads JWT_ISSUER and JWT_AUDIENCE (comma separated).
+func IssuerConfigFromEnv() IssuerConfig {
+	return IssuerConfig{
+		Issuer:   envOrDefault("JWT_ISSUER", DefaultIssuer),
+		Audience: splitList(envOrDefault("JWT_AUDIENCE", DefaultAudience)),
+	}
+}
+
+// VerifierConfigFromEnv reads JWT_ISSUER, JWT_AUDIENCE and JWT_CLOCK_SKEW.
+// The service name is accepted as an audience as well so that service
+// tokens addressed to it validate.
+func VerifierConfigFromEnv(service string) (VerifierConfig, error) {
+	leeway := DefaultLeeway
+	if v := os.Getenv("JWT_CLOCK_SKEW"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return VerifierConfig{}, fmt.Errorf("invalid JWT_CLOCK_SKEW: %w", err)
+		}
+		leeway = d
+	}
+
+	return VerifierConfig{
+		Issuer:   envOrDefault("JWT_ISSUER", DefaultIssuer),
+		Audience: append(splitList(envOrDefault("JWT_AUDIENCE", DefaultAudience)), service),
+		Leeway:   leeway,
+	}, nil
+}
+
+func envOrDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func splitList(s string) []string {
+	var res []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			res = append(res, part)
+		}
+	}
+	return res
+}
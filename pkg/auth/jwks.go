@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// remoteKeySetTTL is how long fetched keys are trusted before refetching.
+	remoteKeySetTTL = 10 * time.Minute
+	// remoteKeySetMinRefresh limits refetches triggered by unknown key IDs.
+	remoteKeySetMinRefresh = 30 * time.Second
+)
+
+// JWK is a JSON Web Key as described in RFC 7517. Only the public
+// parameters of RSA, Ed25519 and P-256 keys are supported.
+type JWK struct {
+	KeyID     string `json:"kid"`
+	KeyType   string `json:"kty"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the keyring.
+func (k *Keyring) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range k.Keys() {
+		set.Keys = append(set.Keys, key.JWK())
+	}
+	return set
+}
+
+// JWK returns the public half of the key in JWK form.
+func (k *Key) JWK() JWK {
+	jwk := JWK{KeyID: k.ID, Algorithm: k.Algorithm, Use: "sig"}
+	switch pub := k.PublicKey.(type) {
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	}
+	return jwk
+}
+
+func (j JWK) key() (*Key, error) {
+	// alg is optional (RFC 7517 section 4.4) and some providers leave it
+	// out of their RSA keys, which are then meant for RS256.
+	if j.Algorithm == "" && j.KeyType == "RSA" {
+		j.Algorithm = AlgorithmRS256
+	}
+	key := &Key{ID: j.KeyID, Algorithm: j.Algorithm}
+	switch {
+	case j.KeyType == "OKP" && j.Curve == "Ed25519" && j.Algorithm == AlgorithmEdDSA:
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("key %s: invalid Ed25519 public key", j.KeyID)
+		}
+		key.PublicKey = ed25519.PublicKey(x)
+	case j.KeyType == "RSA" && j.Algorithm == AlgorithmRS256:
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: invalid RSA modulus", j.KeyID)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("key %s: invalid RSA exponent", j.KeyID)
+		}
+		key.PublicKey = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	case j.KeyType == "EC" && j.Curve == "P-256" && j.Algorithm == AlgorithmES256:
+		x, errX := base64.RawURLEncoding.DecodeString(j.X)
+		y, errY := base64.RawURLEncoding.DecodeString(j.Y)
+		if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+			return nil, fmt.Errorf("key %s: invalid P-256 public key", j.KeyID)
+		}
+		pub, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), append(append([]byte{4}, x...), y...))
+		if err != nil {
+			return nil, fmt.Errorf("key %s: invalid P-256 public key", j.KeyID)
+		}
+		key.PublicKey = pub
+	default:
+		return nil, fmt.Errorf("key %s: unsupported key type %s/%s", j.KeyID, j.KeyType, j.Algorithm)
+	}
+	return key, nil
+}
+
+// RemoteKeySet is a KeySet backed by the JWKS endpoint of a token issuer.
+// Services that only verify tokens use it so they never hold private keys.
+type RemoteKeySet struct {
+	url    string
+	client *http.Client
+
+	mu        sync.RWMutex
+	keys      map[string]*Key
+	fetchedAt time.Time
+
+	fetchMu sync.Mutex
+}
+
+// NewRemoteKeySet creates a key set fetching keys from the given JWKS URL.
+func NewRemoteKeySet(url string) (*RemoteKeySet, error) {
+	if url == "" {
+		return nil, errors.New("JWKS URL is not configured")
+	}
+	return &RemoteKeySet{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+		keys:   map[string]*Key{},
+	}, nil
+}
+
+// Key returns the key with the given ID, refetching the key set when the
+// key is unknown or the cached set is stale.
+func (s *RemoteKeySet) Key(kid string) (*Key, error) {
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	age := time.Since(s.fetchedAt)
+	s.mu.RUnlock()
+
+	if ok && age < remoteKeySetTTL {
+		return key, nil
+	}
+	if !ok && age < remoteKeySetMinRefresh {
+		return nil, ErrUnknownKey
+	}
+
+	if err := s.refresh(); err != nil {
+		if ok {
+			// Keep serving the cached key while the issuer is unreachable.
+			return key, nil
+		}
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+func (s *RemoteKeySet) refresh() error {
+	s.fetchMu.Lock()
+	defer s.fetchMu.Unlock()
+
+	// Another caller may have refreshed while we were waiting.
+	s.mu.RLock()
+	fresh := time.Since(s.fetchedAt) < remoteKeySetMinRefresh
+	s.mu.RUnlock()
+	if fresh {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetching JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	var set JWKS
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("decoding JWKS: %w", err)
+	}
+
+	keys := make(map[string]*Key, len(set.Keys))
+	for _, jwk := range set.Keys {
+		key, err := jwk.key()
+		if err != nil {
+			// Skip keys we cannot use rather than rejecting the whole set.
+			continue
+		}
+		keys[key.ID] = key
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
@@ -1,74 +1,149 @@
 package auth
 
 import (
-        "errors"
-        "os"
-        "time"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"time"
 
-        "github.com/golang-jwt/jwt/v5"
+	"github.com/abhishek622/moviedock/user/pkg/model"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 var (
-        ErrInvalidToken = errors.New("invalid token")
+	ErrInvalidToken = errors.New("invalid token")
 )
 
-func getJWTSecret() []byte {
-        secret := os.Getenv("SESSION_SECRET")
-        if secret == "" {
-                secret = "default-dev-secret-change-in-production"
-        }
-        return []byte(secret)
-}
+const (
+	// AccessTokenTTL is the lifetime of user access tokens. Sessions are kept
+	// alive with refresh tokens issued by the user service.
+	AccessTokenTTL = 15 * time.Minute
+
+	// SystemTokenTTL is the lifetime of service-to-service tokens. They are
+	// minted per call, so they only need to outlive a single request.
+	SystemTokenTTL = 5 * time.Minute
+)
 
 type Claims struct {
-        UserID   string `json:"user_id"`
-        Username string `json:"username"`
-        jwt.RegisteredClaims
+	UserID    string     `json:"user_id"`
+	Username  string     `json:"username"`
+	Role      model.Role `json:"role,omitempty"`
+	Scopes    []string   `json:"scopes,omitempty"`
+	SessionID string     `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// HasScope reports whether the token was granted the given scope.
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+// Issuer signs tokens with the active key of a keyring.
+type Issuer struct {
+	keys *Keyring
+	cfg  IssuerConfig
+}
+
+// NewIssuer creates a token issuer.
+func NewIssuer(keys *Keyring, cfg IssuerConfig) *Issuer {
+	return &Issuer{keys, cfg}
+}
+
+// GenerateToken signs a user access token. Registered claims (iss, aud, sub,
+// jti and validity) are filled in by the issuer.
+func (i *Issuer) GenerateToken(claims Claims) (string, error) {
+	return i.sign(claims, i.cfg.Audience, AccessTokenTTL)
 }
 
-func GenerateToken(userID, username string) (string, error) {
-        claims := Claims{
-                UserID:   userID,
-                Username: username,
-                RegisteredClaims: jwt.RegisteredClaims{
-                        ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-                        IssuedAt:  jwt.NewNumericDate(time.Now()),
-                },
-        }
-
-        token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-        return token.SignedString(getJWTSecret())
+// GenerateSystemToken signs a short-lived token for a call from service to
+// audience. The token is limited to the given scopes.
+func (i *Issuer) GenerateSystemToken(service, audience string, scopes ...string) (string, error) {
+	claims := Claims{
+		UserID:   "system",
+		Username: service,
+		Role:     model.RoleSystem,
+		Scopes:   scopes,
+	}
+
+	return i.sign(claims, []string{audience}, SystemTokenTTL)
 }
 
-func ValidateToken(tokenString string) (*Claims, error) {
-        token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-                if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-                        return nil, ErrInvalidToken
-                }
-                return getJWTSecret(), nil
-        })
+func (i *Issuer) sign(claims Claims, audience []string, ttl time.Duration) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Issuer:    i.cfg.Issuer,
+		Subject:   claims.UserID,
+		Audience:  audience,
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		NotBefore: jwt.NewNumericDate(now),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ID:        jti,
+	}
+
+	key := i.keys.SigningKey()
+	token := jwt.NewWithClaims(key.signingMethod(), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.privateKey)
+}
 
-        if err != nil {
-                return nil, err
-        }
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
 
-        if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-                return claims, nil
-        }
+// Verifier validates tokens against the public keys of a key set.
+type Verifier struct {
+	keys KeySet
+	cfg  VerifierConfig
+}
 
-        return nil, ErrInvalidToken
+// NewVerifier creates a token verifier.
+func NewVerifier(keys KeySet, cfg VerifierConfig) *Verifier {
+	return &Verifier{keys, cfg}
 }
 
-func GenerateSystemToken() (string, error) {
-        claims := Claims{
-                UserID:   "system",
-                Username: "system",
-                RegisteredClaims: jwt.RegisteredClaims{
-                        ExpiresAt: jwt.NewNumericDate(time.Now().Add(365 * 24 * time.Hour)),
-                        IssuedAt:  jwt.NewNumericDate(time.Now()),
-                },
-        }
-
-        token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-        return token.SignedString(getJWTSecret())
+// ValidateToken parses a token and checks its signature and registered claims.
+func (v *Verifier) ValidateToken(_ context.Context, tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, ErrInvalidToken
+		}
+		key, err := v.keys.Key(kid)
+		if err != nil {
+			return nil, err
+		}
+		// The algorithm is pinned by the key, never taken from the token.
+		if token.Method.Alg() != key.Algorithm {
+			return nil, ErrInvalidToken
+		}
+		return key.PublicKey, nil
+	},
+		jwt.WithValidMethods([]string{AlgorithmEdDSA, AlgorithmRS256}),
+		jwt.WithIssuer(v.cfg.Issuer),
+		jwt.WithAudience(v.cfg.Audience...),
+		jwt.WithLeeway(v.cfg.Leeway),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid && claims.ID != "" {
+		return claims, nil
+	}
+
+	return nil, ErrInvalidToken
 }
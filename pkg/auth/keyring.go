@@ -0,0 +1,312 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrNoKeys is returned when a keyring has no usable signing keys.
+	ErrNoKeys = errors.New("no signing keys found")
+	// ErrUnknownKey is returned when a token references a key ID that is not in the key set.
+	ErrUnknownKey = errors.New("unknown signing key")
+)
+
+const (
+	AlgorithmEdDSA = "EdDSA"
+	AlgorithmRS256 = "RS256"
+	// AlgorithmES256 is only accepted from remote key sets, such as those
+	// of OpenID providers; the keyring never signs with it.
+	AlgorithmES256 = "ES256"
+)
+
+// kidTimeLayout is the layout of the creation time prefixing the IDs of
+// rotated keys.
+const kidTimeLayout = "20060102T150405Z"
+
+// Key is a JWT signing key. Keys obtained from a remote key set carry only
+// the public half.
+type Key struct {
+	ID        string
+	Algorithm string
+	PublicKey crypto.PublicKey
+	CreatedAt time.Time
+
+	privateKey crypto.Signer
+}
+
+func newKey(id string, private crypto.Signer, createdAt time.Time) (*Key, error) {
+	k := &Key{ID: id, PublicKey: private.Public(), CreatedAt: createdAt, privateKey: private}
+	switch p := private.(type) {
+	case ed25519.PrivateKey:
+		k.Algorithm = AlgorithmEdDSA
+	case *rsa.PrivateKey:
+		if p.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("key %s: RSA keys must be at least 2048 bits", id)
+		}
+		k.Algorithm = AlgorithmRS256
+	default:
+		return nil, fmt.Errorf("key %s: unsupported key type %T", id, private)
+	}
+	return k, nil
+}
+
+func (k *Key) signingMethod() jwt.SigningMethod {
+	if k.Algorithm == AlgorithmRS256 {
+		return jwt.SigningMethodRS256
+	}
+	return jwt.SigningMethodEdDSA
+}
+
+// KeySet resolves the public key used to verify a token by its key ID.
+type KeySet interface {
+	Key(kid string) (*Key, error)
+}
+
+// Keyring holds the private keys of a token issuer. Keys are stored as PEM
+// files named <kid>.pem in a directory. The newest key signs new tokens and
+// every key in the directory remains valid for verification until it is
+// pruned, so keys can be rotated without invalidating issued tokens.
+type Keyring struct {
+	dir string
+
+	mu     sync.RWMutex
+	keys   map[string]*Key
+	active *Key
+}
+
+// LoadKeyring loads all keys from dir. It fails if dir is not set or holds no keys.
+func LoadKeyring(dir string) (*Keyring, error) {
+	if dir == "" {
+		return nil, errors.New("signing key directory is not configured")
+	}
+
+	k := &Keyring{dir: dir}
+	if err := k.Reload(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Reload re-reads the key directory, picking up keys written by other instances.
+func (k *Keyring) Reload() error {
+	paths, err := filepath.Glob(filepath.Join(k.dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*Key, len(paths))
+	var active *Key
+	for _, path := range paths {
+		key, err := readKeyFile(path)
+		if err != nil {
+			return err
+		}
+		keys[key.ID] = key
+		if active == nil || key.CreatedAt.After(active.CreatedAt) {
+			active = key
+		}
+	}
+
+	if active == nil {
+		return fmt.Errorf("%w in %s", ErrNoKeys, k.dir)
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.active = active
+	k.mu.Unlock()
+	return nil
+}
+
+// SigningKey returns the key new tokens are signed with.
+func (k *Keyring) SigningKey() *Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.active
+}
+
+// Key returns the key with the given ID.
+func (k *Keyring) Key(kid string) (*Key, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+// Keys returns all keys currently valid for verification.
+func (k *Keyring) Keys() []*Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	res := make([]*Key, 0, len(k.keys))
+	for _, key := range k.keys {
+		res = append(res, key)
+	}
+	return res
+}
+
+// Rotate generates a new Ed25519 key, persists it and makes it the signing key.
+func (k *Keyring) Rotate() (*Key, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	kid := now.Format(kidTimeLayout) + "-" + hex.EncodeToString(suffix)
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(k.dir, kid+".pem"), data, 0o600); err != nil {
+		return nil, err
+	}
+
+	key, err := newKey(kid, private, now)
+	if err != nil {
+		return nil, err
+	}
+
+	k.mu.Lock()
+	k.keys[kid] = key
+	k.active = key
+	k.mu.Unlock()
+	return key, nil
+}
+
+// Prune deletes keys that were superseded more than retain ago. Tokens
+// signed with a pruned key no longer verify, so retain must be longer than
+// the lifetime of any issued token.
+func (k *Keyring) Prune(retain time.Duration) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	cutoff := time.Now().Add(-retain)
+	for kid, key := range k.keys {
+		if key == k.active || !k.supersededBefore(key, cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(k.dir, kid+".pem")); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		delete(k.keys, kid)
+	}
+	return nil
+}
+
+// supersededBefore reports whether a newer key was created before t.
+func (k *Keyring) supersededBefore(key *Key, t time.Time) bool {
+	for _, other := range k.keys {
+		if other.CreatedAt.After(key.CreatedAt) && other.CreatedAt.Before(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// StartRotation rotates the signing key whenever it is older than every and
+// prunes keys retired for longer than retain. It blocks until ctx is done.
+func (k *Keyring) StartRotation(ctx context.Context, every, retain time.Duration) {
+	check := every / 10
+	if check > time.Hour {
+		check = time.Hour
+	}
+	ticker := time.NewTicker(check)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := k.Reload(); err != nil {
+				log.Printf("Failed to reload signing keys: %v", err)
+				continue
+			}
+			if time.Since(k.SigningKey().CreatedAt) >= every {
+				key, err := k.Rotate()
+				if err != nil {
+					log.Printf("Failed to rotate signing key: %v", err)
+					continue
+				}
+				log.Printf("Rotated signing key, new key ID %s", key.ID)
+			}
+			if err := k.Prune(retain); err != nil {
+				log.Printf("Failed to prune signing keys: %v", err)
+			}
+		}
+	}
+}
+
+func readKeyFile(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", path)
+	}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported key type %T", path, parsed)
+	}
+
+	kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+	return newKey(kid, signer, keyCreatedAt(kid, info.ModTime()))
+}
+
+// keyCreatedAt returns the creation time encoded in the ID of a rotated
+// key. Copying or restoring key files changes their modification time, so
+// modTime is only used for keys named otherwise.
+func keyCreatedAt(kid string, modTime time.Time) time.Time {
+	prefix, _, _ := strings.Cut(kid, "-")
+	if t, err := time.Parse(kidTimeLayout, prefix); err == nil {
+		return t
+	}
+	return modTime
+}
@@ -0,0 +1,135 @@
+package authz
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/abhishek622/moviedock/pkg/auth"
+)
+
+// APIKeyResolver resolves personal API keys to the claims of the user they
+// act for. It returns an error for unknown, expired and revoked keys.
+type APIKeyResolver interface {
+	ResolveAPIKey(ctx context.Context, key string) (*auth.Claims, error)
+}
+
+// WithAPIKeys returns a validator accepting API keys resolved by r in
+// addition to the tokens accepted by v.
+func WithAPIKeys(v TokenValidator, r APIKeyResolver) TokenValidator {
+	return &apiKeyValidator{v, r}
+}
+
+type apiKeyValidator struct {
+	tokens  TokenValidator
+	apiKeys APIKeyResolver
+}
+
+func (v *apiKeyValidator) ValidateToken(ctx context.Context, token string) (*auth.Claims, error) {
+	if auth.IsAPIKey(token) {
+		return v.apiKeys.ResolveAPIKey(ctx, token)
+	}
+	return v.tokens.ValidateToken(ctx, token)
+}
+
+// apiKeyCacheTTL bounds how long a revoked key keeps working in services
+// resolving keys remotely.
+const apiKeyCacheTTL = time.Minute
+
+// RemoteAPIKeys resolves API keys with the user service introspection
+// endpoint and caches the results briefly.
+type RemoteAPIKeys struct {
+	url    string
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]cachedClaims
+}
+
+type cachedClaims struct {
+	claims  *auth.Claims
+	expires time.Time
+}
+
+// NewRemoteAPIKeys creates a resolver calling the introspection endpoint at url.
+func NewRemoteAPIKeys(url string, client *http.Client) (*RemoteAPIKeys, error) {
+	if url == "" {
+		return nil, errors.New("API key introspection URL is not set")
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &RemoteAPIKeys{url: url, client: client, cache: map[[sha256.Size]byte]cachedClaims{}}, nil
+}
+
+// ResolveAPIKey implements APIKeyResolver.
+func (r *RemoteAPIKeys) ResolveAPIKey(ctx context.Context, key string) (*auth.Claims, error) {
+	id := sha256.Sum256([]byte(key))
+	now := time.Now()
+
+	r.mu.Lock()
+	cached, ok := r.cache[id]
+	r.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.claims, nil
+	}
+
+	claims, err := r.introspect(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	expires := now.Add(apiKeyCacheTTL)
+	if claims.ExpiresAt != nil && claims.ExpiresAt.Before(expires) {
+		expires = claims.ExpiresAt.Time
+	}
+
+	r.mu.Lock()
+	for k, c := range r.cache {
+		if now.After(c.expires) {
+			delete(r.cache, k)
+		}
+	}
+	r.cache[id] = cachedClaims{claims, expires}
+	r.mu.Unlock()
+
+	return claims, nil
+}
+
+func (r *RemoteAPIKeys) introspect(ctx context.Context, key string) (*auth.Claims, error) {
+	body, err := json.Marshal(map[string]string{"api_key": key})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return nil, ErrInvalidToken
+	case resp.StatusCode/100 != 2:
+		return nil, fmt.Errorf("introspecting API key: %s", resp.Status)
+	}
+
+	var claims auth.Claims
+	if err := json.NewDecoder(resp.Body).Decode(&claims); err != nil {
+		return nil, fmt.Errorf("decoding API key claims: %w", err)
+	}
+	return &claims, nil
+}
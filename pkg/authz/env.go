@@ -0,0 +1,36 @@
+package authz
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/abhishek622/moviedock/pkg/auth"
+)
+
+// NewValidatorFromEnv returns the validator of a service verifying tokens
+// against the user service signing keys published at JWKS_URL. API keys
+// are accepted too when API_KEY_INTROSPECT_URL points at the user service
+// introspection endpoint.
+func NewValidatorFromEnv(service string) (TokenValidator, error) {
+	keySet, err := auth.NewRemoteKeySet(os.Getenv("JWKS_URL"))
+	if err != nil {
+		return nil, fmt.Errorf("configuring token verification: %w", err)
+	}
+	verifierConfig, err := auth.VerifierConfigFromEnv(service)
+	if err != nil {
+		return nil, fmt.Errorf("configuring token verification: %w", err)
+	}
+	verifier := auth.NewVerifier(keySet, verifierConfig)
+
+	url := os.Getenv("API_KEY_INTROSPECT_URL")
+	if url == "" {
+		log.Println("Warning: API_KEY_INTROSPECT_URL is not set, API keys are not accepted")
+		return verifier, nil
+	}
+	apiKeys, err := NewRemoteAPIKeys(url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("configuring API keys: %w", err)
+	}
+	return WithAPIKeys(verifier, apiKeys), nil
+}
@@ -0,0 +1,61 @@
+package authz
+
+import (
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/gin-gonic/gin"
+)
+
+// Authenticate returns a middleware validating the bearer token of the
+// request, if one is present, and storing the caller's claims in the request
+// context. Requests without a token proceed anonymously; use Require to
+// reject them.
+func Authenticate(v TokenValidator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		token, ok := bearerToken(header)
+		if !ok {
+			apperr.Respond(c, ErrInvalidAuthHeader)
+			return
+		}
+
+		ctx := c.Request.Context()
+		claims, err := v.ValidateToken(ctx, token)
+		if err != nil {
+			apperr.Respond(c, ErrInvalidToken)
+			return
+		}
+
+		ctx = WithToken(NewContext(ctx, claims), token)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// Require returns a middleware rejecting requests not allowed by p.
+func Require(p Policy) gin.HandlerFunc {
+	return RequireOwned("", p)
+}
+
+// RequireOwned is like Require for routes addressing a single user's data;
+// the owner is taken from the named route parameter.
+func RequireOwned(param string, p Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		req := Request{}
+		req.Claims, _ = ClaimsFromContext(ctx)
+		if param != "" {
+			req.OwnerID = c.Param(param)
+		}
+
+		if err := p(ctx, req); err != nil {
+			apperr.Respond(c, err)
+			return
+		}
+		c.Next()
+	}
+}
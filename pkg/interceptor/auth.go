@@ -2,48 +2,109 @@ package interceptor
 
 import (
 	"context"
-	"strings"
 
+	userv1 "github.com/abhishek622/moviedock/gen/user/v1"
+	"github.com/abhishek622/moviedock/pkg/apperr"
 	"github.com/abhishek622/moviedock/pkg/auth"
+	"github.com/abhishek622/moviedock/pkg/authz"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/metadata"
-	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/health/grpc_health_v1"
 )
 
 type contextKey string
 
 const UserIDKey contextKey = "userID"
 
-func UnaryAuthInterceptor(
-	ctx context.Context,
-	req any,
-	info *grpc.UnaryServerInfo,
-	handler grpc.UnaryHandler,
-) (any, error) {
-	md, ok := metadata.FromIncomingContext(ctx)
+// DefaultAllowlist lists the methods callable without a token: health
+// checks and the user service methods used to obtain a token.
+var DefaultAllowlist = []string{
+	grpc_health_v1.Health_Check_FullMethodName,
+	grpc_health_v1.Health_Watch_FullMethodName,
+	grpc_health_v1.Health_List_FullMethodName,
+	userv1.UserService_CreateUser_FullMethodName,
+	userv1.UserService_AuthenticateUser_FullMethodName,
+}
+
+// UserFromContext returns the claims of the authenticated caller.
+func UserFromContext(ctx context.Context) (*auth.Claims, bool) {
+	return authz.ClaimsFromContext(ctx)
+}
+
+// UserIDFromContext returns the user ID of the authenticated caller.
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	claims, ok := authz.ClaimsFromContext(ctx)
 	if !ok {
-		return nil, status.Error(codes.Unauthenticated, "metadata is not provided")
+		return "", false
 	}
+	return claims.UserID, true
+}
 
-	authHeaders := md["authorization"]
-	if len(authHeaders) == 0 {
-		return nil, status.Error(codes.Unauthenticated, "authorization token is not provided")
+// UnaryAuthInterceptor returns an interceptor authenticating requests with
+// bearer tokens checked by the given validator. Methods in allowlist may be
+// called without a token; callers presenting one are still authenticated.
+func UnaryAuthInterceptor(v authz.TokenValidator, allowlist ...string) grpc.UnaryServerInterceptor {
+	allowed := toSet(allowlist)
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		ctx, err := authenticate(ctx, v, allowed[info.FullMethod])
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
 	}
+}
 
-	authHeader := authHeaders[0]
-	parts := strings.SplitN(authHeader, " ", 2)
-	if len(parts) != 2 || parts[0] != "Bearer" {
-		return nil, status.Error(codes.Unauthenticated, "invalid authorization header format")
+// StreamAuthInterceptor is the streaming counterpart of UnaryAuthInterceptor.
+func StreamAuthInterceptor(v authz.TokenValidator, allowlist ...string) grpc.StreamServerInterceptor {
+	allowed := toSet(allowlist)
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, err := authenticate(ss.Context(), v, allowed[info.FullMethod])
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
 	}
+}
 
-	tokenString := parts[1]
-	claims, err := auth.ValidateToken(tokenString)
+func authenticate(ctx context.Context, v authz.TokenValidator, allowAnonymous bool) (context.Context, error) {
+	ctx, ok, err := authz.AuthenticateIncoming(ctx, v)
 	if err != nil {
-		return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+		return nil, err
 	}
+	if !ok {
+		if allowAnonymous {
+			return ctx, nil
+		}
+		return nil, apperr.GRPCStatus(authz.ErrUnauthenticated)
+	}
+
+	claims, _ := authz.ClaimsFromContext(ctx)
+	return context.WithValue(ctx, UserIDKey, claims.UserID), nil
+}
 
-	ctxWithUser := context.WithValue(ctx, UserIDKey, claims.UserID)
+func toSet(methods []string) map[string]bool {
+	set := make(map[string]bool, len(methods))
+	for _, m := range methods {
+		set[m] = true
+	}
+	return set
+}
+
+// serverStream overrides the context of a grpc.ServerStream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
 
-	return handler(ctxWithUser, req)
+func (s *serverStream) Context() context.Context {
+	return s.ctx
 }
@@ -0,0 +1,128 @@
+package interceptor
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/abhishek622/moviedock/pkg/authz"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// TokenSource returns the bearer token to attach to an outgoing call, or an
+// empty string to send the call unauthenticated.
+type TokenSource func(ctx context.Context) (string, error)
+
+// SystemTokenMinter mints service tokens. *auth.Issuer implements it; only
+// the user service holds the signing keys needed to do so.
+type SystemTokenMinter interface {
+	GenerateSystemToken(service, audience string, scopes ...string) (string, error)
+}
+
+// ForwardToken forwards the token of the request being served, so the
+// downstream service sees the original caller. Services without signing
+// keys use it alone; calls made for anonymous callers then carry no token
+// and can only reach public endpoints.
+func ForwardToken() TokenSource {
+	return func(ctx context.Context) (string, error) {
+		token, _ := authz.TokenFromContext(ctx)
+		return token, nil
+	}
+}
+
+// ServiceToken mints a token identifying service, valid only at audience and
+// only for the given scopes.
+func ServiceToken(minter SystemTokenMinter, service, audience string, scopes ...string) TokenSource {
+	return func(ctx context.Context) (string, error) {
+		return minter.GenerateSystemToken(service, audience, scopes...)
+	}
+}
+
+// FirstOf returns the first non-empty token of the given sources, e.g.
+// FirstOf(ForwardToken(), ServiceToken(...)) to act as the caller when there
+// is one and as the service otherwise.
+func FirstOf(sources ...TokenSource) TokenSource {
+	return func(ctx context.Context) (string, error) {
+		for _, src := range sources {
+			token, err := src(ctx)
+			if err != nil {
+				return "", err
+			}
+			if token != "" {
+				return token, nil
+			}
+		}
+		return "", nil
+	}
+}
+
+// UnaryClientInterceptor attaches the token from src to outgoing unary calls.
+func UnaryClientInterceptor(src TokenSource) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		ctx, err := outgoingContext(ctx, src)
+		if err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor attaches the token from src to outgoing streams.
+func StreamClientInterceptor(src TokenSource) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		ctx, err := outgoingContext(ctx, src)
+		if err != nil {
+			return nil, err
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+func outgoingContext(ctx context.Context, src TokenSource) (context.Context, error) {
+	token, err := src(ctx)
+	if err != nil || token == "" {
+		return ctx, err
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token), nil
+}
+
+// Transport is an http.RoundTripper attaching the token from Source to
+// outgoing HTTP requests, for services calling each other over HTTP.
+type Transport struct {
+	Source TokenSource
+	// Base is the underlying transport, http.DefaultTransport when nil.
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.Source(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if token == "" || req.Header.Get("Authorization") != "" {
+		return base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return base.RoundTrip(req)
+}
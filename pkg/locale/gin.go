@@ -0,0 +1,16 @@
+package locale
+
+import "github.com/gin-gonic/gin"
+
+// Negotiate returns a middleware storing the locale preferences from the
+// Accept-Language header of requests in their context. Responses vary by
+// the header.
+func Negotiate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Vary", "Accept-Language")
+		if prefs := ParseAcceptLanguage(c.GetHeader("Accept-Language")); len(prefs) > 0 {
+			c.Request = c.Request.WithContext(NewContext(c.Request.Context(), prefs))
+		}
+		c.Next()
+	}
+}
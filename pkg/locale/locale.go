@@ -0,0 +1,184 @@
+// Package locale negotiates the language of responses from the
+// Accept-Language header of requests.
+//
+// Locales are BCP 47 language tags such as "pt" or "pt-BR", kept in their
+// canonical case. Preferences are ordered lists of locales, most preferred
+// first, and are carried in request contexts so that services can forward
+// them to the services they call.
+package locale
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxTagLength bounds the length of the tags accepted.
+	maxTagLength = 35
+	// maxPreferences bounds the number of locales taken from a header, so
+	// that a long header costs no more than a reasonable one downstream.
+	maxPreferences = 10
+)
+
+// Canonical returns tag in canonical case: the language in lower case, a
+// script in title case and a region in upper case, with subtags separated
+// by hyphens. It reports false for malformed tags.
+func Canonical(tag string) (string, bool) {
+	if tag == "" || len(tag) > maxTagLength {
+		return "", false
+	}
+	subtags := strings.FieldsFunc(tag, func(r rune) bool { return r == '-' || r == '_' })
+	if len(subtags) == 0 || strings.Count(tag, "-")+strings.Count(tag, "_") != len(subtags)-1 {
+		return "", false
+	}
+
+	for i, s := range subtags {
+		if len(s) > 8 || !isAlnum(s) {
+			return "", false
+		}
+		switch {
+		case i == 0:
+			if len(s) < 2 || len(s) > 3 || !isAlpha(s) {
+				return "", false
+			}
+			subtags[i] = strings.ToLower(s)
+		case len(s) == 4 && isAlpha(s):
+			subtags[i] = strings.ToUpper(s[:1]) + strings.ToLower(s[1:])
+		case len(s) == 2 && isAlpha(s), len(s) == 3 && isDigit(s):
+			subtags[i] = strings.ToUpper(s)
+		default:
+			subtags[i] = strings.ToLower(s)
+		}
+	}
+	return strings.Join(subtags, "-"), true
+}
+
+// Language returns the language subtag of a canonical tag.
+func Language(tag string) string {
+	lang, _, _ := strings.Cut(tag, "-")
+	return lang
+}
+
+// ParseAcceptLanguage returns the locales listed in an Accept-Language
+// header by descending quality, in canonical form, keeping the first
+// maxPreferences. Malformed entries, the wildcard and locales with a
+// quality of zero are left out.
+func ParseAcceptLanguage(header string) []string {
+	type entry struct {
+		tag string
+		q   float64
+	}
+	var entries []entry
+	seen := map[string]bool{}
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag, ok := Canonical(strings.TrimSpace(tag))
+		if !ok || seen[tag] {
+			continue
+		}
+
+		q := 1.0
+		if params = strings.TrimSpace(params); params != "" {
+			v, found := strings.CutPrefix(params, "q=")
+			if !found {
+				continue
+			}
+			var err error
+			if q, err = strconv.ParseFloat(strings.TrimSpace(v), 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		if q == 0 {
+			continue
+		}
+		seen[tag] = true
+		entries = append(entries, entry{tag, q})
+	}
+
+	slices.SortStableFunc(entries, func(a, b entry) int {
+		return cmp.Compare(b.q, a.q)
+	})
+	entries = entries[:min(len(entries), maxPreferences)]
+	tags := make([]string, len(entries))
+	for i, e := range entries {
+		tags[i] = e.tag
+	}
+	return tags
+}
+
+// FormatAcceptLanguage returns an Accept-Language header listing prefs in
+// order of preference.
+func FormatAcceptLanguage(prefs []string) string {
+	parts := make([]string, len(prefs))
+	for i, tag := range prefs {
+		parts[i] = tag
+		if q := 1 - float64(i)/10; i > 0 {
+			parts[i] += fmt.Sprintf(";q=%.1f", max(q, 0.1))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Fallbacks returns the locales to try for prefs in order: each preferred
+// locale followed by its less specific forms, e.g. "pt-BR" then "pt".
+// Locales are listed once, where they first come up.
+func Fallbacks(prefs []string) []string {
+	var chain []string
+	seen := map[string]bool{}
+	for _, tag := range prefs {
+		subtags := strings.Split(tag, "-")
+		for n := len(subtags); n > 0; n-- {
+			if t := strings.Join(subtags[:n], "-"); !seen[t] {
+				seen[t] = true
+				chain = append(chain, t)
+			}
+		}
+	}
+	return chain
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the locale preferences of a
+// request.
+func NewContext(ctx context.Context, prefs []string) context.Context {
+	return context.WithValue(ctx, contextKey{}, prefs)
+}
+
+// FromContext returns the locale preferences of the request being served,
+// nil when it had none.
+func FromContext(ctx context.Context) []string {
+	prefs, _ := ctx.Value(contextKey{}).([]string)
+	return prefs
+}
+
+func isAlnum(s string) bool {
+	for _, r := range s {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlpha(s string) bool {
+	for _, r := range s {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
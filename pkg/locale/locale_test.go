@@ -0,0 +1,100 @@
+package locale
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+		ok   bool
+	}{
+		{tag: "en", want: "en", ok: true},
+		{tag: "EN-us", want: "en-US", ok: true},
+		{tag: "pt_br", want: "pt-BR", ok: true},
+		{tag: "zh-hant-tw", want: "zh-Hant-TW", ok: true},
+		{tag: "es-419", want: "es-419", ok: true},
+		{tag: "de-CH-1901", want: "de-CH-1901", ok: true},
+		{tag: ""},
+		{tag: "*"},
+		{tag: "e"},
+		{tag: "engl"},
+		{tag: "en-"},
+		{tag: "-en"},
+		{tag: "en--US"},
+		{tag: "en-US!"},
+		{tag: "1a-US"},
+		{tag: "en-toolongsubtag"},
+		{tag: "en-" + strings.Repeat("a-", 20) + "a"},
+	}
+	for _, tt := range tests {
+		got, ok := Canonical(tt.tag)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Canonical(%q) = %q, %v, want %q, %v", tt.tag, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{header: "", want: []string{}},
+		{header: "fr-CH, fr;q=0.9, en;q=0.8, de;q=0.7, *;q=0.5", want: []string{"fr-CH", "fr", "en", "de"}},
+		{header: "en;q=0.5, pt-br", want: []string{"pt-BR", "en"}},
+		{header: "de;q=0, en", want: []string{"en"}},
+		{header: "en;q=2, fr;q=abc, es;level=1, it", want: []string{"it"}},
+		{header: "en, EN, en-us;q=0.3, en-US", want: []string{"en", "en-US"}},
+		{header: "en;q=0.8, fr;q=0.8, de", want: []string{"de", "en", "fr"}},
+	}
+	for _, tt := range tests {
+		if got := ParseAcceptLanguage(tt.header); !slices.Equal(got, tt.want) {
+			t.Errorf("ParseAcceptLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestParseAcceptLanguageKeepsPreferredLocales(t *testing.T) {
+	var parts []string
+	for i := range 5000 {
+		parts = append(parts, "x"+string(rune('a'+i%26))+string(rune('a'+i/26%26))+";q=0.1")
+	}
+	parts = append(parts, "pt-BR")
+
+	got := ParseAcceptLanguage(strings.Join(parts, ","))
+	if len(got) != maxPreferences {
+		t.Fatalf("got %d locales, want %d", len(got), maxPreferences)
+	}
+	if got[0] != "pt-BR" {
+		t.Errorf("got %q first, want the preferred pt-BR", got[0])
+	}
+}
+
+func TestFallbacks(t *testing.T) {
+	tests := []struct {
+		prefs []string
+		want  []string
+	}{
+		{prefs: nil, want: nil},
+		{prefs: []string{"pt-BR"}, want: []string{"pt-BR", "pt"}},
+		{prefs: []string{"pt-BR", "pt-PT", "en"}, want: []string{"pt-BR", "pt", "pt-PT", "en"}},
+		{prefs: []string{"pt", "pt-BR"}, want: []string{"pt", "pt-BR"}},
+		{prefs: []string{"zh-Hant-TW"}, want: []string{"zh-Hant-TW", "zh-Hant", "zh"}},
+	}
+	for _, tt := range tests {
+		if got := Fallbacks(tt.prefs); !slices.Equal(got, tt.want) {
+			t.Errorf("Fallbacks(%q) = %q, want %q", tt.prefs, got, tt.want)
+		}
+	}
+}
+
+func TestFormatAcceptLanguageRoundTrip(t *testing.T) {
+	prefs := []string{"pt-BR", "pt", "en", "de", "fr", "it", "es", "nl", "sv", "da"}
+	if got := ParseAcceptLanguage(FormatAcceptLanguage(prefs)); !slices.Equal(got, prefs) {
+		t.Errorf("round trip of %q gave %q", prefs, got)
+	}
+}
@@ -0,0 +1,228 @@
+// Package pagination implements the keyset pagination shared by the list
+// endpoints of all services.
+//
+// Listings are sorted by one of a few named orders, each ending with a
+// unique column so that the order is total. Clients pass limit, sort and an
+// opaque cursor taken from the next_cursor or prev_cursor of the previous
+// page. Cursors carry the sort key of the row they point past, so reading a
+// page costs the same wherever it is in the listing.
+package pagination
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/abhishek622/moviedock/pkg/apperr"
+)
+
+// MaxLimit is the largest page size of any listing.
+const MaxLimit = 100
+
+var (
+	// ErrInvalidCursor is returned for cursors that were not issued for the
+	// requested listing.
+	ErrInvalidCursor = apperr.New(apperr.InvalidArgument, "invalid cursor")
+	// ErrInvalidLimit is returned for malformed limits.
+	ErrInvalidLimit = apperr.New(apperr.InvalidArgument, "invalid limit")
+	// ErrInvalidSort is returned for sort orders a listing does not offer.
+	ErrInvalidSort = apperr.New(apperr.InvalidArgument, "invalid sort")
+)
+
+// Request is a request for a page of a listing.
+type Request struct {
+	Limit int
+	// Sort is the name of the sort order, without the "-" prefix of
+	// descending orders.
+	Sort string
+	Desc bool
+	// Before is set when paging backwards from the cursor.
+	Before bool
+	key    json.RawMessage
+}
+
+// cursor is the decoded form of a cursor.
+type cursor struct {
+	Sort   string          `json:"s"`
+	Key    json.RawMessage `json:"k"`
+	Before bool            `json:"b,omitempty"`
+}
+
+// FromQuery parses the limit, sort and cursor query parameters of a page
+// request. Limits default to def and are capped at MaxLimit. The first of
+// sorts is the default order and may itself be prefixed with "-" to default
+// to descending order; clients may prefix any of them. A cursor implies the
+// order it was issued for.
+func FromQuery(q url.Values, def int, sorts ...string) (Request, error) {
+	r := Request{Limit: def}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return Request{}, ErrInvalidLimit
+		}
+		r.Limit = n
+	}
+	r.Limit = min(r.Limit, MaxLimit)
+
+	sort := q.Get("sort")
+	if v := q.Get("cursor"); v != "" {
+		b, err := base64.RawURLEncoding.DecodeString(v)
+		if err != nil {
+			return Request{}, ErrInvalidCursor
+		}
+		var c cursor
+		if err := json.Unmarshal(b, &c); err != nil || len(c.Key) == 0 {
+			return Request{}, ErrInvalidCursor
+		}
+		if sort != "" && sort != c.Sort {
+			return Request{}, ErrInvalidCursor
+		}
+		sort, r.key, r.Before = c.Sort, c.Key, c.Before
+	}
+
+	if sort == "" && len(sorts) > 0 {
+		sort = sorts[0]
+	}
+	r.Sort, r.Desc = strings.TrimPrefix(sort, "-"), strings.HasPrefix(sort, "-")
+	for _, s := range sorts {
+		if strings.TrimPrefix(s, "-") == r.Sort {
+			return r, nil
+		}
+	}
+	return Request{}, ErrInvalidSort
+}
+
+// HasCursor reports whether the request continues from a cursor.
+func (r Request) HasCursor() bool {
+	return r.key != nil
+}
+
+// Values returns the sort key of the cursor, one value per sort
+// expression, as query arguments. Integers are returned as int64 and other
+// numbers as float64.
+func (r Request) Values() ([]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(r.key))
+	dec.UseNumber()
+	var values []any
+	if err := dec.Decode(&values); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	for i, v := range values {
+		n, ok := v.(json.Number)
+		if !ok {
+			continue
+		}
+		if values[i], ok = numberValue(n); !ok {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return values, nil
+}
+
+func numberValue(n json.Number) (any, bool) {
+	if i, err := n.Int64(); err == nil {
+		return i, true
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+// Descending reports whether rows are read in descending order, which is
+// the case for descending orders paged forwards and ascending ones paged
+// backwards.
+func (r Request) Descending() bool {
+	return r.Desc != r.Before
+}
+
+// SQL returns the condition selecting the rows past the cursor, empty
+// without one, and the ORDER BY clause reading them. exprs are the sort
+// expressions and $n is the first of the placeholders of the key values
+// compared to them.
+func (r Request) SQL(exprs []string, n int) (cond, orderBy string) {
+	op, dir := ">", "ASC"
+	if r.Descending() {
+		op, dir = "<", "DESC"
+	}
+
+	order := make([]string, len(exprs))
+	for i, e := range exprs {
+		order[i] = e + " " + dir
+	}
+	orderBy = strings.Join(order, ", ")
+
+	if !r.HasCursor() {
+		return "", orderBy
+	}
+	params := make([]string, len(exprs))
+	for i := range exprs {
+		params[i] = fmt.Sprintf("$%d", n+i)
+	}
+	cond = fmt.Sprintf("(%s) %s (%s)", strings.Join(exprs, ", "), op, strings.Join(params, ", "))
+	return cond, orderBy
+}
+
+// Info holds the cursors of the pages around a page. A cursor is empty when
+// there is no such page.
+type Info struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// Page turns rows read for r, up to Limit+1 of them in read order, into a
+// page in sort order and returns the cursors of the pages around it. key
+// returns the values of the sort expressions for a row.
+func Page[T any](r Request, rows []T, key func(T) []any) ([]T, Info, error) {
+	more := len(rows) > r.Limit
+	if more {
+		rows = rows[:r.Limit]
+	}
+	if r.Before {
+		slices.Reverse(rows)
+	}
+
+	var info Info
+	if len(rows) == 0 {
+		return rows, info, nil
+	}
+
+	// Paging forwards there is a previous page if we came from a cursor,
+	// and the other way round.
+	hasNext, hasPrev := more, r.HasCursor()
+	if r.Before {
+		hasNext, hasPrev = r.HasCursor(), more
+	}
+
+	var err error
+	if hasNext {
+		if info.NextCursor, err = r.encode(key(rows[len(rows)-1]), false); err != nil {
+			return nil, Info{}, err
+		}
+	}
+	if hasPrev {
+		if info.PrevCursor, err = r.encode(key(rows[0]), true); err != nil {
+			return nil, Info{}, err
+		}
+	}
+	return rows, info, nil
+}
+
+func (r Request) encode(key []any, before bool) (string, error) {
+	k, err := json.Marshal(key)
+	if err != nil {
+		return "", fmt.Errorf("error encoding cursor: %w", err)
+	}
+	sort := r.Sort
+	if r.Desc {
+		sort = "-" + sort
+	}
+	b, err := json.Marshal(cursor{Sort: sort, Key: k, Before: before})
+	if err != nil {
+		return "", fmt.Errorf("error encoding cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,147 @@
+package pagination
+
+import (
+	"cmp"
+	"errors"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+type movie struct {
+	title string
+	id    int64
+}
+
+func movieKey(m movie) []any {
+	return []any{m.title, m.id}
+}
+
+func compareKey(m movie, title string, id int64) int {
+	return cmp.Or(cmp.Compare(m.title, title), cmp.Compare(m.id, id))
+}
+
+// read does in memory what the SQL of r does on a table holding movies: it
+// returns up to Limit+1 movies past the cursor, in read order.
+func read(t *testing.T, r Request, movies []movie) []movie {
+	t.Helper()
+	rows := slices.Clone(movies)
+	slices.SortFunc(rows, func(a, b movie) int { return compareKey(a, b.title, b.id) })
+	if r.Descending() {
+		slices.Reverse(rows)
+	}
+	if r.HasCursor() {
+		values, err := r.Values()
+		if err != nil {
+			t.Fatalf("Values: %v", err)
+		}
+		title, id := values[0].(string), values[1].(int64)
+		rows = slices.DeleteFunc(rows, func(m movie) bool {
+			c := compareKey(m, title, id)
+			return c == 0 || (c < 0) != r.Descending()
+		})
+	}
+	return rows[:min(len(rows), r.Limit+1)]
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	movies := []movie{
+		{"Alien", 4}, {"Brazil", 2}, {"Alien", 1}, {"Casablanca", 7},
+		{"Dune", 3}, {"Brazil", 5}, {"Heat", 6},
+	}
+
+	for _, sort := range []string{"title", "-title"} {
+		t.Run(sort, func(t *testing.T) {
+			want := slices.Clone(movies)
+			slices.SortFunc(want, func(a, b movie) int { return compareKey(a, b.title, b.id) })
+			if sort == "-title" {
+				slices.Reverse(want)
+			}
+
+			// Page forwards through the listing, then back from its last page.
+			q := url.Values{"limit": {"3"}, "sort": {sort}}
+			var pages [][]movie
+			var infos []Info
+			for {
+				r, err := FromQuery(q, 10, "title")
+				if err != nil {
+					t.Fatalf("FromQuery: %v", err)
+				}
+				page, info, err := Page(r, read(t, r, movies), movieKey)
+				if err != nil {
+					t.Fatalf("Page: %v", err)
+				}
+				pages, infos = append(pages, page), append(infos, info)
+				if info.NextCursor == "" {
+					break
+				}
+				q = url.Values{"limit": {"3"}, "cursor": {info.NextCursor}}
+			}
+
+			if got := slices.Concat(pages...); !slices.Equal(got, want) {
+				t.Fatalf("paging forwards gave %v, want %v", got, want)
+			}
+			if infos[0].PrevCursor != "" {
+				t.Error("first page has a previous cursor")
+			}
+
+			for i := len(pages) - 1; i > 0; i-- {
+				r, err := FromQuery(url.Values{"limit": {"3"}, "cursor": {infos[i].PrevCursor}}, 10, "title")
+				if err != nil {
+					t.Fatalf("FromQuery: %v", err)
+				}
+				page, info, err := Page(r, read(t, r, movies), movieKey)
+				if err != nil {
+					t.Fatalf("Page: %v", err)
+				}
+				if !slices.Equal(page, pages[i-1]) {
+					t.Errorf("paging back to page %d gave %v, want %v", i-1, page, pages[i-1])
+				}
+				if info.NextCursor == "" {
+					t.Errorf("page %d read backwards has no next cursor", i-1)
+				}
+				if (info.PrevCursor == "") != (i == 1) {
+					t.Errorf("page %d read backwards has previous cursor %q", i-1, info.PrevCursor)
+				}
+			}
+		})
+	}
+}
+
+func TestFromQuery(t *testing.T) {
+	cursor := func(sort string) string {
+		_, info, err := Page(Request{Limit: 1, Sort: sort}, []movie{{"Alien", 1}, {"Brazil", 2}}, movieKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.NextCursor
+	}
+
+	tests := []struct {
+		name    string
+		query   url.Values
+		want    Request
+		wantErr error
+	}{
+		{name: "defaults", query: url.Values{}, want: Request{Limit: 20, Sort: "title"}},
+		{name: "descending", query: url.Values{"sort": {"-id"}}, want: Request{Limit: 20, Sort: "id", Desc: true}},
+		{name: "capped limit", query: url.Values{"limit": {"1000"}}, want: Request{Limit: MaxLimit, Sort: "title"}},
+		{name: "zero limit", query: url.Values{"limit": {"0"}}, wantErr: ErrInvalidLimit},
+		{name: "bad limit", query: url.Values{"limit": {"ten"}}, wantErr: ErrInvalidLimit},
+		{name: "unknown sort", query: url.Values{"sort": {"year"}}, wantErr: ErrInvalidSort},
+		{name: "bad cursor", query: url.Values{"cursor": {"not a cursor"}}, wantErr: ErrInvalidCursor},
+		{name: "cursor of another sort", query: url.Values{"sort": {"id"}, "cursor": {cursor("title")}}, wantErr: ErrInvalidCursor},
+		{name: "cursor of unknown sort", query: url.Values{"cursor": {cursor("year")}}, wantErr: ErrInvalidSort},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromQuery(tt.query, 20, "title", "id")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (got.Limit != tt.want.Limit || got.Sort != tt.want.Sort || got.Desc != tt.want.Desc || got.HasCursor()) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -11,9 +11,12 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/abhishek622/moviedock/pkg/authz"
 	"github.com/abhishek622/moviedock/pkg/discovery"
 	"github.com/abhishek622/moviedock/pkg/discovery/consul"
+	"github.com/abhishek622/moviedock/pkg/interceptor"
 	"github.com/abhishek622/moviedock/rating/internal/controller/rating"
+	metadatagateway "github.com/abhishek622/moviedock/rating/internal/gateway/metadata/http"
 	httphandler "github.com/abhishek622/moviedock/rating/internal/handler/http"
 	"github.com/abhishek622/moviedock/rating/internal/repository/postgres"
 	"github.com/gin-gonic/gin"
@@ -42,13 +45,12 @@ func main() {
 		log.Fatalf("Failed to create repository: %v", err)
 	}
 
-	// Create controller
-	ctrl := rating.New(repo)
-
-	// Create HTTP handler with Gin
-	router := gin.Default()
-	handler := httphandler.New(ctrl)
-	handler.RegisterRoutes(router)
+	// Token verification against the user service signing keys, and API
+	// keys when introspection is configured
+	validator, err := authz.NewValidatorFromEnv(serviceName)
+	if err != nil {
+		log.Fatalf("Failed to configure authentication: %v", err)
+	}
 
 	// Service discovery setup
 	registry, err := consul.NewRegistry(*consulURL)
@@ -56,6 +58,24 @@ func main() {
 		log.Fatalf("Failed to create Consul client: %v", err)
 	}
 
+	// External IDs of rating events are resolved by the metadata service.
+	// The lookup is public, so forwarding the caller's token is enough and
+	// the rating service needs no signing keys for service tokens.
+	client := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &interceptor.Transport{Source: interceptor.ForwardToken()},
+	}
+	metadataGateway := metadatagateway.New(registry, client)
+
+	// Create controller
+	ctrl := rating.New(repo, metadataGateway)
+
+	// Create HTTP handler with Gin
+	router := gin.Default()
+	router.Use(authz.Authenticate(validator))
+	handler := httphandler.New(ctrl)
+	handler.RegisterRoutes(router)
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
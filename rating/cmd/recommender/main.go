@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/abhishek622/moviedock/pkg/discovery/consul"
+	"github.com/abhishek622/moviedock/rating/internal/controller/rating"
+	metadatagateway "github.com/abhishek622/moviedock/rating/internal/gateway/metadata/http"
+	"github.com/abhishek622/moviedock/rating/internal/recommend"
+	"github.com/abhishek622/moviedock/rating/internal/repository/postgres"
+	"github.com/joho/godotenv"
+)
+
+// recommender recomputes the similar movies served by the rating service.
+// It runs once against the rating database, configured like the rating
+// service, and is meant to be scheduled periodically.
+func main() {
+	opts := recommend.DefaultOptions
+	consulURL := flag.String("consul-url", "localhost:8500", "Consul URL")
+	flag.IntVar(&opts.Neighbors, "neighbors", opts.Neighbors, "Similar movies kept per movie")
+	flag.IntVar(&opts.MinCommonRaters, "min-common-raters", opts.MinCommonRaters, "Users who must have rated two movies to compare their ratings")
+	flag.Float64Var(&opts.Shrinkage, "shrinkage", opts.Shrinkage, "Damping of rating similarities with few common raters")
+	flag.Float64Var(&opts.RatingWeight, "rating-weight", opts.RatingWeight, "Weight of rating similarity against genre and director similarity, from 0 to 1")
+	flag.IntVar(&opts.MaxUserRatings, "max-user-ratings", opts.MaxUserRatings, "Most recent ratings of each user compared, 0 for all")
+	flag.Parse()
+	if opts.Neighbors < 1 || opts.MinCommonRaters < 1 || opts.Shrinkage < 0 || opts.RatingWeight < 0 || opts.RatingWeight > 1 || opts.MaxUserRatings < 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := godotenv.Load(".env"); err != nil {
+		log.Println("Warning: unable to find .env file")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	repo, err := postgres.New()
+	if err != nil {
+		log.Fatalf("Failed to create repository: %v", err)
+	}
+
+	// Movie genres and directors are read from the public metadata API, so
+	// the job calls it without a token
+	registry, err := consul.NewRegistry(*consulURL)
+	if err != nil {
+		log.Fatalf("Failed to create Consul client: %v", err)
+	}
+	metadataGateway := metadatagateway.New(registry, &http.Client{Timeout: 30 * time.Second})
+
+	start := time.Now()
+	n, err := rating.New(repo, metadataGateway).RefreshSimilarities(ctx, opts)
+	if err != nil {
+		log.Fatalf("Failed to compute similarities: %v", err)
+	}
+	log.Printf("Stored %d similarities in %s", n, time.Since(start).Round(time.Millisecond))
+}
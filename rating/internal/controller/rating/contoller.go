@@ -4,33 +4,61 @@ import (
 	"context"
 	"errors"
 
+	metadatamodel "github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/pagination"
 	"github.com/abhishek622/moviedock/rating/internal/repository"
 	"github.com/abhishek622/moviedock/rating/pkg/model"
 )
 
-// ErrNotFound is returned when no ratings are found for a record.
-var ErrNotFound = errors.New("ratings not found for a record")
+var (
+	// ErrNotFound is returned when no ratings are found for a record.
+	ErrNotFound = apperr.New(apperr.NotFound, "ratings not found for a record")
+	// ErrInvalidRecordType is returned for record types that cannot be rated.
+	ErrInvalidRecordType = apperr.New(apperr.InvalidArgument, "invalid record type")
+	// ErrTooManyRecords is returned when aggregating too many records at once.
+	ErrTooManyRecords = apperr.New(apperr.InvalidArgument, "too many records")
+)
+
+// maxAggregatedRecords limits the records aggregated in one request.
+const maxAggregatedRecords = 500
 
 type ratingRepository interface {
 	Get(ctx context.Context, recordID model.RecordID, recordType model.RecordType) ([]model.Rating, error)
+	GetAggregated(ctx context.Context, recordType model.RecordType, recordIDs []model.RecordID) ([]model.AggregatedRating, error)
 	Put(ctx context.Context, recordID model.RecordID, recordType model.RecordType, rating *model.Rating) error
+	ListUserRatings(ctx context.Context, filter model.RatingFilter) ([]model.Rating, pagination.Info, error)
 	Delete(ctx context.Context, userID model.UserID) error
+	DeleteRating(ctx context.Context, recordID model.RecordID, recordType model.RecordType, userID model.UserID) error
+	ListRatings(ctx context.Context, recordType model.RecordType) ([]model.Rating, error)
+	ReplaceSimilarities(ctx context.Context, sims []model.Similarity) error
+	ListSimilar(ctx context.Context, recordID model.RecordID, limit int) ([]model.Similarity, error)
+	Recommend(ctx context.Context, userID model.UserID, limit int) ([]model.Recommendation, error)
+}
+
+type metadataGateway interface {
+	LookupExternalIDs(ctx context.Context, provider string, externalIDs []string) ([]*metadatamodel.ExternalID, error)
+	ListMetadata(ctx context.Context) ([]*metadatamodel.Metadata, error)
 }
 
 // New creates a rating service controller.
 type Controller struct {
-	repo ratingRepository
+	repo            ratingRepository
+	metadataGateway metadataGateway
 }
 
 // Controller defines a rating service controller.
-func New(repo ratingRepository) *Controller {
-	return &Controller{repo}
+func New(repo ratingRepository, metadataGateway metadataGateway) *Controller {
+	return &Controller{repo, metadataGateway}
 }
 
 // GetAggregatedRating returns the aggregated rating for a record or ErrNotFound if there are no ratings for it.
 func (c *Controller) GetAggregatedRating(ctx context.Context, recordID model.RecordID, recordType model.RecordType) (float64, error) {
+	if !recordType.Valid() {
+		return 0, ErrInvalidRecordType
+	}
 	ratings, err := c.repo.Get(ctx, recordID, recordType)
-	if err != nil && err == repository.ErrNotFound {
+	if err != nil && errors.Is(err, repository.ErrNotFound) {
 		return 0, ErrNotFound
 	} else if err != nil {
 		return 0, err
@@ -42,11 +70,50 @@ func (c *Controller) GetAggregatedRating(ctx context.Context, recordID model.Rec
 	return sum / float64(len(ratings)), nil
 }
 
+// GetAggregatedRatings returns the aggregated ratings of several records of
+// the same type. Records without ratings are left out.
+func (c *Controller) GetAggregatedRatings(ctx context.Context, recordType model.RecordType, recordIDs []model.RecordID) ([]model.AggregatedRating, error) {
+	if !recordType.Valid() {
+		return nil, ErrInvalidRecordType
+	}
+	if len(recordIDs) > maxAggregatedRecords {
+		return nil, ErrTooManyRecords
+	}
+	if len(recordIDs) == 0 {
+		return []model.AggregatedRating{}, nil
+	}
+	return c.repo.GetAggregated(ctx, recordType, recordIDs)
+}
+
 // PutRating writes a rating for a given record.
 func (c *Controller) PutRating(ctx context.Context, recordID model.RecordID, recordType model.RecordType, rating *model.Rating) error {
+	if !recordType.Valid() {
+		return ErrInvalidRecordType
+	}
 	return c.repo.Put(ctx, recordID, recordType, rating)
 }
 
-func (c *Controller) DeleteRating(ctx context.Context, userID model.UserID) error {
+// DeleteRating removes a user's rating of a record or returns ErrNotFound if the user has not rated it.
+func (c *Controller) DeleteRating(ctx context.Context, recordID model.RecordID, recordType model.RecordType, userID model.UserID) error {
+	if !recordType.Valid() {
+		return ErrInvalidRecordType
+	}
+	err := c.repo.DeleteRating(ctx, recordID, recordType, userID)
+	if err != nil && errors.Is(err, repository.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// ListUserRatings returns a page of the ratings written by a user.
+func (c *Controller) ListUserRatings(ctx context.Context, filter model.RatingFilter) ([]model.Rating, pagination.Info, error) {
+	if filter.RecordType != "" && !filter.RecordType.Valid() {
+		return nil, pagination.Info{}, ErrInvalidRecordType
+	}
+	return c.repo.ListUserRatings(ctx, filter)
+}
+
+// DeleteUserRatings removes all ratings written by a user.
+func (c *Controller) DeleteUserRatings(ctx context.Context, userID model.UserID) error {
 	return c.repo.Delete(ctx, userID)
 }
@@ -0,0 +1,160 @@
+package rating
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/rating/pkg/model"
+)
+
+var (
+	// ErrTooManyEvents is returned when ingesting too many events at once.
+	ErrTooManyEvents = apperr.New(apperr.InvalidArgument, "too many events")
+	// ErrMissingProvider is reported for events naming a record by external
+	// ID without a provider.
+	ErrMissingProvider = apperr.New(apperr.InvalidArgument, "provider_id is required with external_id")
+	// ErrExternalIDNotMovie is reported for events naming a record other
+	// than a movie by external ID.
+	ErrExternalIDNotMovie = apperr.New(apperr.InvalidArgument, "external ids are only resolved for movies")
+	// ErrUnknownExternalID is reported for events naming a record by an
+	// external ID mapped to no movie.
+	ErrUnknownExternalID = apperr.New(apperr.NotFound, "no movie with this external id")
+	// ErrMissingUser is reported for events without a user ID.
+	ErrMissingUser = apperr.New(apperr.InvalidArgument, "user_id is required")
+	// ErrInvalidValue is reported for ratings outside of 1 to 10.
+	ErrInvalidValue = apperr.New(apperr.InvalidArgument, "value must be between 1 and 10")
+	// ErrInvalidEventType is reported for events that are neither puts nor
+	// deletes.
+	ErrInvalidEventType = apperr.New(apperr.InvalidArgument, "invalid event type")
+)
+
+const (
+	maxIngestEvents = 1000
+	// lookupBatchSize is how many external IDs are resolved per request to
+	// the metadata service.
+	lookupBatchSize = 500
+)
+
+// externalKey identifies a record by its ID at a provider.
+type externalKey struct {
+	provider, externalID string
+}
+
+// externalKeyOf returns the key of the record an event names by external
+// ID, normalized like the metadata service stores them.
+func externalKeyOf(e *model.RatingEvent) externalKey {
+	return externalKey{
+		provider:   strings.ToLower(strings.TrimSpace(e.ProviderID)),
+		externalID: strings.TrimSpace(e.ExternalID),
+	}
+}
+
+// Ingest applies a batch of rating events from data providers. Movies named
+// by their ID at the provider of an event are resolved through the metadata
+// service. Failed events are reported and do not stop the others.
+func (c *Controller) Ingest(ctx context.Context, events []model.RatingEvent) (*model.IngestReport, error) {
+	if len(events) > maxIngestEvents {
+		return nil, ErrTooManyEvents
+	}
+
+	resolved, err := c.resolveExternalIDs(ctx, events)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.IngestReport{Errors: []model.IngestError{}}
+	for i := range events {
+		if err := c.applyEvent(ctx, &events[i], resolved); err != nil {
+			if apperr.KindOf(err) == apperr.Internal {
+				log.Printf("Failed to ingest rating event: %v", err)
+			}
+			report.Failed++
+			report.Errors = append(report.Errors, model.IngestError{Index: i, Error: apperr.Message(err)})
+			continue
+		}
+		report.Processed++
+	}
+	return report, nil
+}
+
+// resolveExternalIDs looks up the movies named by external ID in events.
+func (c *Controller) resolveExternalIDs(ctx context.Context, events []model.RatingEvent) (map[externalKey]model.RecordID, error) {
+	byProvider := map[string][]string{}
+	seen := map[externalKey]bool{}
+	for _, e := range events {
+		key := externalKeyOf(&e)
+		if key.externalID == "" || key.provider == "" || e.RecordType != model.RecordTypeMovie {
+			continue
+		}
+		if !seen[key] {
+			seen[key] = true
+			byProvider[key.provider] = append(byProvider[key.provider], key.externalID)
+		}
+	}
+
+	resolved := make(map[externalKey]model.RecordID, len(seen))
+	if len(seen) == 0 {
+		return resolved, nil
+	}
+	if c.metadataGateway == nil {
+		return nil, errors.New("external ids cannot be resolved without a metadata gateway")
+	}
+
+	for provider, ids := range byProvider {
+		for start := 0; start < len(ids); start += lookupBatchSize {
+			batch := ids[start:min(start+lookupBatchSize, len(ids))]
+			mappings, err := c.metadataGateway.LookupExternalIDs(ctx, provider, batch)
+			if err != nil {
+				return nil, fmt.Errorf("error resolving %s ids: %w", provider, err)
+			}
+			for _, m := range mappings {
+				resolved[externalKey{provider, m.ExternalID}] = model.RecordID(m.MetadataID)
+			}
+		}
+	}
+	return resolved, nil
+}
+
+func (c *Controller) applyEvent(ctx context.Context, e *model.RatingEvent, resolved map[externalKey]model.RecordID) error {
+	recordID := e.RecordID
+	if key := externalKeyOf(e); key.externalID != "" {
+		if key.provider == "" {
+			return ErrMissingProvider
+		}
+		if e.RecordType != model.RecordTypeMovie {
+			return ErrExternalIDNotMovie
+		}
+		id, ok := resolved[key]
+		if !ok {
+			return ErrUnknownExternalID
+		}
+		recordID = id
+	}
+	if e.UserID == "" {
+		return ErrMissingUser
+	}
+
+	switch e.EventType {
+	case model.RatingEventTypePut:
+		if e.Value < 1 || e.Value > 10 {
+			return ErrInvalidValue
+		}
+		return c.PutRating(ctx, recordID, e.RecordType, &model.Rating{
+			RecordID:   recordID,
+			RecordType: e.RecordType,
+			UserID:     e.UserID,
+			Value:      e.Value,
+		})
+	case model.RatingEventTypeDelete:
+		// Deleting a rating that is already gone is not an error
+		if err := c.DeleteRating(ctx, recordID, e.RecordType, e.UserID); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		return nil
+	}
+	return ErrInvalidEventType
+}
@@ -0,0 +1,63 @@
+package rating
+
+import (
+	"context"
+	"log"
+
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/rating/internal/recommend"
+	"github.com/abhishek622/moviedock/rating/pkg/model"
+)
+
+// ErrSimilarUnsupported is returned when asking for records similar to a
+// record that is not a movie.
+var ErrSimilarUnsupported = apperr.New(apperr.InvalidArgument, "similar records are only computed for movies")
+
+// maxRecommendations limits the similar movies and recommendations returned
+// at once.
+const maxRecommendations = 50
+
+// Similar returns the movies most similar to a movie, as last computed by
+// RefreshSimilarities. Movies without computed similarities have none.
+func (c *Controller) Similar(ctx context.Context, recordID model.RecordID, recordType model.RecordType, limit int) ([]model.Similarity, error) {
+	if !recordType.Valid() {
+		return nil, ErrInvalidRecordType
+	}
+	if recordType != model.RecordTypeMovie {
+		return nil, ErrSimilarUnsupported
+	}
+	return c.repo.ListSimilar(ctx, recordID, min(limit, maxRecommendations))
+}
+
+// Recommend returns the movies a user is predicted to like best among the
+// ones similar to the movies the user rated. Users who rated no movies get
+// none.
+func (c *Controller) Recommend(ctx context.Context, userID model.UserID, limit int) ([]model.Recommendation, error) {
+	return c.repo.Recommend(ctx, userID, min(limit, maxRecommendations))
+}
+
+// RefreshSimilarities recomputes the similarities of all movies from their
+// ratings and the catalog features of the metadata service, replacing the
+// stored ones. It returns the number of similarities stored.
+func (c *Controller) RefreshSimilarities(ctx context.Context, opts recommend.Options) (int, error) {
+	movies, err := c.metadataGateway.ListMetadata(ctx)
+	if err != nil {
+		return 0, err
+	}
+	features := make(map[model.RecordID]recommend.Features, len(movies))
+	for _, m := range movies {
+		features[model.RecordID(m.MetadataID)] = recommend.Features{Genres: m.Genres, Director: m.Director}
+	}
+
+	ratings, err := c.repo.ListRatings(ctx, model.RecordTypeMovie)
+	if err != nil {
+		return 0, err
+	}
+	log.Printf("Computing similarities of %d movies from %d ratings", len(features), len(ratings))
+
+	sims := recommend.Similarities(ratings, features, opts)
+	if err := c.repo.ReplaceSimilarities(ctx, sims); err != nil {
+		return 0, err
+	}
+	return len(sims), nil
+}
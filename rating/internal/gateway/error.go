@@ -0,0 +1,5 @@
+package gateway
+
+import "github.com/abhishek622/moviedock/pkg/apperr"
+
+var ErrNotFound = apperr.New(apperr.NotFound, "not found")
@@ -0,0 +1,91 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/abhishek622/moviedock/metadata/pkg/model"
+	"github.com/abhishek622/moviedock/pkg/discovery"
+	"github.com/abhishek622/moviedock/pkg/pagination"
+	"github.com/abhishek622/moviedock/rating/internal/gateway"
+)
+
+type Gateway struct {
+	registry discovery.Registry
+	client   *http.Client
+}
+
+func New(registry discovery.Registry, client *http.Client) *Gateway {
+	return &Gateway{registry, client}
+}
+
+// LookupExternalIDs resolves identifiers at a provider to metadata IDs.
+// Unknown identifiers are left out.
+func (g *Gateway) LookupExternalIDs(ctx context.Context, provider string, externalIDs []string) ([]*model.ExternalID, error) {
+	query := url.Values{"ids": externalIDs}
+	var v struct {
+		ExternalIDs []*model.ExternalID `json:"external_ids"`
+	}
+	if err := g.get(ctx, "/api/v1/external_ids/"+url.PathEscape(provider)+"?"+query.Encode(), &v); err != nil {
+		return nil, err
+	}
+	return v.ExternalIDs, nil
+}
+
+// ListMetadata returns the metadata of all movies, fetching the catalog
+// page by page.
+func (g *Gateway) ListMetadata(ctx context.Context) ([]*model.Metadata, error) {
+	var res []*model.Metadata
+	query := url.Values{"limit": {strconv.Itoa(pagination.MaxLimit)}}
+	for {
+		var v struct {
+			Metadata   []*model.Metadata `json:"metadata"`
+			NextCursor string            `json:"next_cursor"`
+		}
+		if err := g.get(ctx, "/api/v1/metadata?"+query.Encode(), &v); err != nil {
+			return nil, err
+		}
+		res = append(res, v.Metadata...)
+		if v.NextCursor == "" {
+			return res, nil
+		}
+		query.Set("cursor", v.NextCursor)
+	}
+}
+
+// get decodes the JSON response to a GET request for path on a metadata
+// service instance into v.
+func (g *Gateway) get(ctx context.Context, path string, v any) error {
+	addrs, err := g.registry.ServiceAddresses(ctx, "metadata")
+	if err != nil {
+		return err
+	}
+
+	addr := addrs[rand.Intn(len(addrs))]
+	endpoint := "http://" + addr + path
+	log.Printf("Calling metadata service. Request: GET %s", endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return gateway.ErrNotFound
+	} else if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("non-2xx response: %v", resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -1,12 +1,16 @@
 package http
 
 import (
-	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/authz"
+	"github.com/abhishek622/moviedock/pkg/pagination"
 	"github.com/abhishek622/moviedock/rating/internal/controller/rating"
 	"github.com/abhishek622/moviedock/rating/pkg/model"
+	usermodel "github.com/abhishek622/moviedock/user/pkg/model"
 	"github.com/gin-gonic/gin"
 )
 
@@ -28,26 +32,93 @@ func (h *Handler) RegisterRoutes(router *gin.Engine) {
 	// API v1 routes
 	v1 := router.Group("/api/v1/rating")
 	{
-		v1.POST("", h.PutRating)
-		v1.GET("", h.GetAggregatedRating)
+		v1.GET("/:record_type", h.GetAggregatedRatings)
+		v1.GET("/:record_type/:id", h.GetAggregatedRating)
+		v1.GET("/:record_type/:id/similar", h.GetSimilar)
+
+		// Users may only write and delete their own ratings, with API keys
+		// granted the ratings:write scope
+		write := authz.Require(authz.APIKeyScope(authz.ScopeRatingsWrite))
+		v1.PUT("/:record_type/:id", write, h.PutRating)
+		v1.DELETE("/:record_type/:id", write, h.DeleteRating)
+
+		// The rating history and recommendations of a user are visible to the
+		// user, with API keys granted the ratings:read scope, and admins
+		ownerOrAdmin := authz.AnyOf(
+			authz.AllOf(authz.Owner(), authz.APIKeyScope(authz.ScopeRatingsRead)),
+			authz.RequireRole(usermodel.RoleAdmin),
+		)
+		v1.GET("/user/:user_id", authz.RequireOwned("user_id", ownerOrAdmin), h.ListUserRatings)
+		v1.GET("/user/:user_id/recommendations", authz.RequireOwned("user_id", ownerOrAdmin), h.GetRecommendations)
+
+		// Removing all ratings of a user is allowed to the user from a login
+		// session, admins and services granted the ratings:delete scope
+		v1.DELETE("/user/:user_id", authz.RequireOwned("user_id", authz.AnyOf(
+			authz.AllOf(authz.Owner(), authz.RejectAPIKeys()),
+			authz.RequireRole(usermodel.RoleAdmin),
+			authz.RequireScope(authz.ScopeRatingsDelete),
+		)), h.DeleteUserRatings)
+
+		// Data providers feed rating events with an API key granted the
+		// ratings:ingest scope
+		v1.POST("/events", authz.Require(authz.AnyOf(
+			authz.RequireRole(usermodel.RoleAdmin),
+			authz.RequireScope(authz.ScopeRatingsIngest),
+		)), h.IngestEvents)
+	}
+}
+
+// IngestEventsRequest represents the JSON payload for ingesting rating events
+type IngestEventsRequest struct {
+	Events []model.RatingEvent `json:"events" binding:"required"`
+}
+
+// IngestEvents applies a batch of rating events, reporting the failed ones.
+func (h *Handler) IngestEvents(c *gin.Context) {
+	var req IngestEventsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.ctrl.Ingest(c.Request.Context(), req.Events)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
 	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// PutRatingRequest represents the JSON payload for rating a record
+type PutRatingRequest struct {
+	Value model.RatingValue `json:"value" binding:"required,min=1,max=10"`
 }
 
 func (h *Handler) PutRating(c *gin.Context) {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
 
-	var req model.Rating
+	var req PutRatingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.ctrl.PutRating(c.Request.Context(), model.RecordID(id), model.RecordType(req.RecordType), &req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+	recordType := model.RecordType(c.Param("record_type"))
+	r := &model.Rating{
+		RecordID:   model.RecordID(id),
+		RecordType: recordType,
+		UserID:     model.UserID(claims.UserID),
+		Value:      req.Value,
+	}
+
+	if err := h.ctrl.PutRating(c.Request.Context(), model.RecordID(id), recordType, r); err != nil {
+		apperr.Respond(c, err)
 		return
 	}
 
@@ -55,7 +126,7 @@ func (h *Handler) PutRating(c *gin.Context) {
 }
 
 func (h *Handler) GetAggregatedRating(c *gin.Context) {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
@@ -69,22 +140,144 @@ func (h *Handler) GetAggregatedRating(c *gin.Context) {
 	}
 
 	v, err := h.ctrl.GetAggregatedRating(c.Request.Context(), recordID, recordType)
-	if err != nil && errors.Is(err, rating.ErrNotFound) {
-		c.JSON(http.StatusNotFound, gin.H{"error": "rating not found"})
+	if err != nil {
+		apperr.Respond(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"rating": v})
 }
 
+// GetAggregatedRatings returns the aggregated ratings of the records of a
+// type listed in the comma separated ids query parameter
+func (h *Handler) GetAggregatedRatings(c *gin.Context) {
+	var ids []model.RecordID
+	for _, s := range strings.Split(c.Query("ids"), ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ids"})
+			return
+		}
+		ids = append(ids, model.RecordID(id))
+	}
+
+	ratings, err := h.ctrl.GetAggregatedRatings(c.Request.Context(), model.RecordType(c.Param("record_type")), ids)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ratings": ratings})
+}
+
+// DeleteRating removes the caller's rating of a record
 func (h *Handler) DeleteRating(c *gin.Context) {
-	id := c.Param("id")
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+	recordType := model.RecordType(c.Param("record_type"))
+	if err := h.ctrl.DeleteRating(c.Request.Context(), model.RecordID(id), recordType, model.UserID(claims.UserID)); err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListUserRatings returns a page of the ratings written by a user, most
+// recently updated first, optionally filtered by record type
+func (h *Handler) ListUserRatings(c *gin.Context) {
+	page, err := pagination.FromQuery(c.Request.URL.Query(), 20, "-updated_at")
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	ratings, info, err := h.ctrl.ListUserRatings(c.Request.Context(), model.RatingFilter{
+		UserID:     model.UserID(c.Param("user_id")),
+		RecordType: model.RecordType(c.Query("record_type")),
+		Page:       page,
+	})
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	res := gin.H{"ratings": ratings}
+	if info.NextCursor != "" {
+		res["next_cursor"] = info.NextCursor
+	}
+	if info.PrevCursor != "" {
+		res["prev_cursor"] = info.PrevCursor
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// DeleteUserRatings removes all ratings written by a user
+func (h *Handler) DeleteUserRatings(c *gin.Context) {
+	id := c.Param("user_id")
 	if id == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
-	if err := h.ctrl.DeleteRating(c.Request.Context(), model.UserID(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.ctrl.DeleteUserRatings(c.Request.Context(), model.UserID(id)); err != nil {
+		apperr.Respond(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	c.Status(http.StatusNoContent)
+}
+
+// GetSimilar returns the movies most similar to a movie
+func (h *Handler) GetSimilar(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	limit, ok := queryLimit(c)
+	if !ok {
+		return
+	}
+
+	recordType := model.RecordType(c.Param("record_type"))
+	similar, err := h.ctrl.Similar(c.Request.Context(), model.RecordID(id), recordType, limit)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"similar": similar})
+}
+
+// GetRecommendations returns the movies recommended to a user
+func (h *Handler) GetRecommendations(c *gin.Context) {
+	limit, ok := queryLimit(c)
+	if !ok {
+		return
+	}
+
+	recommendations, err := h.ctrl.Recommend(c.Request.Context(), model.UserID(c.Param("user_id")), limit)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recommendations": recommendations})
+}
+
+// queryLimit parses the limit query parameter, defaulting to 10, responding
+// with an error if it is malformed
+func queryLimit(c *gin.Context) (int, bool) {
+	v := c.Query("limit")
+	if v == "" {
+		return 10, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return 0, false
+	}
+	return n, true
 }
@@ -0,0 +1,310 @@
+// Package recommend computes which movies are alike, blending item-item
+// collaborative filtering over user ratings with the genres and directors
+// movies share.
+package recommend
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abhishek622/moviedock/rating/pkg/model"
+)
+
+// Weights of the features making up the feature similarity of two movies.
+const (
+	genreWeight    = 0.6
+	directorWeight = 0.4
+)
+
+// Features describes a movie for the feature similarity.
+type Features struct {
+	Genres   []string
+	Director string
+}
+
+// Options tune the similarity computation.
+type Options struct {
+	// Neighbors is the number of similar movies kept for each movie.
+	Neighbors int
+	// MinCommonRaters is the number of users who must have rated both
+	// movies for their ratings to be compared.
+	MinCommonRaters int
+	// Shrinkage damps the rating similarity of movies with few common
+	// raters, scaling it by n/(n+Shrinkage) for n common raters.
+	Shrinkage float64
+	// RatingWeight is the weight of the rating similarity, from 0 to 1,
+	// against the feature similarity.
+	RatingWeight float64
+	// MaxUserRatings is the number of most recent ratings of each user
+	// that are compared, as a user contributes a pair for every two movies
+	// they rated. Zero compares all of them.
+	MaxUserRatings int
+}
+
+// DefaultOptions are the options of the recommender job.
+var DefaultOptions = Options{
+	Neighbors:       20,
+	MinCommonRaters: 3,
+	Shrinkage:       10,
+	RatingWeight:    0.7,
+	MaxUserRatings:  500,
+}
+
+// pair identifies two movies, the lower ID first.
+type pair struct {
+	a, b model.RecordID
+}
+
+func newPair(a, b model.RecordID) pair {
+	if a > b {
+		a, b = b, a
+	}
+	return pair{a, b}
+}
+
+// Similarities returns the most similar movies of each movie in features,
+// best first. Ratings of movies missing from features are ignored, so that
+// deleted movies are neither compared nor recommended.
+func Similarities(ratings []model.Rating, features map[model.RecordID]Features, opts Options) []model.Similarity {
+	f := newFeatureIndex(features)
+	scores := ratingSimilarities(ratings, features, opts)
+	for p, s := range scores {
+		scores[p] = opts.RatingWeight*s + (1-opts.RatingWeight)*f.similarity(p.a, p.b)
+	}
+	// Movies alike only in their features are limited to the best feature
+	// neighbors of each movie: a pair outside them cannot make the top
+	// neighbors of either movie, which have at least as many better ones.
+	for p, s := range f.neighbors(opts.Neighbors) {
+		if _, ok := scores[p]; !ok {
+			scores[p] = (1 - opts.RatingWeight) * s
+		}
+	}
+
+	neighbors := map[model.RecordID][]model.Similarity{}
+	for p, s := range scores {
+		if s <= 0 {
+			continue
+		}
+		neighbors[p.a] = append(neighbors[p.a], model.Similarity{RecordID: p.a, SimilarID: p.b, Score: s})
+		neighbors[p.b] = append(neighbors[p.b], model.Similarity{RecordID: p.b, SimilarID: p.a, Score: s})
+	}
+
+	ids := make([]model.RecordID, 0, len(neighbors))
+	for id := range neighbors {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var res []model.Similarity
+	for _, id := range ids {
+		sims := neighbors[id]
+		sort.Slice(sims, func(i, j int) bool { return better(sims[i], sims[j]) })
+		if len(sims) > opts.Neighbors {
+			sims = sims[:opts.Neighbors]
+		}
+		res = append(res, sims...)
+	}
+	return res
+}
+
+// ratingSimilarities returns the adjusted cosine similarity of movies rated
+// by the same users: ratings are centered on the mean rating of their user,
+// so that movies are alike when users liked or disliked both compared to
+// their other ratings.
+func ratingSimilarities(ratings []model.Rating, features map[model.RecordID]Features, opts Options) map[pair]float64 {
+	type deviation struct {
+		id    model.RecordID
+		value float64
+	}
+	byUser := map[model.UserID][]model.Rating{}
+	for _, r := range ratings {
+		if _, ok := features[r.RecordID]; !ok {
+			continue
+		}
+		byUser[r.UserID] = append(byUser[r.UserID], r)
+	}
+
+	type stats struct {
+		dot    float64
+		raters int
+	}
+	pairs := map[pair]*stats{}
+	norms := map[model.RecordID]float64{}
+	for _, rs := range byUser {
+		rs = mostRecent(rs, opts.MaxUserRatings)
+		devs := make([]deviation, len(rs))
+		for i, r := range rs {
+			devs[i] = deviation{r.RecordID, float64(r.Value)}
+		}
+
+		var mean float64
+		for _, d := range devs {
+			mean += d.value
+		}
+		mean /= float64(len(devs))
+		for i := range devs {
+			devs[i].value -= mean
+			norms[devs[i].id] += devs[i].value * devs[i].value
+		}
+
+		for i := range devs {
+			for j := i + 1; j < len(devs); j++ {
+				p := newPair(devs[i].id, devs[j].id)
+				s, ok := pairs[p]
+				if !ok {
+					s = &stats{}
+					pairs[p] = s
+				}
+				s.dot += devs[i].value * devs[j].value
+				s.raters++
+			}
+		}
+	}
+
+	res := make(map[pair]float64, len(pairs))
+	for p, s := range pairs {
+		if s.raters < opts.MinCommonRaters || s.dot <= 0 {
+			continue
+		}
+		sim := s.dot / math.Sqrt(norms[p.a]*norms[p.b])
+		res[p] = sim * float64(s.raters) / (float64(s.raters) + opts.Shrinkage)
+	}
+	return res
+}
+
+// mostRecent returns the n most recently updated of the ratings of a user,
+// or all of them when n is zero. Ratings updated at the same time are kept
+// by movie ID, so that the result does not depend on their order.
+func mostRecent(ratings []model.Rating, n int) []model.Rating {
+	if n <= 0 || len(ratings) <= n {
+		return ratings
+	}
+	updatedAt := func(r model.Rating) time.Time {
+		if r.UpdatedAt == nil {
+			return time.Time{}
+		}
+		return *r.UpdatedAt
+	}
+	sort.Slice(ratings, func(i, j int) bool {
+		a, b := updatedAt(ratings[i]), updatedAt(ratings[j])
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return ratings[i].RecordID < ratings[j].RecordID
+	})
+	return ratings[:n]
+}
+
+// featureIndex looks up the movies sharing a genre or their director.
+type featureIndex struct {
+	genres     map[model.RecordID]map[string]bool
+	directors  map[model.RecordID]string
+	byGenre    map[string][]model.RecordID
+	byDirector map[string][]model.RecordID
+}
+
+func newFeatureIndex(features map[model.RecordID]Features) *featureIndex {
+	f := &featureIndex{
+		genres:     make(map[model.RecordID]map[string]bool, len(features)),
+		directors:  map[model.RecordID]string{},
+		byGenre:    map[string][]model.RecordID{},
+		byDirector: map[string][]model.RecordID{},
+	}
+	for id, feat := range features {
+		f.genres[id] = map[string]bool{}
+		for _, g := range feat.Genres {
+			g = strings.ToLower(strings.TrimSpace(g))
+			if g != "" && !f.genres[id][g] {
+				f.genres[id][g] = true
+				f.byGenre[g] = append(f.byGenre[g], id)
+			}
+		}
+		if d := strings.ToLower(strings.TrimSpace(feat.Director)); d != "" {
+			f.directors[id] = d
+			f.byDirector[d] = append(f.byDirector[d], id)
+		}
+	}
+	return f
+}
+
+// similarity returns the feature similarity of two movies: the Jaccard
+// index of their genres and whether they have the same director, weighted
+// together.
+func (f *featureIndex) similarity(a, b model.RecordID) float64 {
+	shared := 0
+	for g := range f.genres[a] {
+		if f.genres[b][g] {
+			shared++
+		}
+	}
+	return f.score(a, b, shared)
+}
+
+func (f *featureIndex) score(a, b model.RecordID, sharedGenres int) float64 {
+	var s float64
+	if union := len(f.genres[a]) + len(f.genres[b]) - sharedGenres; union > 0 {
+		s = genreWeight * float64(sharedGenres) / float64(union)
+	}
+	if d := f.directors[a]; d != "" && d == f.directors[b] {
+		s += directorWeight
+	}
+	return s
+}
+
+// neighbors returns the feature similarities of each movie with at most k
+// of the movies it is most alike. Candidates are scored one movie at a
+// time, so memory stays linear in the number of movies however many share
+// a genre.
+func (f *featureIndex) neighbors(k int) map[pair]float64 {
+	res := map[pair]float64{}
+	shared := map[model.RecordID]int{}
+	var best []model.Similarity
+	for a := range f.genres {
+		clear(shared)
+		for g := range f.genres[a] {
+			for _, b := range f.byGenre[g] {
+				shared[b]++
+			}
+		}
+		for _, b := range f.byDirector[f.directors[a]] {
+			if _, ok := shared[b]; !ok {
+				shared[b] = 0
+			}
+		}
+		delete(shared, a)
+
+		best = best[:0]
+		for b, n := range shared {
+			best = insertTop(best, model.Similarity{RecordID: a, SimilarID: b, Score: f.score(a, b, n)}, k)
+		}
+		for _, s := range best {
+			res[newPair(a, s.SimilarID)] = s.Score
+		}
+	}
+	return res
+}
+
+// insertTop inserts s into sims, which is sorted best first, keeping at
+// most k similarities.
+func insertTop(sims []model.Similarity, s model.Similarity, k int) []model.Similarity {
+	i := sort.Search(len(sims), func(i int) bool { return better(s, sims[i]) })
+	if i >= k {
+		return sims
+	}
+	if len(sims) < k {
+		sims = append(sims, model.Similarity{})
+	}
+	copy(sims[i+1:], sims[i:])
+	sims[i] = s
+	return sims
+}
+
+// better reports whether a ranks before b: by score, then by ID.
+func better(a, b model.Similarity) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	return a.SimilarID < b.SimilarID
+}
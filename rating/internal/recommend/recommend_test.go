@@ -0,0 +1,161 @@
+package recommend
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/abhishek622/moviedock/rating/pkg/model"
+)
+
+// rate returns the ratings users give movies 1, 2 and 3, one row per user.
+func rate(values ...[3]model.RatingValue) []model.Rating {
+	var res []model.Rating
+	for i, v := range values {
+		user := model.UserID(rune('a' + i))
+		for j, value := range v {
+			res = append(res, model.Rating{RecordID: model.RecordID(j + 1), UserID: user, Value: value})
+		}
+	}
+	return res
+}
+
+// score returns the similarity of a to b in sims, or 0 without one.
+func score(sims []model.Similarity, a, b model.RecordID) float64 {
+	for _, s := range sims {
+		if s.RecordID == a && s.SimilarID == b {
+			return s.Score
+		}
+	}
+	return 0
+}
+
+func TestSimilaritiesFromRatings(t *testing.T) {
+	// Users who like movie 1 like movie 2 and dislike movie 3.
+	ratings := rate([3]model.RatingValue{5, 5, 1}, [3]model.RatingValue{4, 5, 2}, [3]model.RatingValue{5, 4, 1}, [3]model.RatingValue{2, 1, 5})
+	features := map[model.RecordID]Features{1: {}, 2: {}, 3: {}}
+	opts := Options{Neighbors: 5, MinCommonRaters: 3, Shrinkage: 0, RatingWeight: 1}
+
+	sims := Similarities(ratings, features, opts)
+	if s := score(sims, 1, 2); s < 0.5 || s > 1+1e-9 {
+		t.Errorf("similarity of 1 and 2 = %v, want between 0.5 and 1", s)
+	}
+	if s := score(sims, 1, 2); s != score(sims, 2, 1) {
+		t.Errorf("similarity is not symmetric: %v and %v", s, score(sims, 2, 1))
+	}
+	if s := score(sims, 1, 3); s != 0 {
+		t.Errorf("movies liked by opposite users have similarity %v", s)
+	}
+
+	opts.Shrinkage = 4
+	shrunk := Similarities(ratings, features, opts)
+	if want := score(sims, 1, 2) * 4 / (4 + 4); math.Abs(score(shrunk, 1, 2)-want) > 1e-9 {
+		t.Errorf("shrunk similarity = %v, want %v", score(shrunk, 1, 2), want)
+	}
+}
+
+func TestSimilaritiesNeedCommonRaters(t *testing.T) {
+	ratings := rate([3]model.RatingValue{5, 5, 1}, [3]model.RatingValue{4, 5, 2})
+	features := map[model.RecordID]Features{1: {}, 2: {}, 3: {}}
+	opts := Options{Neighbors: 5, MinCommonRaters: 3, RatingWeight: 1}
+
+	if sims := Similarities(ratings, features, opts); len(sims) != 0 {
+		t.Errorf("got %v from two raters, want none", sims)
+	}
+}
+
+func TestSimilaritiesIgnoreMoviesWithoutFeatures(t *testing.T) {
+	ratings := rate([3]model.RatingValue{5, 5, 1}, [3]model.RatingValue{4, 5, 2}, [3]model.RatingValue{5, 4, 1})
+	features := map[model.RecordID]Features{1: {}, 3: {}}
+	opts := Options{Neighbors: 5, MinCommonRaters: 1, RatingWeight: 1}
+
+	for _, s := range Similarities(ratings, features, opts) {
+		if s.RecordID == 2 || s.SimilarID == 2 {
+			t.Errorf("got %+v for deleted movie 2", s)
+		}
+	}
+}
+
+func TestSimilaritiesFromFeatures(t *testing.T) {
+	features := map[model.RecordID]Features{
+		1: {Genres: []string{"Drama", "Crime"}, Director: "Michael Mann"},
+		2: {Genres: []string{"crime ", "Thriller"}, Director: "michael mann"},
+		3: {Genres: []string{"Drama"}},
+		4: {Genres: []string{"Comedy"}},
+	}
+	opts := Options{Neighbors: 5, MinCommonRaters: 1, RatingWeight: 0.5}
+
+	sims := Similarities(nil, features, opts)
+	tests := []struct {
+		a, b model.RecordID
+		want float64
+	}{
+		// One of three genres and the director in common.
+		{a: 1, b: 2, want: 0.5 * (genreWeight/3 + directorWeight)},
+		{a: 1, b: 3, want: 0.5 * genreWeight / 2},
+		{a: 2, b: 3},
+		{a: 1, b: 4},
+	}
+	for _, tt := range tests {
+		if got := score(sims, tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("similarity of %d and %d = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+	if sims[0].RecordID != 1 || sims[0].SimilarID != 2 || sims[1].SimilarID != 3 {
+		t.Errorf("similarities of movie 1 are not sorted best first: %v", sims[:2])
+	}
+}
+
+func TestSimilaritiesKeepNeighbors(t *testing.T) {
+	features := map[model.RecordID]Features{}
+	for id := range model.RecordID(10) {
+		features[id] = Features{Genres: []string{"Western"}}
+	}
+	opts := Options{Neighbors: 3, MinCommonRaters: 1, RatingWeight: 0.5}
+
+	counts := map[model.RecordID]int{}
+	for _, s := range Similarities(nil, features, opts) {
+		counts[s.RecordID]++
+	}
+	for id, n := range counts {
+		if n > opts.Neighbors {
+			t.Errorf("movie %d has %d neighbors, want at most %d", id, n, opts.Neighbors)
+		}
+	}
+	if len(counts) != len(features) {
+		t.Errorf("%d of %d movies have neighbors", len(counts), len(features))
+	}
+}
+
+func TestMostRecent(t *testing.T) {
+	at := func(unix int64) *time.Time {
+		t := time.Unix(unix, 0)
+		return &t
+	}
+	ratings := []model.Rating{
+		{RecordID: 1, UpdatedAt: at(100)},
+		{RecordID: 2, UpdatedAt: at(300)},
+		{RecordID: 3},
+		{RecordID: 4, UpdatedAt: at(200)},
+		{RecordID: 5, UpdatedAt: at(300)},
+	}
+	tests := []struct {
+		n    int
+		want []model.RecordID
+	}{
+		{n: 0, want: []model.RecordID{1, 2, 3, 4, 5}},
+		{n: 10, want: []model.RecordID{1, 2, 3, 4, 5}},
+		{n: 3, want: []model.RecordID{2, 5, 4}},
+		{n: 1, want: []model.RecordID{2}},
+	}
+	for _, tt := range tests {
+		var got []model.RecordID
+		for _, r := range mostRecent(slices.Clone(ratings), tt.n) {
+			got = append(got, r.RecordID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("mostRecent(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
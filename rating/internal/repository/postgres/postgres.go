@@ -5,8 +5,12 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/pagination"
+	"github.com/abhishek622/moviedock/rating/internal/repository"
 	"github.com/abhishek622/moviedock/rating/pkg/model"
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
@@ -68,14 +72,111 @@ func (r *Repository) Get(ctx context.Context, recordID model.RecordID, recordTyp
 			Value:  model.RatingValue(value),
 		})
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, repository.ErrNotFound
+	}
 	return res, nil
 }
 
+// GetAggregated returns the average and number of ratings of each of the
+// given records of a type. Records without ratings are left out.
+func (r *Repository) GetAggregated(ctx context.Context, recordType model.RecordType, recordIDs []model.RecordID) ([]model.AggregatedRating, error) {
+	ids := make([]int32, len(recordIDs))
+	for i, id := range recordIDs {
+		ids[i] = int32(id)
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT record_id, avg(value)::float8, count(*) FROM ratings
+         WHERE record_type = $1 AND record_id = ANY($2)
+         GROUP BY record_id ORDER BY record_id`,
+		recordType, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []model.AggregatedRating{}
+	for rows.Next() {
+		var a model.AggregatedRating
+		if err := rows.Scan(&a.RecordID, &a.Rating, &a.Count); err != nil {
+			return nil, err
+		}
+		res = append(res, a)
+	}
+	return res, rows.Err()
+}
+
+// historySorts maps the orders of rating histories to their sort
+// expressions, which end with the columns identifying a rating of a user.
+var historySorts = map[string][]string{
+	"updated_at": {"updated_at", "record_type", "record_id"},
+}
+
+// ListUserRatings returns the page of a user's ratings matching filter it
+// asks for.
+func (r *Repository) ListUserRatings(ctx context.Context, filter model.RatingFilter) ([]model.Rating, pagination.Info, error) {
+	exprs, ok := historySorts[filter.Page.Sort]
+	if !ok {
+		return nil, pagination.Info{}, pagination.ErrInvalidSort
+	}
+
+	where := []string{"user_id = $1"}
+	args := []any{filter.UserID}
+	if filter.RecordType != "" {
+		args = append(args, filter.RecordType)
+		where = append(where, fmt.Sprintf("record_type = $%d", len(args)))
+	}
+
+	cond, orderBy := filter.Page.SQL(exprs, len(args)+1)
+	if cond != "" {
+		values, err := filter.Page.Values()
+		if err != nil {
+			return nil, pagination.Info{}, err
+		}
+		if len(values) != len(exprs) {
+			return nil, pagination.Info{}, pagination.ErrInvalidCursor
+		}
+		args = append(args, values...)
+		where = append(where, cond)
+	}
+	args = append(args, filter.Page.Limit+1)
+
+	rows, err := r.db.QueryContext(ctx,
+		fmt.Sprintf(`SELECT record_id, record_type, value, updated_at FROM ratings WHERE %s ORDER BY %s LIMIT $%d`,
+			strings.Join(where, " AND "), orderBy, len(args)),
+		args...)
+	if err != nil {
+		return nil, pagination.Info{}, apperr.FromPostgres(err)
+	}
+	defer rows.Close()
+
+	res := []model.Rating{}
+	for rows.Next() {
+		var updatedAt time.Time
+		rating := model.Rating{UserID: filter.UserID, UpdatedAt: &updatedAt}
+		if err := rows.Scan(&rating.RecordID, &rating.RecordType, &rating.Value, &updatedAt); err != nil {
+			return nil, pagination.Info{}, err
+		}
+		res = append(res, rating)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, pagination.Info{}, apperr.FromPostgres(err)
+	}
+
+	return pagination.Page(filter.Page, res, func(r model.Rating) []any {
+		return []any{r.UpdatedAt, r.RecordType, r.RecordID}
+	})
+}
+
 // Put adds a rating for a given record.
 func (r *Repository) Put(ctx context.Context, recordID model.RecordID, recordType model.RecordType, rating *model.Rating) error {
 	_, err := r.db.ExecContext(ctx, `INSERT INTO ratings (record_id, record_type, user_id, value) VALUES ($1, $2, $3, $4)
-	ON CONFLICT (record_id, user_id) DO UPDATE
-	SET value = EXCLUDED.value, record_type = EXCLUDED.record_type`,
+	ON CONFLICT (record_id, record_type, user_id) DO UPDATE
+	SET value = EXCLUDED.value`,
 		recordID, recordType, rating.UserID, rating.Value)
 	return err
 }
@@ -85,3 +186,18 @@ func (r *Repository) Delete(ctx context.Context, userID model.UserID) error {
 	_, err := r.db.ExecContext(ctx, "DELETE FROM ratings WHERE user_id = $1", userID)
 	return err
 }
+
+// DeleteRating removes a single user's rating of a record.
+func (r *Repository) DeleteRating(ctx context.Context, recordID model.RecordID, recordType model.RecordType, userID model.UserID) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM ratings WHERE record_id = $1 AND record_type = $2 AND user_id = $3",
+		recordID, recordType, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/abhishek622/moviedock/rating/pkg/model"
+)
+
+// similarityBatchSize is the number of similarities inserted per statement.
+const similarityBatchSize = 1000
+
+// ListRatings returns all ratings of records of a type, with the time they
+// were last updated.
+func (r *Repository) ListRatings(ctx context.Context, recordType model.RecordType) ([]model.Rating, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT record_id, user_id, value, updated_at FROM ratings WHERE record_type = $1`, recordType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []model.Rating{}
+	for rows.Next() {
+		var updatedAt time.Time
+		rating := model.Rating{RecordType: recordType, UpdatedAt: &updatedAt}
+		if err := rows.Scan(&rating.RecordID, &rating.UserID, &rating.Value, &updatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, rating)
+	}
+	return res, rows.Err()
+}
+
+// ReplaceSimilarities replaces all movie similarities in one transaction,
+// so that readers see either the previous or the new ones.
+func (r *Repository) ReplaceSimilarities(ctx context.Context, sims []model.Similarity) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM movie_similarities`); err != nil {
+		return err
+	}
+	for start := 0; start < len(sims); start += similarityBatchSize {
+		batch := sims[start:min(start+similarityBatchSize, len(sims))]
+		recordIDs := make([]int32, len(batch))
+		similarIDs := make([]int32, len(batch))
+		scores := make([]float64, len(batch))
+		for i, s := range batch {
+			recordIDs[i], similarIDs[i], scores[i] = int32(s.RecordID), int32(s.SimilarID), s.Score
+		}
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO movie_similarities (record_id, similar_id, score)
+             SELECT * FROM unnest($1::int[], $2::int[], $3::float8[])`,
+			recordIDs, similarIDs, scores)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListSimilar returns the movies most similar to a movie, best first.
+func (r *Repository) ListSimilar(ctx context.Context, recordID model.RecordID, limit int) ([]model.Similarity, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT similar_id, score FROM movie_similarities
+         WHERE record_id = $1 ORDER BY score DESC, similar_id LIMIT $2`,
+		recordID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []model.Similarity{}
+	for rows.Next() {
+		s := model.Similarity{RecordID: recordID}
+		if err := rows.Scan(&s.SimilarID, &s.Score); err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, rows.Err()
+}
+
+// Recommend returns the movies a user has not rated that are similar to
+// the movies the user rated, highest predicted rating first. A prediction
+// is the mean rating of the user moved by the deviations from it of the
+// user's ratings of similar movies, weighted by their similarity.
+func (r *Repository) Recommend(ctx context.Context, userID model.UserID, limit int) ([]model.Recommendation, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`WITH rated AS (
+             SELECT record_id, value, avg(value) OVER () AS mean
+             FROM ratings WHERE user_id = $1 AND record_type = $2
+         )
+         SELECT s.similar_id,
+                (min(r.mean) + sum(s.score * (r.value - r.mean)) / sum(s.score))::float8 AS predicted,
+                sum(s.score) AS score
+         FROM rated r JOIN movie_similarities s ON s.record_id = r.record_id
+         WHERE NOT EXISTS (SELECT 1 FROM rated WHERE rated.record_id = s.similar_id)
+         GROUP BY s.similar_id
+         ORDER BY predicted DESC, score DESC, s.similar_id
+         LIMIT $3`,
+		userID, model.RecordTypeMovie, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []model.Recommendation{}
+	for rows.Next() {
+		var rec model.Recommendation
+		if err := rows.Scan(&rec.RecordID, &rec.PredictedRating, &rec.Score); err != nil {
+			return nil, err
+		}
+		res = append(res, rec)
+	}
+	return res, rows.Err()
+}
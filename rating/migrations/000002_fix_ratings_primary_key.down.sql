@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_ratings_user_id;
+
+ALTER TABLE ratings DROP CONSTRAINT IF EXISTS ratings_pkey;
+ALTER TABLE ratings ADD PRIMARY KEY (record_id);
+
+CREATE SEQUENCE IF NOT EXISTS ratings_record_id_seq OWNED BY ratings.record_id;
+ALTER TABLE ratings ALTER COLUMN record_id SET DEFAULT nextval('ratings_record_id_seq');
@@ -0,0 +1,9 @@
+-- record_id references a record in another service, it is not generated here
+ALTER TABLE ratings ALTER COLUMN record_id DROP DEFAULT;
+DROP SEQUENCE IF EXISTS ratings_record_id_seq;
+
+-- one rating per user and record
+ALTER TABLE ratings DROP CONSTRAINT IF EXISTS ratings_pkey;
+ALTER TABLE ratings ADD PRIMARY KEY (record_id, record_type, user_id);
+
+CREATE INDEX IF NOT EXISTS idx_ratings_user_id ON ratings (user_id);
@@ -0,0 +1 @@
+ALTER TABLE ratings DROP CONSTRAINT IF EXISTS ratings_record_type_check;
@@ -0,0 +1,2 @@
+ALTER TABLE ratings ADD CONSTRAINT ratings_record_type_check
+  CHECK (record_type IN ('movie', 'series', 'season', 'episode'));
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_ratings_user_history;
+ALTER TABLE ratings ALTER COLUMN updated_at DROP NOT NULL;
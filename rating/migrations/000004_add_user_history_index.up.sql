@@ -0,0 +1,5 @@
+-- rating history is paged by updated_at, which must therefore be set
+UPDATE ratings SET updated_at = COALESCE(created_at, now()) WHERE updated_at IS NULL;
+ALTER TABLE ratings ALTER COLUMN updated_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_ratings_user_history ON ratings (user_id, updated_at, record_type, record_id);
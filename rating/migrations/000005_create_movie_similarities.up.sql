@@ -0,0 +1,10 @@
+-- similar movies, computed offline by the recommender job
+CREATE TABLE IF NOT EXISTS movie_similarities (
+    record_id INT NOT NULL,
+    similar_id INT NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (record_id, similar_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_movie_similarities_score ON movie_similarities (record_id, score DESC, similar_id);
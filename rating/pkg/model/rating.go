@@ -1,25 +1,65 @@
 package model
 
+import (
+	"time"
+
+	"github.com/abhishek622/moviedock/pkg/pagination"
+)
+
 type RecordID int32
 type RecordType string
 
 const (
-	RecordTypeMovie = RecordType("movie")
+	RecordTypeMovie   = RecordType("movie")
+	RecordTypeSeries  = RecordType("series")
+	RecordTypeSeason  = RecordType("season")
+	RecordTypeEpisode = RecordType("episode")
 )
 
+// Valid reports whether t is a known record type.
+func (t RecordType) Valid() bool {
+	switch t {
+	case RecordTypeMovie, RecordTypeSeries, RecordTypeSeason, RecordTypeEpisode:
+		return true
+	}
+	return false
+}
+
 type UserID string
 type RatingValue int
 
+// Rating is a user's rating of a record. UpdatedAt is filled in when
+// listing the ratings of a user and ignored when writing them.
 type Rating struct {
 	RecordID   RecordID    `json:"record_id"`
 	RecordType RecordType  `json:"record_type"`
 	UserID     UserID      `json:"user_id"`
 	Value      RatingValue `json:"value"`
+	UpdatedAt  *time.Time  `json:"updated_at,omitempty"`
+}
+
+// RatingFilter selects the ratings returned by a listing of a user's
+// ratings. An empty RecordType does not filter.
+type RatingFilter struct {
+	UserID     UserID
+	RecordType RecordType
+	Page       pagination.Request
+}
+
+// AggregatedRating is the average of the ratings of a record.
+type AggregatedRating struct {
+	RecordID RecordID `json:"record_id"`
+	Rating   float64  `json:"rating"`
+	Count    int      `json:"count"`
 }
 
+// RatingEvent is a rating written or removed at a data provider. Records
+// may be named by their ID at the provider in ExternalID instead of
+// RecordID, which is only supported for movies.
 type RatingEvent struct {
 	Rating
 	ProviderID string          `json:"provider_id"`
+	ExternalID string          `json:"external_id,omitempty"`
 	EventType  RatingEventType `json:"event_type"`
 }
 
@@ -29,3 +69,34 @@ const (
 	RatingEventTypePut    = RatingEventType("put")
 	RatingEventTypeDelete = RatingEventType("delete")
 )
+
+// IngestError reports why the rating event at Index of a batch failed.
+type IngestError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// IngestReport is the outcome of ingesting a batch of rating events.
+type IngestReport struct {
+	Processed int           `json:"processed"`
+	Failed    int           `json:"failed"`
+	Errors    []IngestError `json:"errors"`
+}
+
+// Similarity scores how alike the movie SimilarID is to the movie RecordID,
+// from 0 to 1, judged by the ratings users gave both and the genres and
+// director they share.
+type Similarity struct {
+	RecordID  RecordID `json:"record_id"`
+	SimilarID RecordID `json:"similar_id"`
+	Score     float64  `json:"score"`
+}
+
+// Recommendation is a movie recommended to a user. PredictedRating is the
+// rating the user is expected to give it and Score how strongly the movies
+// the user rated point to it.
+type Recommendation struct {
+	RecordID        RecordID `json:"record_id"`
+	PredictedRating float64  `json:"predicted_rating"`
+	Score           float64  `json:"score"`
+}
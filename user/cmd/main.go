@@ -8,13 +8,21 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/abhishek622/moviedock/pkg/auth"
+	"github.com/abhishek622/moviedock/pkg/authz"
 	"github.com/abhishek622/moviedock/pkg/discovery"
 	"github.com/abhishek622/moviedock/pkg/discovery/consul"
+	"github.com/abhishek622/moviedock/pkg/interceptor"
 	"github.com/abhishek622/moviedock/user/internal/controller/user"
+	ratinggateway "github.com/abhishek622/moviedock/user/internal/gateway/rating/http"
 	httphandler "github.com/abhishek622/moviedock/user/internal/handler/http"
+	"github.com/abhishek622/moviedock/user/internal/notifier/local"
+	"github.com/abhishek622/moviedock/user/internal/oidc"
+	"github.com/abhishek622/moviedock/user/internal/password"
 	"github.com/abhishek622/moviedock/user/internal/repository/postgres"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -25,8 +33,11 @@ const serviceName = "user"
 
 func main() {
 	var (
-		port      = flag.Int("port", 8083, "API handler port")
-		consulURL = flag.String("consul-url", "localhost:8500", "Consul URL")
+		port            = flag.Int("port", 8083, "API handler port")
+		consulURL       = flag.String("consul-url", "localhost:8500", "Consul URL")
+		keyRotation     = flag.Duration("key-rotation", 30*24*time.Hour, "Signing key rotation interval")
+		keyRetain       = flag.Duration("key-retain", 7*24*time.Hour, "How long retired signing keys stay valid for verification")
+		requireVerified = flag.Bool("require-verified-email", false, "Reject logins of users who have not verified their email")
 	)
 	flag.Parse()
 	log.Printf("Starting the movie user service on port %d", port)
@@ -42,13 +53,17 @@ func main() {
 		log.Fatalf("Failed to create repository: %v", err)
 	}
 
-	// Create controller
-	ctrl := user.New(repo)
+	// Load token signing keys
+	keyring, err := auth.LoadKeyring(os.Getenv("JWT_KEYS_DIR"))
+	if err != nil {
+		log.Fatalf("Failed to load signing keys: %v", err)
+	}
 
-	// Create HTTP handler with Gin
-	router := gin.Default()
-	handler := httphandler.New(ctrl)
-	handler.RegisterRoutes(router)
+	verifierConfig, err := auth.VerifierConfigFromEnv(serviceName)
+	if err != nil {
+		log.Fatalf("Failed to configure token verification: %v", err)
+	}
+	verifier := auth.NewVerifier(keyring, verifierConfig)
 
 	// Service discovery setup
 	registry, err := consul.NewRegistry(*consulURL)
@@ -56,9 +71,72 @@ func main() {
 		log.Fatalf("Failed to create Consul client: %v", err)
 	}
 
+	// Rating service calls authenticate as the user service with a narrowly scoped token
+	issuer := auth.NewIssuer(keyring, auth.IssuerConfigFromEnv())
+	ratingClient := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &interceptor.Transport{Source: interceptor.ServiceToken(issuer, serviceName, "rating", authz.ScopeRatingsDelete)},
+	}
+	ratingGateway := ratinggateway.New(registry, ratingClient)
+
+	// Emails are recorded locally until a mail provider is configured
+	notifier := local.New(os.Getenv("NOTIFIER_FILE"))
+
+	appURL := os.Getenv("APP_URL")
+	if appURL == "" {
+		appURL = "http://localhost:3000"
+	}
+
+	// Password hashing
+	passwordConfig, err := password.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure password hashing: %v", err)
+	}
+	hasher, err := password.New(passwordConfig)
+	if err != nil {
+		log.Fatalf("Failed to configure password hashing: %v", err)
+	}
+
+	// Social login providers
+	oidcConfigs, err := oidc.ConfigsFromEnv(appURL)
+	if err != nil {
+		log.Fatalf("Failed to configure OIDC providers: %v", err)
+	}
+	providers := oidc.NewProviders(oidcConfigs, &http.Client{Timeout: 10 * time.Second})
+
+	// Create controller
+	ctrl, err := user.New(repo, issuer, ratingGateway, notifier, hasher, providers, user.Config{
+		AppURL:               appURL,
+		RequireVerifiedEmail: *requireVerified,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create controller: %v", err)
+	}
+
+	// Create HTTP handler with Gin
+	router := gin.Default()
+	// Logins are throttled per client IP, so X-Forwarded-For is only
+	// believed from the proxies listed in TRUSTED_PROXIES (comma separated
+	// addresses or CIDRs), none by default.
+	var trustedProxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("Failed to configure trusted proxies: %v", err)
+	}
+	router.Use(authz.Authenticate(authz.WithAPIKeys(verifier, ctrl)))
+	handler := httphandler.New(ctrl, keyring)
+	handler.RegisterRoutes(router)
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Rotate signing keys in the background
+	go keyring.StartRotation(ctx, *keyRotation, *keyRetain)
+
 	instanceID := discovery.GenerateInstanceID(serviceName)
 	serviceAddress := fmt.Sprintf("host.docker.internal:%d", *port)
 
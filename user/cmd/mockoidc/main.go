@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/abhishek622/moviedock/user/internal/oidc/mock"
+)
+
+// mockoidc runs a mock OpenID Connect provider that logs in anyone without
+// asking. Point the user service at it with
+//
+//	OIDC_PROVIDERS=mock
+//	OIDC_MOCK_ISSUER=http://localhost:8090
+//	OIDC_MOCK_CLIENT_ID=moviedock
+func main() {
+	var (
+		port             = flag.Int("port", 8090, "API handler port")
+		issuer           = flag.String("issuer", "", "Issuer URL (default http://localhost:<port>)")
+		clientID         = flag.String("client-id", "moviedock", "Accepted client ID")
+		clientSecret     = flag.String("client-secret", "", "Accepted client secret")
+		unverifiedEmails = flag.Bool("unverified-emails", false, "Report emails as unverified")
+	)
+	flag.Parse()
+
+	if *issuer == "" {
+		*issuer = fmt.Sprintf("http://localhost:%d", *port)
+	}
+
+	server, err := mock.New(mock.Config{
+		Issuer:           *issuer,
+		ClientID:         *clientID,
+		ClientSecret:     *clientSecret,
+		UnverifiedEmails: *unverifiedEmails,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create mock provider: %v", err)
+	}
+
+	log.Printf("Starting the mock OIDC provider on port %d with issuer %s", *port, *issuer)
+	if err := http.ListenAndServe(fmt.Sprintf(":%d", *port), server); err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/user/internal/repository"
+	"github.com/abhishek622/moviedock/user/pkg/model"
+)
+
+// ErrSelfModification is returned when an admin tries to demote, deactivate
+// or log out themselves, which could leave no admin able to undo it.
+var ErrSelfModification = apperr.New(apperr.InvalidArgument, "admins cannot change their own role or status")
+
+// ListUsers returns a page of users matching filter.
+func (c *Controller) ListUsers(ctx context.Context, filter model.UserFilter) (*model.UserList, error) {
+	users, info, total, err := c.repo.ListUsers(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &model.UserList{
+		Users: make([]*model.UserProfile, 0, len(users)),
+		Total: total,
+		Info:  info,
+	}
+	for _, u := range users {
+		list.Users = append(list.Users, u.Profile())
+	}
+	return list, nil
+}
+
+// ChangeRole sets the role of a user. Sessions keep their current role until
+// their access token is refreshed.
+func (c *Controller) ChangeRole(ctx context.Context, actorID, userID string, role model.Role) (*model.UserProfile, error) {
+	if actorID == userID {
+		return nil, ErrSelfModification
+	}
+
+	user, err := c.repo.UpdateRole(ctx, userID, role)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	c.audit(ctx, model.AuditEventRoleChanged, actorID, userID, map[string]any{"role": role})
+	return user.Profile(), nil
+}
+
+// SetActive activates or deactivates a user. Deactivated users cannot log in
+// and all of their sessions are ended.
+func (c *Controller) SetActive(ctx context.Context, actorID, userID string, active bool) (*model.UserProfile, error) {
+	if actorID == userID {
+		return nil, ErrSelfModification
+	}
+
+	user, err := c.repo.SetActive(ctx, userID, active)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	event := model.AuditEventUserActivated
+	if !active {
+		event = model.AuditEventUserDeactivated
+		if err := c.repo.RevokeUserTokens(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	c.audit(ctx, event, actorID, userID, nil)
+	return user.Profile(), nil
+}
+
+// ForceLogout ends all sessions of a user. Access tokens already issued stay
+// valid until they expire.
+func (c *Controller) ForceLogout(ctx context.Context, actorID, userID string) error {
+	if actorID == userID {
+		return ErrSelfModification
+	}
+
+	if _, err := c.repo.GetByID(ctx, userID); errors.Is(err, repository.ErrNotFound) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+
+	if err := c.repo.RevokeUserTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	c.audit(ctx, model.AuditEventForcedLogout, actorID, userID, nil)
+	return nil
+}
+
+// audit records an admin action on a user. Failures are logged only, the
+// action itself has already happened.
+func (c *Controller) audit(ctx context.Context, event model.AuditEventType, actorID, userID string, details map[string]any) {
+	if details == nil {
+		details = map[string]any{}
+	}
+	details["actor_id"] = actorID
+
+	err := c.repo.CreateAuditEvent(ctx, &model.AuditEvent{Type: event, UserID: &userID, Details: details})
+	if err != nil {
+		log.Printf("Failed to audit %s of user %s: %v", event, userID, err)
+	}
+}
@@ -0,0 +1,145 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/auth"
+	"github.com/abhishek622/moviedock/pkg/authz"
+	"github.com/abhishek622/moviedock/user/internal/repository"
+	"github.com/abhishek622/moviedock/user/pkg/model"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrInvalidAPIKey is returned when an API key is unknown, expired or revoked.
+	ErrInvalidAPIKey = apperr.New(apperr.Unauthenticated, "invalid or expired API key")
+	// ErrAPIKeyNotFound is returned when revoking a key the user does not have.
+	ErrAPIKeyNotFound = apperr.New(apperr.NotFound, "API key not found")
+	// ErrScopeNotAllowed is returned when requesting a scope the user may not grant.
+	ErrScopeNotAllowed = apperr.New(apperr.PermissionDenied, "requested scope is not allowed")
+)
+
+const (
+	// apiKeyTouchInterval is how often the last use of a key is written.
+	apiKeyTouchInterval = time.Minute
+	// apiKeyPrefixLength is how much of a key is stored in clear to identify it.
+	apiKeyPrefixLength = len(auth.APIKeyPrefix) + 6
+)
+
+// userScopes are the scopes any user may put on an API key, limiting what
+// the key may do on their behalf.
+var userScopes = []string{
+	authz.ScopeProfileRead, authz.ScopeProfileWrite, authz.ScopeRatingsRead, authz.ScopeRatingsWrite,
+}
+
+// grantableScopes lists the scopes each role may put on its API keys.
+var grantableScopes = map[model.Role][]string{
+	model.RoleUser:  userScopes,
+	model.RoleAdmin: append(slices.Clone(userScopes), authz.ScopeAdmin, authz.ScopeRatingsDelete, authz.ScopeRatingsIngest),
+}
+
+// CreateAPIKey mints a personal API key. The key is only returned here.
+func (c *Controller) CreateAPIKey(ctx context.Context, userID string, req *model.CreateAPIKeyRequest) (*model.CreatedAPIKey, error) {
+	user, err := c.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, scope := range req.Scopes {
+		if !slices.Contains(grantableScopes[user.Role], scope) {
+			return nil, ErrScopeNotAllowed
+		}
+	}
+
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	value := auth.APIKeyPrefix + token
+
+	key := model.APIKey{
+		UserID:  userID,
+		Name:    req.Name,
+		Prefix:  value[:apiKeyPrefixLength],
+		KeyHash: hashToken(value),
+		Scopes:  slices.Compact(slices.Sorted(slices.Values(req.Scopes))),
+	}
+	if req.ExpiresInDays != nil {
+		expiresAt := time.Now().AddDate(0, 0, *req.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := c.repo.CreateAPIKey(ctx, &key); err != nil {
+		return nil, err
+	}
+	return &model.CreatedAPIKey{APIKey: key, Key: value}, nil
+}
+
+// ListAPIKeys returns the API keys of a user, including revoked ones.
+func (c *Controller) ListAPIKeys(ctx context.Context, userID string) ([]*model.APIKey, error) {
+	return c.repo.ListAPIKeys(ctx, userID)
+}
+
+// RevokeAPIKey revokes one of a user's API keys.
+func (c *Controller) RevokeAPIKey(ctx context.Context, userID, keyID string) error {
+	err := c.repo.RevokeAPIKey(ctx, userID, keyID)
+	if errors.Is(err, repository.ErrNotFound) || apperr.Is(err, apperr.InvalidArgument) {
+		return ErrAPIKeyNotFound
+	}
+	return err
+}
+
+// ResolveAPIKey returns the claims an API key authenticates with. Keys act
+// as their owner with the regular user role; admins keep their role only on
+// keys granted the admin scope.
+func (c *Controller) ResolveAPIKey(ctx context.Context, value string) (*auth.Claims, error) {
+	key, err := c.repo.GetAPIKeyByHash(ctx, hashToken(value))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidAPIKey
+	} else if err != nil {
+		return nil, err
+	}
+
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	user, err := c.repo.GetByID(ctx, key.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidAPIKey
+	} else if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrAccountDisabled
+	}
+
+	if err := c.repo.TouchAPIKey(ctx, key.KeyID, apiKeyTouchInterval); err != nil {
+		log.Printf("Failed to record use of API key %s: %v", key.KeyID, err)
+	}
+
+	role := model.RoleUser
+	if user.Role == model.RoleAdmin && slices.Contains(key.Scopes, authz.ScopeAdmin) {
+		role = model.RoleAdmin
+	}
+
+	claims := &auth.Claims{
+		UserID:   user.UserID,
+		Username: user.Email,
+		Role:     role,
+		Scopes:   key.Scopes,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: user.UserID,
+			ID:      key.KeyID,
+		},
+	}
+	if key.ExpiresAt != nil {
+		claims.ExpiresAt = jwt.NewNumericDate(*key.ExpiresAt)
+	}
+	return claims, nil
+}
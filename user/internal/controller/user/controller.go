@@ -2,52 +2,452 @@ package user
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
 
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/auth"
+	"github.com/abhishek622/moviedock/pkg/pagination"
+	"github.com/abhishek622/moviedock/user/internal/notifier"
+	"github.com/abhishek622/moviedock/user/internal/oidc"
+	"github.com/abhishek622/moviedock/user/internal/password"
+	"github.com/abhishek622/moviedock/user/internal/repository"
 	"github.com/abhishek622/moviedock/user/pkg/model"
-	"golang.org/x/crypto/bcrypt"
 )
 
-// ErrNotFound is returned when a requested record is not found.
-var ErrNotFound = errors.New("not found")
+var (
+	// ErrNotFound is returned when a requested record is not found.
+	ErrNotFound = apperr.New(apperr.NotFound, "user not found")
+	// ErrEmailExists is returned when registering an email that is already in use.
+	ErrEmailExists = apperr.New(apperr.AlreadyExists, "user with this email already exists")
+	// ErrInvalidCredentials is returned when an email and password do not match.
+	ErrInvalidCredentials = apperr.New(apperr.Unauthenticated, "invalid email or password")
+	// ErrInvalidRefreshToken is returned when a refresh token is unknown, expired or revoked.
+	ErrInvalidRefreshToken = apperr.New(apperr.Unauthenticated, "invalid or expired refresh token")
+	// ErrInvalidActionToken is returned when a password reset or email verification token is unknown, expired or used.
+	ErrInvalidActionToken = apperr.New(apperr.InvalidArgument, "invalid or expired token")
+	// ErrEmailNotVerified is returned on login when verification is required and the user has not verified their email.
+	ErrEmailNotVerified = apperr.New(apperr.PermissionDenied, "email address has not been verified")
+	// ErrAccountDisabled is returned when a deactivated user tries to log in.
+	ErrAccountDisabled = apperr.New(apperr.PermissionDenied, "account is disabled")
+	// ErrTooManyAttempts is returned when logins are locked out after repeated failures.
+	ErrTooManyAttempts = apperr.New(apperr.TooManyRequests, "too many failed login attempts, try again later")
+	// ErrAlreadyVerified is returned when requesting verification of an email that is already verified.
+	ErrAlreadyVerified = apperr.New(apperr.Conflict, "email address is already verified")
+)
+
+const (
+	// refreshTokenTTL is the lifetime of a single refresh token. Every refresh
+	// rotates the token, so an active session never expires.
+	refreshTokenTTL = 30 * 24 * time.Hour
+	// passwordResetTTL is how long a password reset link stays valid.
+	passwordResetTTL = time.Hour
+	// emailVerificationTTL is how long an email verification link stays valid.
+	emailVerificationTTL = 48 * time.Hour
+)
+
+type userRepository interface {
+	RegisterUser(ctx context.Context, user *model.User) (*model.User, error)
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	GetByID(ctx context.Context, id string) (*model.User, error)
+	UpdateProfile(ctx context.Context, id string, update *model.ProfileUpdate) (*model.User, error)
+	Delete(ctx context.Context, id string) error
+	CreateToken(ctx context.Context, token *model.UserToken) error
+	GetTokenByHash(ctx context.Context, hash string) (*model.UserToken, error)
+	RotateToken(ctx context.Context, oldTokenID string, next *model.UserToken) error
+	RevokeTokenFamily(ctx context.Context, familyID string) error
+	CreateActionToken(ctx context.Context, token *model.ActionToken) error
+	VerifyEmail(ctx context.Context, tokenHash string) (string, error)
+	ResetPassword(ctx context.Context, tokenHash, encryptedPassword string) (string, error)
+	UpdatePassword(ctx context.Context, userID, encryptedPassword string) error
+	UpdatePreferences(ctx context.Context, id string, preferences *model.Preferences) (*model.User, error)
+	UpdatePublicMetadata(ctx context.Context, id string, metadata model.Metadata) (*model.User, error)
+	UpdatePrivateMetadata(ctx context.Context, id string, metadata model.Metadata) (*model.User, error)
+	RecordLogin(ctx context.Context, userID string) error
+	LoginLockedUntil(ctx context.Context, keys ...string) (time.Time, error)
+	RecordLoginFailure(ctx context.Context, key string, window time.Duration) (int, error)
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	ClearLoginFailures(ctx context.Context, key string) error
+	CreateAuditEvent(ctx context.Context, event *model.AuditEvent) error
+	ListUsers(ctx context.Context, filter model.UserFilter) ([]*model.User, pagination.Info, int, error)
+	UpdateRole(ctx context.Context, id string, role model.Role) (*model.User, error)
+	SetActive(ctx context.Context, id string, active bool) (*model.User, error)
+	RevokeUserTokens(ctx context.Context, userID string) error
+	GetActionToken(ctx context.Context, tokenHash string, purpose model.TokenPurpose) (*model.ActionToken, error)
+	ConsumeActionToken(ctx context.Context, tokenHash string, purpose model.TokenPurpose) (string, error)
+	SetTOTPSecret(ctx context.Context, userID, secret string) error
+	UseTOTPStep(ctx context.Context, userID string, step int64) error
+	EnableTOTP(ctx context.Context, userID string, recoveryCodeHashes []string) error
+	DisableTOTP(ctx context.Context, userID string) error
+	ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userID, codeHash string) error
+	CreateAPIKey(ctx context.Context, key *model.APIKey) error
+	ListAPIKeys(ctx context.Context, userID string) ([]*model.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (*model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID string) error
+	TouchAPIKey(ctx context.Context, keyID string, interval time.Duration) error
+	CreateOIDCLoginState(ctx context.Context, state *model.OIDCLoginState) error
+	ConsumeOIDCLoginState(ctx context.Context, stateHash, provider string) (*model.OIDCLoginState, error)
+	GetByIdentity(ctx context.Context, provider, subject string) (*model.User, error)
+	RegisterUserWithIdentity(ctx context.Context, user *model.User, identity *model.UserIdentity, verified bool) (*model.User, error)
+	LinkIdentity(ctx context.Context, identity *model.UserIdentity) error
+	ListIdentities(ctx context.Context, userID string) ([]*model.UserIdentity, error)
+	DeleteIdentity(ctx context.Context, userID, identityID string) error
+}
 
-func HashPassword(password string) (string, error) {
-	HashPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+type ratingGateway interface {
+	DeleteUserRatings(ctx context.Context, userID string) error
+}
+
+// Config holds the settings of a user service controller.
+type Config struct {
+	// AppURL is the base URL of the frontend, used to build the links sent to users.
+	AppURL string
+	// RequireVerifiedEmail rejects logins of users who have not verified their email.
+	RequireVerifiedEmail bool
+}
+
+type Controller struct {
+	repo          userRepository
+	issuer        *auth.Issuer
+	ratingGateway ratingGateway
+	notifier      notifier.Notifier
+	hasher        password.Hasher
+	providers     oidc.Providers
+	cfg           Config
+
+	// dummyHash is compared against when the email of a login is unknown,
+	// so that such logins take as long as real ones.
+	dummyHash string
+}
+
+func New(repo userRepository, issuer *auth.Issuer, ratingGateway ratingGateway, notifier notifier.Notifier, hasher password.Hasher, providers oidc.Providers, cfg Config) (*Controller, error) {
+	token, err := generateOpaqueToken()
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	dummyHash, err := hasher.Hash(token)
+	if err != nil {
+		return nil, fmt.Errorf("generating dummy password hash: %w", err)
 	}
 
-	return string(HashPassword), nil
+	return &Controller{
+		repo:          repo,
+		issuer:        issuer,
+		ratingGateway: ratingGateway,
+		notifier:      notifier,
+		hasher:        hasher,
+		providers:     providers,
+		cfg:           cfg,
+		dummyHash:     dummyHash,
+	}, nil
+}
 
+// RegisterUser creates a user with the given password and sends them an
+// email verification link.
+func (c *Controller) RegisterUser(ctx context.Context, user *model.User, plainPassword string) (*model.User, error) {
+	encryptedPassword, err := c.hasher.Hash(plainPassword)
+	if err != nil {
+		return nil, err
+	}
+	user.EncryptedPassword = encryptedPassword
+
+	res, err := c.repo.RegisterUser(ctx, user)
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		return nil, ErrEmailExists
+	} else if err != nil {
+		return nil, err
+	}
+
+	// The account is usable without the email, so a failed delivery only
+	// means the user has to request another link.
+	if err := c.sendEmailVerification(ctx, res); err != nil {
+		log.Printf("Failed to send verification email to user %s: %v", res.UserID, err)
+	}
+	return res, nil
 }
 
-type userRepository interface {
-	RegisterUser(ctx context.Context, user *model.User) (*model.User, error)
-	LoginUser(ctx context.Context, user *model.User) (*model.User, error)
-	// LogoutUser(ctx context.Context, user *model.User) (*model.User, error)
-	// RefreshToken(ctx context.Context, user *model.User) (*model.User, error)
+// IssueTokens starts a new session for an authenticated user.
+func (c *Controller) IssueTokens(ctx context.Context, user *model.User) (*model.TokenPair, error) {
+	claims := auth.Claims{UserID: user.UserID, Username: user.Email, Role: user.Role}
+	return c.issueTokens(ctx, claims, "", "")
 }
 
-type Controller struct {
-	repo userRepository
+// RefreshToken exchanges a refresh token for a new token pair. The presented
+// token is revoked; presenting it again revokes the whole session.
+func (c *Controller) RefreshToken(ctx context.Context, refreshToken string) (*model.TokenPair, error) {
+	token, err := c.repo.GetTokenByHash(ctx, hashToken(refreshToken))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidRefreshToken
+	} else if err != nil {
+		return nil, err
+	}
+
+	if token.Revoked {
+		if token.ReplacedBy != nil {
+			log.Printf("Refresh token reuse detected for user %s, revoking family %s", token.UserID, token.FamilyID)
+			if err := c.repo.RevokeTokenFamily(ctx, token.FamilyID); err != nil {
+				return nil, err
+			}
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if time.Now().After(token.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := c.repo.GetByID(ctx, token.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidRefreshToken
+	} else if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrAccountDisabled
+	}
+
+	claims := auth.Claims{UserID: user.UserID, Username: user.Email, Role: user.Role}
+	pair, err := c.issueTokens(ctx, claims, token.FamilyID, token.TokenID)
+	if errors.Is(err, repository.ErrTokenRevoked) {
+		// Lost a race against another refresh with the same token.
+		return nil, ErrInvalidRefreshToken
+	}
+	return pair, err
+}
+
+// LogoutUser revokes the session the refresh token belongs to. Unknown
+// tokens are ignored so that logout is idempotent.
+func (c *Controller) LogoutUser(ctx context.Context, refreshToken string) error {
+	token, err := c.repo.GetTokenByHash(ctx, hashToken(refreshToken))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return c.repo.RevokeTokenFamily(ctx, token.FamilyID)
+}
+
+// LogoutSession revokes the session an access token was issued for.
+func (c *Controller) LogoutSession(ctx context.Context, sessionID string) error {
+	if sessionID == "" {
+		return nil
+	}
+	return c.repo.RevokeTokenFamily(ctx, sessionID)
+}
+
+// GetProfile returns the profile of a user.
+func (c *Controller) GetProfile(ctx context.Context, userID string) (*model.UserProfile, error) {
+	user, err := c.repo.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return user.Profile(), nil
+}
+
+// UpdateProfile changes the profile fields set in update.
+func (c *Controller) UpdateProfile(ctx context.Context, userID string, update *model.ProfileUpdate) (*model.UserProfile, error) {
+	user, err := c.repo.UpdateProfile(ctx, userID, update)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return user.Profile(), nil
+}
+
+// DeleteAccount removes a user and everything they own. Ratings live in the
+// rating service and are deleted first, so a failure leaves the account in
+// place and the deletion can be retried.
+func (c *Controller) DeleteAccount(ctx context.Context, userID string) error {
+	if _, err := c.repo.GetByID(ctx, userID); errors.Is(err, repository.ErrNotFound) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+
+	if err := c.ratingGateway.DeleteUserRatings(ctx, userID); err != nil {
+		log.Printf("Failed to delete ratings of user %s: %v", userID, err)
+		return err
+	}
+
+	err := c.repo.Delete(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
 }
 
-func New(repo userRepository) *Controller {
-	return &Controller{repo}
+// RequestPasswordReset sends a password reset link to the user with the
+// given email. Unknown emails are ignored so that callers cannot probe
+// which emails are registered.
+func (c *Controller) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := c.repo.GetByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	token, err := c.createActionToken(ctx, user.UserID, model.TokenPurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	return c.notifier.Notify(ctx, notifier.Message{
+		To:      user.Email,
+		Subject: "Reset your moviedock password",
+		Body: "Someone requested a password reset for your moviedock account. " +
+			"If it was you, open the link below within the next hour:\n\n" +
+			c.link("/reset-password", token) +
+			"\n\nIf you did not request a reset you can ignore this email.",
+	})
 }
 
-func (c *Controller) RegisterUser(ctx context.Context, user *model.User) (*model.User, error) {
-	return c.repo.RegisterUser(ctx, user)
+// ResetPassword sets a new password using a token from RequestPasswordReset.
+// All sessions of the user are logged out.
+func (c *Controller) ResetPassword(ctx context.Context, token, plainPassword string) error {
+	encryptedPassword, err := c.hasher.Hash(plainPassword)
+	if err != nil {
+		return err
+	}
+
+	userID, err := c.repo.ResetPassword(ctx, hashToken(token), encryptedPassword)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidActionToken
+	} else if err != nil {
+		return err
+	}
+
+	log.Printf("Password reset for user %s", userID)
+	return nil
 }
 
-func (c *Controller) LoginUser(ctx context.Context, user *model.User) (*model.User, error) {
-	return c.repo.LoginUser(ctx, user)
+// SendEmailVerification sends a new email verification link to a user.
+func (c *Controller) SendEmailVerification(ctx context.Context, userID string) error {
+	user, err := c.repo.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+
+	if user.IsVerified {
+		return ErrAlreadyVerified
+	}
+	return c.sendEmailVerification(ctx, user)
 }
 
-// func (c *Controller) LogoutUser(ctx context.Context, user *model.User) (*model.User, error) {
-// 	return c.repo.LogoutUser(ctx, user)
-// }
+// VerifyEmail marks the email of a user as verified using a token sent by
+// SendEmailVerification.
+func (c *Controller) VerifyEmail(ctx context.Context, token string) error {
+	_, err := c.repo.VerifyEmail(ctx, hashToken(token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidActionToken
+	}
+	return err
+}
+
+func (c *Controller) sendEmailVerification(ctx context.Context, user *model.User) error {
+	token, err := c.createActionToken(ctx, user.UserID, model.TokenPurposeEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return c.notifier.Notify(ctx, notifier.Message{
+		To:      user.Email,
+		Subject: "Verify your moviedock email address",
+		Body: "Welcome to moviedock! Please confirm your email address by opening the link below:\n\n" +
+			c.link("/verify-email", token),
+	})
+}
+
+// createActionToken stores a new single-use token for the user and returns
+// its value, which is only ever sent to the user.
+func (c *Controller) createActionToken(ctx context.Context, userID string, purpose model.TokenPurpose, ttl time.Duration) (string, error) {
+	value, err := generateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
 
-// func (c *Controller) RefreshToken(ctx context.Context, user *model.User) (*model.User, error) {
-// 	return c.repo.RefreshToken(ctx, user)
-// }
+	token := &model.ActionToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(value),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := c.repo.CreateActionToken(ctx, token); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// link returns a frontend URL carrying an action token.
+func (c *Controller) link(path, token string) string {
+	return c.cfg.AppURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// issueTokens mints a refresh token in the given family and an access token
+// carrying the family as its session ID. When previousTokenID is set the
+// previous refresh token is rotated out.
+func (c *Controller) issueTokens(ctx context.Context, claims auth.Claims, familyID, previousTokenID string) (*model.TokenPair, error) {
+	refreshToken, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	token := &model.UserToken{
+		UserID:         claims.UserID,
+		FamilyID:       familyID,
+		EncryptedToken: hashToken(refreshToken),
+		ExpiresAt:      now.Add(refreshTokenTTL),
+	}
+
+	if previousTokenID == "" {
+		err = c.repo.CreateToken(ctx, token)
+	} else {
+		err = c.repo.RotateToken(ctx, previousTokenID, token)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	claims.SessionID = token.FamilyID
+	accessToken, err := c.issuer.GenerateToken(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		AccessExpiresAt:  now.Add(auth.AccessTokenTTL),
+		RefreshExpiresAt: token.ExpiresAt,
+	}, nil
+}
+
+// generateOpaqueToken returns 32 random bytes encoded for use in URLs and headers.
+func generateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the value stored for an opaque token. Tokens carry at
+// least 128 bits of entropy, so an unsalted SHA-256 cannot be brute-forced
+// and is sufficient.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
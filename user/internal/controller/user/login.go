@@ -0,0 +1,162 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/abhishek622/moviedock/user/internal/repository"
+	"github.com/abhishek622/moviedock/user/pkg/model"
+)
+
+// Failed logins are counted per email and per client IP. Once a key exceeds
+// its allowance every further failure locks it out for twice as long as the
+// previous one, up to maxLockout. Counters are forgotten after attemptWindow
+// without failures.
+const (
+	accountAttempts = 5
+	ipAttempts      = 20
+	baseLockout     = 30 * time.Second
+	maxLockout      = time.Hour
+	attemptWindow   = time.Hour
+)
+
+// LoginUser checks a user's credentials. It returns ErrInvalidCredentials
+// if there is no user with the email or the password does not match,
+// ErrAccountDisabled for deactivated users and ErrTooManyAttempts while the
+// email or the client IP is locked out.
+//
+// Unknown emails cost the same hash comparison and are throttled like
+// existing ones, so responses do not reveal which emails are registered.
+//
+// Users with two-factor authentication enabled finish logging in with
+// CreateLoginChallenge and CompleteLogin.
+func (c *Controller) LoginUser(ctx context.Context, email, password, ip string) (*model.User, error) {
+	accountKey := "email:" + strings.ToLower(email)
+	ipKey := "ip:" + ip
+
+	lockedUntil, err := c.repo.LoginLockedUntil(ctx, accountKey, ipKey)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().Before(lockedUntil) {
+		return nil, ErrTooManyAttempts
+	}
+
+	user, err := c.repo.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	hash := c.dummyHash
+	if user != nil {
+		hash = user.EncryptedPassword
+	}
+	ok, err := c.hasher.Verify(hash, password)
+	if err != nil {
+		log.Printf("Failed to verify password hash: %v", err)
+	}
+	if !ok || user == nil {
+		var userID *string
+		if user != nil {
+			userID = &user.UserID
+		}
+		c.recordLoginFailure(ctx, accountKey, accountAttempts, userID, ip)
+		c.recordLoginFailure(ctx, ipKey, ipAttempts, nil, ip)
+		return nil, ErrInvalidCredentials
+	}
+
+	// Upgrade hashes made with an older algorithm or cost now that the
+	// plain password is at hand.
+	if c.hasher.NeedsRehash(user.EncryptedPassword) {
+		c.rehashPassword(ctx, user, password)
+	}
+
+	if !user.IsActive {
+		return nil, ErrAccountDisabled
+	}
+	if c.cfg.RequireVerifiedEmail && !user.IsVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	if err := c.repo.ClearLoginFailures(ctx, accountKey); err != nil {
+		log.Printf("Failed to clear login failures of user %s: %v", user.UserID, err)
+	}
+	// Logins with a second factor are recorded once the challenge is passed.
+	if !user.TOTPEnabled {
+		c.recordLogin(ctx, user.UserID)
+	}
+	return user, nil
+}
+
+func (c *Controller) recordLogin(ctx context.Context, userID string) {
+	if err := c.repo.RecordLogin(ctx, userID); err != nil {
+		log.Printf("Failed to record login of user %s: %v", userID, err)
+	}
+}
+
+// recordLoginFailure counts a failed login for key and locks the key out
+// once it has used up its allowance. Errors are logged rather than returned
+// so that the caller still sees ErrInvalidCredentials.
+func (c *Controller) recordLoginFailure(ctx context.Context, key string, allowed int, userID *string, ip string) {
+	failures, err := c.repo.RecordLoginFailure(ctx, key, attemptWindow)
+	if err != nil {
+		log.Printf("Failed to record login failure: %v", err)
+		return
+	}
+
+	lockout := lockoutDuration(failures, allowed)
+	if lockout == 0 {
+		return
+	}
+
+	until := time.Now().Add(lockout)
+	if err := c.repo.LockLogin(ctx, key, until); err != nil {
+		log.Printf("Failed to lock out %s: %v", key, err)
+		return
+	}
+
+	log.Printf("Locked out %s until %s after %d failed logins", key, until.Format(time.RFC3339), failures)
+	err = c.repo.CreateAuditEvent(ctx, &model.AuditEvent{
+		Type:   model.AuditEventLoginLockout,
+		UserID: userID,
+		IP:     ip,
+		Details: map[string]any{
+			"key":          key,
+			"failures":     failures,
+			"locked_until": until,
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to audit lockout of %s: %v", key, err)
+	}
+}
+
+// lockoutDuration returns how long to lock a key out after the given number
+// of consecutive failures, or zero if the key is still within its allowance.
+func lockoutDuration(failures, allowed int) time.Duration {
+	if failures < allowed {
+		return 0
+	}
+	lockout := baseLockout
+	for i := allowed; i < failures && lockout < maxLockout; i++ {
+		lockout *= 2
+	}
+	return min(lockout, maxLockout)
+}
+
+// rehashPassword stores a new hash of the user's password. Failures are only
+// logged; the old hash keeps working.
+func (c *Controller) rehashPassword(ctx context.Context, user *model.User, plainPassword string) {
+	encryptedPassword, err := c.hasher.Hash(plainPassword)
+	if err == nil {
+		err = c.repo.UpdatePassword(ctx, user.UserID, encryptedPassword)
+	}
+	if err != nil {
+		log.Printf("Failed to rehash password of user %s: %v", user.UserID, err)
+		return
+	}
+	user.EncryptedPassword = encryptedPassword
+}
@@ -0,0 +1,335 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/user/internal/repository"
+	"github.com/abhishek622/moviedock/user/internal/totp"
+	"github.com/abhishek622/moviedock/user/pkg/model"
+)
+
+var (
+	// ErrMFAEnabled is returned when enrolling a user who already has two-factor authentication.
+	ErrMFAEnabled = apperr.New(apperr.Conflict, "two-factor authentication is already enabled")
+	// ErrMFANotEnabled is returned when managing two-factor authentication of a user without it.
+	ErrMFANotEnabled = apperr.New(apperr.Conflict, "two-factor authentication is not enabled")
+	// ErrMFANotEnrolled is returned when confirming an enrollment that was never started.
+	ErrMFANotEnrolled = apperr.New(apperr.Conflict, "two-factor enrollment has not been started")
+	// ErrInvalidMFACode is returned when a TOTP or recovery code does not match.
+	ErrInvalidMFACode = apperr.New(apperr.Unauthenticated, "invalid two-factor code")
+	// ErrInvalidChallenge is returned when a login challenge is unknown, expired or used.
+	ErrInvalidChallenge = apperr.New(apperr.Unauthenticated, "invalid or expired login challenge")
+)
+
+const (
+	// totpIssuer is the account issuer shown by authenticator apps.
+	totpIssuer = "moviedock"
+	// mfaChallengeTTL is how long a user has to enter their second factor after the password.
+	mfaChallengeTTL = 5 * time.Minute
+	// recoveryCodeCount is the number of recovery codes handed out at a time.
+	recoveryCodeCount = 10
+	// recoveryCodeBytes is the number of random bytes of a recovery code,
+	// encoded as 28 base32 characters.
+	recoveryCodeBytes = 17
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// EnrollTOTP starts two-factor enrollment by generating a new secret. The
+// secret only takes effect once confirmed with ConfirmTOTP.
+func (c *Controller) EnrollTOTP(ctx context.Context, userID string) (*model.TOTPEnrollment, error) {
+	user, err := c.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrMFAEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.repo.SetTOTPSecret(ctx, userID, secret)
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		return nil, ErrMFAEnabled
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &model.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication once the user proves their
+// authenticator produces valid codes, and returns their recovery codes.
+func (c *Controller) ConfirmTOTP(ctx context.Context, userID, code, ip string) (*model.RecoveryCodes, error) {
+	user, err := c.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrMFAEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	if err := c.checkTOTP(ctx, user, code, ip); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.repo.EnableTOTP(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	c.audit(ctx, model.AuditEventTOTPEnabled, userID, userID, nil)
+	return &model.RecoveryCodes{Codes: codes}, nil
+}
+
+// DisableTOTP turns off two-factor authentication. The user has to present
+// a current TOTP code or one of their recovery codes.
+func (c *Controller) DisableTOTP(ctx context.Context, userID, code, ip string) error {
+	user, err := c.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrMFANotEnabled
+	}
+
+	if err := c.checkSecondFactor(ctx, user, code, ip); err != nil {
+		return err
+	}
+	if err := c.repo.DisableTOTP(ctx, userID); err != nil {
+		return err
+	}
+
+	c.audit(ctx, model.AuditEventTOTPDisabled, userID, userID, nil)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user, who has to
+// present a current TOTP code.
+func (c *Controller) RegenerateRecoveryCodes(ctx context.Context, userID, code, ip string) (*model.RecoveryCodes, error) {
+	user, err := c.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrMFANotEnabled
+	}
+
+	if err := c.checkTOTP(ctx, user, code, ip); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return &model.RecoveryCodes{Codes: codes}, nil
+}
+
+// CreateLoginChallenge returns the challenge a user with two-factor
+// authentication receives after entering their password.
+func (c *Controller) CreateLoginChallenge(ctx context.Context, user *model.User) (*model.LoginChallenge, error) {
+	token, err := c.createActionToken(ctx, user.UserID, model.TokenPurposeMFAChallenge, mfaChallengeTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &model.LoginChallenge{
+		MFARequired:    true,
+		ChallengeToken: token,
+		ExpiresAt:      time.Now().Add(mfaChallengeTTL),
+	}, nil
+}
+
+// CompleteLogin finishes a login started with CreateLoginChallenge using a
+// TOTP code or a recovery code. Failed codes are throttled like passwords.
+func (c *Controller) CompleteLogin(ctx context.Context, challengeToken, code, recoveryCode, ip string) (*model.User, error) {
+	challengeHash := hashToken(challengeToken)
+	challenge, err := c.repo.GetActionToken(ctx, challengeHash, model.TokenPurposeMFAChallenge)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidChallenge
+	} else if err != nil {
+		return nil, err
+	}
+
+	key := mfaKey(challenge.UserID)
+	if err := c.checkMFALockout(ctx, key); err != nil {
+		return nil, err
+	}
+
+	user, err := c.repo.GetByID(ctx, challenge.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidChallenge
+	} else if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrAccountDisabled
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrInvalidChallenge
+	}
+
+	if recoveryCode != "" {
+		err = c.useRecoveryCode(ctx, user, recoveryCode)
+	} else {
+		err = c.validateTOTP(ctx, user, code)
+	}
+	if errors.Is(err, ErrInvalidMFACode) {
+		c.recordLoginFailure(ctx, key, accountAttempts, &user.UserID, ip)
+		return nil, err
+	} else if err != nil {
+		return nil, err
+	}
+
+	if _, err := c.repo.ConsumeActionToken(ctx, challengeHash, model.TokenPurposeMFAChallenge); errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidChallenge
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := c.repo.ClearLoginFailures(ctx, key); err != nil {
+		return nil, err
+	}
+	c.recordLogin(ctx, user.UserID)
+	return user, nil
+}
+
+// mfaKey is the key failed second factors of a user are counted under.
+func mfaKey(userID string) string {
+	return "mfa:" + userID
+}
+
+// checkMFALockout returns ErrTooManyAttempts while the second factor of a
+// user is locked out.
+func (c *Controller) checkMFALockout(ctx context.Context, key string) error {
+	lockedUntil, err := c.repo.LoginLockedUntil(ctx, key)
+	if err != nil {
+		return err
+	}
+	if time.Now().Before(lockedUntil) {
+		return ErrTooManyAttempts
+	}
+	return nil
+}
+
+// checkSecondFactor accepts either a TOTP code or a recovery code. Failed
+// codes are throttled together with those of CompleteLogin.
+func (c *Controller) checkSecondFactor(ctx context.Context, user *model.User, code, ip string) error {
+	return c.throttleMFA(ctx, user, ip, func() error {
+		if len(strings.ReplaceAll(code, " ", "")) == totp.Digits {
+			return c.validateTOTP(ctx, user, code)
+		}
+		return c.useRecoveryCode(ctx, user, code)
+	})
+}
+
+// checkTOTP accepts a TOTP code, throttling failures like checkSecondFactor.
+func (c *Controller) checkTOTP(ctx context.Context, user *model.User, code, ip string) error {
+	return c.throttleMFA(ctx, user, ip, func() error {
+		return c.validateTOTP(ctx, user, code)
+	})
+}
+
+// throttleMFA runs check unless the second factor of the user is locked
+// out, counting a failure if it rejects the code and clearing the failures
+// once it accepts one.
+func (c *Controller) throttleMFA(ctx context.Context, user *model.User, ip string, check func() error) error {
+	key := mfaKey(user.UserID)
+	if err := c.checkMFALockout(ctx, key); err != nil {
+		return err
+	}
+
+	err := check()
+	if errors.Is(err, ErrInvalidMFACode) {
+		c.recordLoginFailure(ctx, key, accountAttempts, &user.UserID, ip)
+		return err
+	} else if err != nil {
+		return err
+	}
+	return c.repo.ClearLoginFailures(ctx, key)
+}
+
+// validateTOTP validates a code against the user's secret. Each code is
+// only accepted once.
+func (c *Controller) validateTOTP(ctx context.Context, user *model.User, code string) error {
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	err := c.repo.UseTOTPStep(ctx, user.UserID, step)
+	if errors.Is(err, repository.ErrTokenRevoked) {
+		return ErrInvalidMFACode
+	}
+	return err
+}
+
+func (c *Controller) useRecoveryCode(ctx context.Context, user *model.User, code string) error {
+	err := c.repo.UseRecoveryCode(ctx, user.UserID, hashToken(normalizeRecoveryCode(code)))
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidMFACode
+	} else if err != nil {
+		return err
+	}
+
+	c.audit(ctx, model.AuditEventRecoveryCode, user.UserID, user.UserID, nil)
+	return nil
+}
+
+func (c *Controller) getUser(ctx context.Context, userID string) (*model.User, error) {
+	user, err := c.repo.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return user, err
+}
+
+// generateRecoveryCodes returns new recovery codes, formatted for the user
+// as four groups of seven characters, together with the hashes to store.
+// Codes carry 136 bits of entropy so that, like other opaque tokens, they
+// can be stored as unsalted hashes.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+		groups := make([]string, 0, len(raw)/7)
+		for j := 0; j < len(raw); j += 7 {
+			groups = append(groups, raw[j:min(j+7, len(raw))])
+		}
+		codes[i] = strings.Join(groups, "-")
+		hashes[i] = hashToken(raw)
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode strips the formatting users may type along with a code.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
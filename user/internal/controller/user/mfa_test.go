@@ -0,0 +1,196 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abhishek622/moviedock/user/internal/repository"
+	"github.com/abhishek622/moviedock/user/internal/totp"
+	"github.com/abhishek622/moviedock/user/pkg/model"
+)
+
+// mfaRepository keeps a user with two-factor authentication and the login
+// failure counters in memory. Other repository methods are not implemented.
+type mfaRepository struct {
+	userRepository
+
+	mu       sync.Mutex
+	user     *model.User
+	steps    map[int64]bool
+	failures map[string]int
+	locked   map[string]time.Time
+}
+
+func newMFARepository(t *testing.T) *mfaRepository {
+	t.Helper()
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &mfaRepository{
+		user:     &model.User{UserID: "1", Email: "film.fan@example.com", IsActive: true, TOTPEnabled: true, TOTPSecret: secret},
+		steps:    map[int64]bool{},
+		failures: map[string]int{},
+		locked:   map[string]time.Time{},
+	}
+}
+
+func (r *mfaRepository) GetByID(_ context.Context, id string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id != r.user.UserID {
+		return nil, repository.ErrNotFound
+	}
+	u := *r.user
+	return &u, nil
+}
+
+func (r *mfaRepository) UseTOTPStep(_ context.Context, _ string, step int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.steps[step] {
+		return repository.ErrTokenRevoked
+	}
+	r.steps[step] = true
+	return nil
+}
+
+func (r *mfaRepository) UseRecoveryCode(context.Context, string, string) error {
+	return repository.ErrNotFound
+}
+
+func (r *mfaRepository) DisableTOTP(context.Context, string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.user.TOTPEnabled = false
+	return nil
+}
+
+func (r *mfaRepository) LoginLockedUntil(_ context.Context, keys ...string) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var until time.Time
+	for _, k := range keys {
+		if r.locked[k].After(until) {
+			until = r.locked[k]
+		}
+	}
+	return until, nil
+}
+
+func (r *mfaRepository) RecordLoginFailure(_ context.Context, key string, _ time.Duration) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures[key]++
+	return r.failures[key], nil
+}
+
+func (r *mfaRepository) LockLogin(_ context.Context, key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.locked[key] = until
+	return nil
+}
+
+func (r *mfaRepository) ClearLoginFailures(_ context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.failures, key)
+	delete(r.locked, key)
+	return nil
+}
+
+func (r *mfaRepository) CreateAuditEvent(context.Context, *model.AuditEvent) error {
+	return nil
+}
+
+// currentCode returns the TOTP code of the user for now.
+func (r *mfaRepository) currentCode(t *testing.T) string {
+	t.Helper()
+	code, err := totp.Code(r.user.TOTPSecret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// wrongCode returns a code that does not match around now.
+func (r *mfaRepository) wrongCode(t *testing.T) string {
+	t.Helper()
+	for _, code := range []string{"000000", "111111", "222222", "333333"} {
+		if _, ok := totp.Validate(r.user.TOTPSecret, code, time.Now()); !ok {
+			return code
+		}
+	}
+	t.Fatal("no wrong code found")
+	return ""
+}
+
+func TestDisableTOTPLocksOutAfterFailedCodes(t *testing.T) {
+	repo := newMFARepository(t)
+	ctrl, err := New(repo, nil, nil, nil, plainHasher{}, nil, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for i := range accountAttempts {
+		if err := ctrl.DisableTOTP(ctx, "1", repo.wrongCode(t), "192.0.2.1"); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("attempt %d: got error %v, want %v", i+1, err, ErrInvalidMFACode)
+		}
+	}
+
+	// Even the right code is refused while locked out, here and at login.
+	if err := ctrl.DisableTOTP(ctx, "1", repo.currentCode(t), "192.0.2.1"); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("right code while locked out: got error %v, want %v", err, ErrTooManyAttempts)
+	}
+	if _, err := ctrl.RegenerateRecoveryCodes(ctx, "1", repo.currentCode(t), "192.0.2.1"); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("regenerating recovery codes while locked out: got error %v, want %v", err, ErrTooManyAttempts)
+	}
+	if !repo.user.TOTPEnabled {
+		t.Error("two-factor authentication was disabled while locked out")
+	}
+}
+
+func TestDisableTOTPClearsFailuresOnSuccess(t *testing.T) {
+	repo := newMFARepository(t)
+	ctrl, err := New(repo, nil, nil, nil, plainHasher{}, nil, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for range accountAttempts - 1 {
+		if err := ctrl.DisableTOTP(ctx, "1", repo.wrongCode(t), "192.0.2.1"); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("got error %v, want %v", err, ErrInvalidMFACode)
+		}
+	}
+	if err := ctrl.DisableTOTP(ctx, "1", repo.currentCode(t), "192.0.2.1"); err != nil {
+		t.Fatalf("DisableTOTP: %v", err)
+	}
+	if n := repo.failures[mfaKey("1")]; n != 0 {
+		t.Errorf("%d failures left after a valid code", n)
+	}
+}
+
+func TestLockoutDuration(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 1, want: 0},
+		{failures: accountAttempts - 1, want: 0},
+		{failures: accountAttempts, want: baseLockout},
+		{failures: accountAttempts + 1, want: 2 * baseLockout},
+		{failures: accountAttempts + 3, want: 8 * baseLockout},
+		{failures: accountAttempts + 100, want: maxLockout},
+	}
+	for _, tt := range tests {
+		if got := lockoutDuration(tt.failures, accountAttempts); got != tt.want {
+			t.Errorf("lockoutDuration(%d, %d) = %v, want %v", tt.failures, accountAttempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/user/internal/oidc"
+	"github.com/abhishek622/moviedock/user/internal/repository"
+	"github.com/abhishek622/moviedock/user/pkg/model"
+)
+
+var (
+	// ErrUnknownProvider is returned for identity providers that are not configured.
+	ErrUnknownProvider = apperr.New(apperr.NotFound, "unknown identity provider")
+	// ErrInvalidLoginState is returned when the state a provider returned with is unknown, expired or used.
+	ErrInvalidLoginState = apperr.New(apperr.InvalidArgument, "invalid or expired login state")
+	// ErrProviderLogin is returned when the provider rejects the code or its ID token fails verification.
+	ErrProviderLogin = apperr.New(apperr.Unauthenticated, "login with the identity provider failed")
+	// ErrProviderEmailMissing is returned when a provider does not share the email of a new user.
+	ErrProviderEmailMissing = apperr.New(apperr.InvalidArgument, "the identity provider did not share an email address")
+	// ErrIdentityConflict is returned when an external identity cannot be linked to an existing account automatically.
+	ErrIdentityConflict = apperr.New(apperr.Conflict, "an account with this email already exists, log in and link the provider from your account")
+	// ErrIdentityLinked is returned when linking an identity that is already linked to a user.
+	ErrIdentityLinked = apperr.New(apperr.AlreadyExists, "this identity is already linked to an account")
+)
+
+// oidcLoginStateTTL is how long a user may take to log in at the provider.
+const oidcLoginStateTTL = 10 * time.Minute
+
+// OIDCProviders returns the names of the configured identity providers.
+func (c *Controller) OIDCProviders() []string {
+	return c.providers.Names()
+}
+
+// StartOIDCLogin returns the URL to send the user to for logging in with a
+// provider. If userID is set, the identity will be linked to that user
+// instead.
+func (c *Controller) StartOIDCLogin(ctx context.Context, providerName string, userID *string) (*model.OIDCAuthorization, error) {
+	provider, ok := c.providers[providerName]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	state, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	codeVerifier, err := oidc.RandomString()
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.repo.CreateOIDCLoginState(ctx, &model.OIDCLoginState{
+		StateHash:    hashToken(state),
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		UserID:       userID,
+		ExpiresAt:    time.Now().Add(oidcLoginStateTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	url, err := provider.AuthCodeURL(ctx, state, nonce, oidc.CodeChallenge(codeVerifier))
+	if err != nil {
+		return nil, err
+	}
+	return &model.OIDCAuthorization{AuthorizationURL: url, State: state}, nil
+}
+
+// CompleteOIDCLogin logs in the user a provider returned with. Users are
+// found by their linked identity. Otherwise a new user is registered, or the
+// identity is linked to the user with the same email if both the provider
+// and the user have verified it; an unverified address could belong to
+// someone else, so such accounts have to be linked with LinkOIDCIdentity.
+//
+// Like LoginUser it does not issue tokens, and users with two-factor
+// authentication finish logging in with CreateLoginChallenge and
+// CompleteLogin.
+func (c *Controller) CompleteOIDCLogin(ctx context.Context, providerName, code, state string) (*model.User, error) {
+	loginState, identity, err := c.exchangeOIDCCode(ctx, providerName, code, state)
+	if err != nil {
+		return nil, err
+	}
+	if loginState.UserID != nil {
+		return nil, ErrInvalidLoginState
+	}
+
+	user, err := c.repo.GetByIdentity(ctx, providerName, identity.Subject)
+	if errors.Is(err, repository.ErrNotFound) {
+		user, err = c.registerOIDCUser(ctx, providerName, identity)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsActive {
+		return nil, ErrAccountDisabled
+	}
+	if c.cfg.RequireVerifiedEmail && !user.IsVerified {
+		return nil, ErrEmailNotVerified
+	}
+	if !user.TOTPEnabled {
+		c.recordLogin(ctx, user.UserID)
+	}
+	return user, nil
+}
+
+// registerOIDCUser links an identity without a user to the user with the
+// same verified email, or registers a new user for it.
+func (c *Controller) registerOIDCUser(ctx context.Context, providerName string, identity *oidc.Identity) (*model.User, error) {
+	if identity.Email == "" {
+		return nil, ErrProviderEmailMissing
+	}
+	userIdentity := &model.UserIdentity{Provider: providerName, Subject: identity.Subject, Email: identity.Email}
+
+	existing, err := c.repo.GetByEmail(ctx, identity.Email)
+	if err == nil {
+		if !identity.EmailVerified || !existing.IsVerified {
+			return nil, ErrIdentityConflict
+		}
+		userIdentity.UserID = existing.UserID
+		if err := c.linkIdentity(ctx, userIdentity); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	// The user logs in through the provider, but gets a password nobody
+	// knows so the account stays usable with a password reset.
+	plainPassword, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	encryptedPassword, err := c.hasher.Hash(plainPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		Email:             identity.Email,
+		EncryptedPassword: encryptedPassword,
+		FullName:          identity.Name,
+		Role:              model.RoleUser,
+		IsActive:          true,
+	}
+	user, err = c.repo.RegisterUserWithIdentity(ctx, user, userIdentity, identity.EmailVerified)
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		// Lost a race with a concurrent registration of the same email or identity.
+		return nil, ErrIdentityConflict
+	} else if err != nil {
+		return nil, err
+	}
+	c.audit(ctx, model.AuditEventIdentityLinked, user.UserID, user.UserID, map[string]any{"provider": providerName})
+
+	if !user.IsVerified {
+		if err := c.sendEmailVerification(ctx, user); err != nil {
+			log.Printf("Failed to send verification email to user %s: %v", user.UserID, err)
+		}
+	}
+	return user, nil
+}
+
+// LinkOIDCIdentity links the identity a provider returned with to the user
+// who started the login with StartOIDCLogin.
+func (c *Controller) LinkOIDCIdentity(ctx context.Context, userID, providerName, code, state string) (*model.UserIdentity, error) {
+	loginState, identity, err := c.exchangeOIDCCode(ctx, providerName, code, state)
+	if err != nil {
+		return nil, err
+	}
+	if loginState.UserID == nil || *loginState.UserID != userID {
+		return nil, ErrInvalidLoginState
+	}
+
+	userIdentity := &model.UserIdentity{
+		UserID:   userID,
+		Provider: providerName,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}
+	if err := c.linkIdentity(ctx, userIdentity); err != nil {
+		return nil, err
+	}
+	return userIdentity, nil
+}
+
+// ListIdentities returns the identities linked to a user.
+func (c *Controller) ListIdentities(ctx context.Context, userID string) ([]*model.UserIdentity, error) {
+	return c.repo.ListIdentities(ctx, userID)
+}
+
+// UnlinkIdentity removes an identity from a user. The user can still log in
+// with their password or another provider.
+func (c *Controller) UnlinkIdentity(ctx context.Context, userID, identityID string) error {
+	err := c.repo.DeleteIdentity(ctx, userID, identityID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	c.audit(ctx, model.AuditEventIdentityRemoved, userID, userID, map[string]any{"identity_id": identityID})
+	return nil
+}
+
+// exchangeOIDCCode redeems a login state and the code the provider returned
+// with for the verified identity of the user.
+func (c *Controller) exchangeOIDCCode(ctx context.Context, providerName, code, state string) (*model.OIDCLoginState, *oidc.Identity, error) {
+	provider, ok := c.providers[providerName]
+	if !ok {
+		return nil, nil, ErrUnknownProvider
+	}
+
+	loginState, err := c.repo.ConsumeOIDCLoginState(ctx, hashToken(state), providerName)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, ErrInvalidLoginState
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	identity, err := provider.Exchange(ctx, code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		log.Printf("Failed to complete login with %s: %v", providerName, err)
+		return nil, nil, ErrProviderLogin
+	}
+	return loginState, identity, nil
+}
+
+func (c *Controller) linkIdentity(ctx context.Context, identity *model.UserIdentity) error {
+	err := c.repo.LinkIdentity(ctx, identity)
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		return ErrIdentityLinked
+	} else if err != nil {
+		return err
+	}
+	c.audit(ctx, model.AuditEventIdentityLinked, identity.UserID, identity.UserID, map[string]any{"provider": identity.Provider})
+	return nil
+}
@@ -0,0 +1,410 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abhishek622/moviedock/user/internal/oidc"
+	"github.com/abhishek622/moviedock/user/internal/oidc/mock"
+	"github.com/abhishek622/moviedock/user/internal/repository"
+	"github.com/abhishek622/moviedock/user/pkg/model"
+)
+
+const (
+	testProvider    = "mock"
+	testRedirectURL = "http://app.test/auth/oidc/mock/callback"
+)
+
+// oidcRepository keeps the users, identities and login states of the OIDC
+// flow in memory. Other repository methods are not implemented.
+type oidcRepository struct {
+	userRepository
+
+	mu         sync.Mutex
+	users      map[string]*model.User
+	identities map[string]*model.UserIdentity // by provider and subject
+	states     map[string]*model.OIDCLoginState
+}
+
+func newOIDCRepository() *oidcRepository {
+	return &oidcRepository{
+		users:      map[string]*model.User{},
+		identities: map[string]*model.UserIdentity{},
+		states:     map[string]*model.OIDCLoginState{},
+	}
+}
+
+func (r *oidcRepository) addUser(user *model.User) *model.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user.UserID = strconv.Itoa(len(r.users) + 1)
+	r.users[user.UserID] = user
+	return user
+}
+
+func (r *oidcRepository) GetByEmail(_ context.Context, email string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *oidcRepository) RecordLogin(context.Context, string) error {
+	return nil
+}
+
+func (r *oidcRepository) CreateAuditEvent(context.Context, *model.AuditEvent) error {
+	return nil
+}
+
+func (r *oidcRepository) CreateOIDCLoginState(_ context.Context, state *model.OIDCLoginState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states[state.StateHash] = state
+	return nil
+}
+
+func (r *oidcRepository) ConsumeOIDCLoginState(_ context.Context, stateHash, provider string) (*model.OIDCLoginState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.states[stateHash]
+	delete(r.states, stateHash)
+	if !ok || state.Provider != provider || !state.ExpiresAt.After(time.Now()) {
+		return nil, repository.ErrNotFound
+	}
+	return state, nil
+}
+
+func (r *oidcRepository) GetByIdentity(_ context.Context, provider, subject string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	identity, ok := r.identities[provider+"/"+subject]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return r.users[identity.UserID], nil
+}
+
+func (r *oidcRepository) RegisterUserWithIdentity(ctx context.Context, user *model.User, identity *model.UserIdentity, verified bool) (*model.User, error) {
+	user.IsVerified = verified
+	user = r.addUser(user)
+	identity.UserID = user.UserID
+	if err := r.LinkIdentity(ctx, identity); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (r *oidcRepository) LinkIdentity(_ context.Context, identity *model.UserIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := identity.Provider + "/" + identity.Subject
+	if _, ok := r.identities[key]; ok {
+		return repository.ErrAlreadyExists
+	}
+	r.identities[key] = identity
+	return nil
+}
+
+// plainHasher stands in for a password hasher, which the OIDC flow only
+// needs for the unusable password of new users.
+type plainHasher struct{}
+
+func (plainHasher) Hash(password string) (string, error)       { return "plain:" + password, nil }
+func (plainHasher) Verify(hash, password string) (bool, error) { return hash == "plain:"+password, nil }
+func (plainHasher) NeedsRehash(string) bool                    { return false }
+
+// newOIDCTest returns a controller logging in with a mock provider.
+func newOIDCTest(t *testing.T) (*Controller, *oidcRepository) {
+	t.Helper()
+
+	var provider *mock.Server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provider.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	provider, err := mock.New(mock.Config{Issuer: server.URL, ClientID: "moviedock", ClientSecret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	providers := oidc.NewProviders([]oidc.Config{{
+		Name:         testProvider,
+		Issuer:       server.URL,
+		ClientID:     "moviedock",
+		ClientSecret: "secret",
+		RedirectURL:  testRedirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+	}}, server.Client())
+
+	repo := newOIDCRepository()
+	ctrl, err := New(repo, nil, nil, nil, plainHasher{}, providers, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctrl, repo
+}
+
+// authorize starts a login and follows the authorization URL to the mock
+// provider, returning the code and state it redirects back with.
+func authorize(t *testing.T, ctrl *Controller, userID *string, email string) (code, state string) {
+	t.Helper()
+
+	auth, err := ctrl.StartOIDCLogin(context.Background(), testProvider, userID)
+	if err != nil {
+		t.Fatalf("StartOIDCLogin: %v", err)
+	}
+	u, err := url.Parse(auth.AuthorizationURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	q.Set("login_hint", email)
+	u.RawQuery = q.Encode()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize: status %d", resp.StatusCode)
+	}
+
+	back, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isCallback(back) {
+		t.Fatalf("authorize: redirected to %s", back)
+	}
+	if e := back.Query().Get("error"); e != "" {
+		t.Fatalf("authorize: %s", e)
+	}
+	return back.Query().Get("code"), back.Query().Get("state")
+}
+
+func isCallback(u *url.URL) bool {
+	callback, _ := url.Parse(testRedirectURL)
+	return u.Scheme == callback.Scheme && u.Host == callback.Host && u.Path == callback.Path
+}
+
+// loginState returns the single pending login state.
+func (r *oidcRepository) loginState(t *testing.T) *model.OIDCLoginState {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.states) != 1 {
+		t.Fatalf("got %d login states, want 1", len(r.states))
+	}
+	for _, s := range r.states {
+		return s
+	}
+	return nil
+}
+
+func TestOIDCLoginRegistersNewUser(t *testing.T) {
+	ctrl, repo := newOIDCTest(t)
+	ctx := context.Background()
+
+	code, state := authorize(t, ctrl, nil, "New.User@Example.com")
+	user, err := ctrl.CompleteOIDCLogin(ctx, testProvider, code, state)
+	if err != nil {
+		t.Fatalf("CompleteOIDCLogin: %v", err)
+	}
+	if user.Email != "new.user@example.com" || !user.IsVerified || user.Role != model.RoleUser {
+		t.Errorf("registered user %+v", user)
+	}
+
+	// Logging in again finds the user by the linked identity.
+	code, state = authorize(t, ctrl, nil, "new.user@example.com")
+	again, err := ctrl.CompleteOIDCLogin(ctx, testProvider, code, state)
+	if err != nil {
+		t.Fatalf("CompleteOIDCLogin: %v", err)
+	}
+	if again.UserID != user.UserID || len(repo.users) != 1 {
+		t.Errorf("second login returned user %s, %d users exist", again.UserID, len(repo.users))
+	}
+}
+
+func TestOIDCLoginLinksExistingUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		verified bool
+		wantErr  error
+	}{
+		{name: "verified email", verified: true},
+		{name: "unverified email", verified: false, wantErr: ErrIdentityConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, repo := newOIDCTest(t)
+			existing := repo.addUser(&model.User{Email: "film.fan@example.com", IsActive: true, IsVerified: tt.verified})
+
+			code, state := authorize(t, ctrl, nil, "film.fan@example.com")
+			user, err := ctrl.CompleteOIDCLogin(context.Background(), testProvider, code, state)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CompleteOIDCLogin: got error %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(repo.identities) != 0 {
+					t.Errorf("identity linked despite the conflict")
+				}
+				return
+			}
+			if user.UserID != existing.UserID || len(repo.users) != 1 || len(repo.identities) != 1 {
+				t.Errorf("logged in as %s, %d users and %d identities exist", user.UserID, len(repo.users), len(repo.identities))
+			}
+		})
+	}
+}
+
+func TestOIDCLinkIdentity(t *testing.T) {
+	ctrl, repo := newOIDCTest(t)
+	ctx := context.Background()
+	user := repo.addUser(&model.User{Email: "film.fan@example.com", IsActive: true})
+
+	code, state := authorize(t, ctrl, &user.UserID, "other.address@example.com")
+	identity, err := ctrl.LinkOIDCIdentity(ctx, user.UserID, testProvider, code, state)
+	if err != nil {
+		t.Fatalf("LinkOIDCIdentity: %v", err)
+	}
+	if identity.UserID != user.UserID || identity.Email != "other.address@example.com" {
+		t.Errorf("linked identity %+v", identity)
+	}
+
+	// The identity now logs in as the user.
+	code, state = authorize(t, ctrl, nil, "other.address@example.com")
+	loggedIn, err := ctrl.CompleteOIDCLogin(ctx, testProvider, code, state)
+	if err != nil {
+		t.Fatalf("CompleteOIDCLogin: %v", err)
+	}
+	if loggedIn.UserID != user.UserID {
+		t.Errorf("logged in as %s, want %s", loggedIn.UserID, user.UserID)
+	}
+
+	// It cannot be linked a second time.
+	code, state = authorize(t, ctrl, &user.UserID, "other.address@example.com")
+	if _, err := ctrl.LinkOIDCIdentity(ctx, user.UserID, testProvider, code, state); !errors.Is(err, ErrIdentityLinked) {
+		t.Errorf("linking again: got error %v, want %v", err, ErrIdentityLinked)
+	}
+}
+
+func TestOIDCLoginStateMustMatchFlow(t *testing.T) {
+	ctrl, repo := newOIDCTest(t)
+	ctx := context.Background()
+	user := repo.addUser(&model.User{Email: "film.fan@example.com", IsActive: true})
+
+	// A state started for linking cannot log in, and the other way around.
+	code, state := authorize(t, ctrl, &user.UserID, "film.fan@example.com")
+	if _, err := ctrl.CompleteOIDCLogin(ctx, testProvider, code, state); !errors.Is(err, ErrInvalidLoginState) {
+		t.Errorf("logging in with a link state: got error %v, want %v", err, ErrInvalidLoginState)
+	}
+	code, state = authorize(t, ctrl, nil, "film.fan@example.com")
+	if _, err := ctrl.LinkOIDCIdentity(ctx, user.UserID, testProvider, code, state); !errors.Is(err, ErrInvalidLoginState) {
+		t.Errorf("linking with a login state: got error %v, want %v", err, ErrInvalidLoginState)
+	}
+
+	// A link state only links to the user who started it.
+	other := "another-user"
+	code, state = authorize(t, ctrl, &other, "film.fan@example.com")
+	if _, err := ctrl.LinkOIDCIdentity(ctx, user.UserID, testProvider, code, state); !errors.Is(err, ErrInvalidLoginState) {
+		t.Errorf("linking with another user's state: got error %v, want %v", err, ErrInvalidLoginState)
+	}
+}
+
+func TestOIDCLoginRejectsReusedState(t *testing.T) {
+	ctrl, _ := newOIDCTest(t)
+	ctx := context.Background()
+
+	code, state := authorize(t, ctrl, nil, "film.fan@example.com")
+	if _, err := ctrl.CompleteOIDCLogin(ctx, testProvider, code, state); err != nil {
+		t.Fatalf("CompleteOIDCLogin: %v", err)
+	}
+	if _, err := ctrl.CompleteOIDCLogin(ctx, testProvider, code, state); !errors.Is(err, ErrInvalidLoginState) {
+		t.Errorf("reusing the state: got error %v, want %v", err, ErrInvalidLoginState)
+	}
+	if _, err := ctrl.CompleteOIDCLogin(ctx, testProvider, code, "unknown"); !errors.Is(err, ErrInvalidLoginState) {
+		t.Errorf("unknown state: got error %v, want %v", err, ErrInvalidLoginState)
+	}
+}
+
+func TestOIDCLoginRejectsExpiredState(t *testing.T) {
+	ctrl, repo := newOIDCTest(t)
+
+	code, state := authorize(t, ctrl, nil, "film.fan@example.com")
+	repo.loginState(t).ExpiresAt = time.Now().Add(-time.Second)
+	if _, err := ctrl.CompleteOIDCLogin(context.Background(), testProvider, code, state); !errors.Is(err, ErrInvalidLoginState) {
+		t.Errorf("got error %v, want %v", err, ErrInvalidLoginState)
+	}
+}
+
+func TestOIDCLoginRejectsTamperedState(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(*model.OIDCLoginState)
+	}{
+		{
+			name: "PKCE verifier does not match the challenge",
+			tamper: func(s *model.OIDCLoginState) {
+				s.CodeVerifier += "x"
+			},
+		},
+		{
+			name: "nonce does not match the ID token",
+			tamper: func(s *model.OIDCLoginState) {
+				s.Nonce += "x"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, repo := newOIDCTest(t)
+
+			code, state := authorize(t, ctrl, nil, "film.fan@example.com")
+			tt.tamper(repo.loginState(t))
+			if _, err := ctrl.CompleteOIDCLogin(context.Background(), testProvider, code, state); !errors.Is(err, ErrProviderLogin) {
+				t.Errorf("got error %v, want %v", err, ErrProviderLogin)
+			}
+			if len(repo.users) != 0 {
+				t.Errorf("%d users registered", len(repo.users))
+			}
+		})
+	}
+}
+
+func TestOIDCAuthorizationURLCarriesPKCEChallenge(t *testing.T) {
+	ctrl, repo := newOIDCTest(t)
+
+	auth, err := ctrl.StartOIDCLogin(context.Background(), testProvider, nil)
+	if err != nil {
+		t.Fatalf("StartOIDCLogin: %v", err)
+	}
+	u, err := url.Parse(auth.AuthorizationURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	loginState := repo.loginState(t)
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") != oidc.CodeChallenge(loginState.CodeVerifier) {
+		t.Errorf("authorization URL challenge %s/%s does not match the stored verifier", q.Get("code_challenge_method"), q.Get("code_challenge"))
+	}
+	if q.Get("nonce") != loginState.Nonce || q.Get("state") != auth.State || hashToken(auth.State) != loginState.StateHash {
+		t.Errorf("authorization URL nonce or state does not match the stored login state")
+	}
+}
@@ -0,0 +1,145 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/user/internal/repository"
+	"github.com/abhishek622/moviedock/user/pkg/model"
+)
+
+// Limits on free-form user metadata.
+const (
+	maxMetadataKeys  = 50
+	maxMetadataBytes = 8 << 10
+)
+
+var metadataKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// GetPreferences returns the preferences of a user.
+func (c *Controller) GetPreferences(ctx context.Context, userID string) (*model.Preferences, error) {
+	user, err := c.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return normalizePreferences(&user.Preferences), nil
+}
+
+// UpdatePreferences replaces the preferences of a user. Genres are compared
+// case-insensitively and stored in lower case.
+func (c *Controller) UpdatePreferences(ctx context.Context, userID string, preferences *model.Preferences) (*model.Preferences, error) {
+	user, err := c.repo.UpdatePreferences(ctx, userID, normalizePreferences(preferences))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &user.Preferences, nil
+}
+
+// GetPublicProfile returns the profile of a user visible to everyone.
+// Deactivated users are reported as not found.
+func (c *Controller) GetPublicProfile(ctx context.Context, userID string) (*model.PublicProfile, error) {
+	user, err := c.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrNotFound
+	}
+	return user.PublicProfile(), nil
+}
+
+// UpdatePublicMetadata replaces the public metadata of a user.
+func (c *Controller) UpdatePublicMetadata(ctx context.Context, userID string, metadata model.Metadata) (model.Metadata, error) {
+	if err := validateMetadata(metadata); err != nil {
+		return nil, err
+	}
+
+	user, err := c.repo.UpdatePublicMetadata(ctx, userID, nonNil(metadata))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return user.PublicMetadata, nil
+}
+
+// GetPrivateMetadata returns the private metadata of a user.
+func (c *Controller) GetPrivateMetadata(ctx context.Context, userID string) (model.Metadata, error) {
+	user, err := c.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return nonNil(user.PrivateMetadata), nil
+}
+
+// UpdatePrivateMetadata replaces the private metadata of a user.
+func (c *Controller) UpdatePrivateMetadata(ctx context.Context, userID string, metadata model.Metadata) (model.Metadata, error) {
+	if err := validateMetadata(metadata); err != nil {
+		return nil, err
+	}
+
+	user, err := c.repo.UpdatePrivateMetadata(ctx, userID, nonNil(metadata))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return user.PrivateMetadata, nil
+}
+
+// validateMetadata limits the number and names of keys and the encoded size
+// of free-form metadata.
+func validateMetadata(metadata model.Metadata) error {
+	if len(metadata) > maxMetadataKeys {
+		return apperr.New(apperr.InvalidArgument, fmt.Sprintf("metadata may have at most %d keys", maxMetadataKeys))
+	}
+	for key := range metadata {
+		if !metadataKeyPattern.MatchString(key) {
+			return apperr.New(apperr.InvalidArgument, fmt.Sprintf("invalid metadata key %q", key))
+		}
+	}
+
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		return apperr.Wrap(apperr.InvalidArgument, "invalid metadata", err)
+	}
+	if len(b) > maxMetadataBytes {
+		return apperr.New(apperr.InvalidArgument, fmt.Sprintf("metadata may be at most %d bytes", maxMetadataBytes))
+	}
+	return nil
+}
+
+// normalizePreferences returns a copy of p with genres trimmed, lower cased
+// and deduplicated, and without nil slices.
+func normalizePreferences(p *model.Preferences) *model.Preferences {
+	n := *p
+	n.FavoriteGenres = normalizeGenres(p.FavoriteGenres)
+	n.ContentFilters.HiddenGenres = normalizeGenres(p.ContentFilters.HiddenGenres)
+	return &n
+}
+
+func normalizeGenres(genres []string) []string {
+	res := make([]string, 0, len(genres))
+	for _, g := range genres {
+		g = strings.ToLower(strings.TrimSpace(g))
+		if g != "" && !slices.Contains(res, g) {
+			res = append(res, g)
+		}
+	}
+	return res
+}
+
+func nonNil(m model.Metadata) model.Metadata {
+	if m == nil {
+		return model.Metadata{}
+	}
+	return m
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+
+	"github.com/abhishek622/moviedock/pkg/discovery"
+)
+
+// Gateway defines an HTTP gateway to the rating service.
+type Gateway struct {
+	registry discovery.Registry
+	client   *http.Client
+}
+
+// New creates a rating service gateway. The client is expected to
+// authenticate its requests as the user service.
+func New(registry discovery.Registry, client *http.Client) *Gateway {
+	return &Gateway{registry, client}
+}
+
+// DeleteUserRatings removes all ratings written by a user.
+func (g *Gateway) DeleteUserRatings(ctx context.Context, userID string) error {
+	addrs, err := g.registry.ServiceAddresses(ctx, "rating")
+	if err != nil {
+		return err
+	}
+
+	addr := addrs[rand.Intn(len(addrs))]
+	u := "http://" + addr + "/api/v1/rating/user/" + url.PathEscape(userID)
+	log.Printf("Calling rating service. Request: DELETE %s", u)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("non-2xx response: %v", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/authz"
+	"github.com/abhishek622/moviedock/pkg/pagination"
+	"github.com/abhishek622/moviedock/user/pkg/model"
+	"github.com/gin-gonic/gin"
+)
+
+// ListUsers returns a page of users sorted by creation time or email,
+// optionally filtered by a search query, role and active status
+func (h *Handler) ListUsers(c *gin.Context) {
+	page, err := pagination.FromQuery(c.Request.URL.Query(), 20, "created_at", "email")
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	filter := model.UserFilter{
+		Query: c.Query("q"),
+		Role:  model.Role(c.Query("role")),
+		Page:  page,
+	}
+	if v := c.Query("is_active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid is_active"})
+			return
+		}
+		filter.IsActive = &active
+	}
+
+	list, err := h.ctrl.ListUsers(c.Request.Context(), filter)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GetUser returns the profile of any user
+func (h *Handler) GetUser(c *gin.Context) {
+	profile, err := h.ctrl.GetProfile(c.Request.Context(), c.Param("user_id"))
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// ChangeRole sets the role of a user
+func (h *Handler) ChangeRole(c *gin.Context) {
+	var req model.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+	profile, err := h.ctrl.ChangeRole(c.Request.Context(), claims.UserID, c.Param("user_id"), req.Role)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// ActivateUser allows a deactivated user to log in again
+func (h *Handler) ActivateUser(c *gin.Context) {
+	h.setActive(c, true)
+}
+
+// DeactivateUser prevents a user from logging in and ends their sessions
+func (h *Handler) DeactivateUser(c *gin.Context) {
+	h.setActive(c, false)
+}
+
+func (h *Handler) setActive(c *gin.Context, active bool) {
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+	profile, err := h.ctrl.SetActive(c.Request.Context(), claims.UserID, c.Param("user_id"), active)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// ForceLogout ends all sessions of a user
+func (h *Handler) ForceLogout(c *gin.Context) {
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+	if err := h.ctrl.ForceLogout(c.Request.Context(), claims.UserID, c.Param("user_id")); err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
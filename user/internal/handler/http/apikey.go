@@ -0,0 +1,82 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/authz"
+	"github.com/abhishek622/moviedock/user/pkg/model"
+	"github.com/gin-gonic/gin"
+)
+
+// ListAPIKeys returns the API keys of the authenticated user
+func (h *Handler) ListAPIKeys(c *gin.Context) {
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+
+	keys, err := h.ctrl.ListAPIKeys(c.Request.Context(), claims.UserID)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// CreateAPIKey mints an API key for the authenticated user. The key is only
+// shown in this response
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	var req model.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+	key, err := h.ctrl.CreateAPIKey(c.Request.Context(), claims.UserID, &req)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// RevokeAPIKey revokes one of the authenticated user's API keys
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+
+	if err := h.ctrl.RevokeAPIKey(c.Request.Context(), claims.UserID, c.Param("key_id")); err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// IntrospectAPIKey returns the claims an API key authenticates with. Other
+// services call it to accept API keys
+func (h *Handler) IntrospectAPIKey(c *gin.Context) {
+	var req model.IntrospectAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	claims, err := h.ctrl.ResolveAPIKey(c.Request.Context(), req.APIKey)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, claims)
+}
@@ -3,11 +3,13 @@ package http
 import (
 	"net/http"
 
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/auth"
+	"github.com/abhishek622/moviedock/pkg/authz"
 	"github.com/abhishek622/moviedock/user/internal/controller/user"
 	"github.com/abhishek622/moviedock/user/pkg/model"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"golang.org/x/crypto/bcrypt"
 )
 
 var validate *validator.Validate
@@ -18,10 +20,11 @@ func init() {
 
 type Handler struct {
 	ctrl *user.Controller
+	keys *auth.Keyring
 }
 
-func New(ctrl *user.Controller) *Handler {
-	return &Handler{ctrl}
+func New(ctrl *user.Controller, keys *auth.Keyring) *Handler {
+	return &Handler{ctrl, keys}
 }
 
 // RegisterRoutes registers all the routes for the user service.
@@ -31,6 +34,9 @@ func (h *Handler) RegisterRoutes(router *gin.Engine) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	// Public keys used by other services to verify access tokens
+	router.GET("/.well-known/jwks.json", h.JWKS)
+
 	// API v1 routes
 	v1 := router.Group("/api/v1")
 	{
@@ -39,20 +45,76 @@ func (h *Handler) RegisterRoutes(router *gin.Engine) {
 		{
 			auth.POST("/register", h.RegisterUser)
 			auth.POST("/login", h.LoginUser)
+			auth.POST("/login/mfa", h.LoginMFA)
+			auth.POST("/refresh", h.RefreshToken)
+			auth.POST("/logout", h.LogoutUser)
+			auth.POST("/password/forgot", h.ForgotPassword)
+			auth.POST("/password/reset", h.ResetPassword)
+			auth.POST("/verify-email", h.VerifyEmail)
+			auth.POST("/api-keys/introspect", h.IntrospectAPIKey)
+			auth.GET("/oidc/providers", h.OIDCProviders)
+			auth.POST("/oidc/:provider/authorize", h.StartOIDCLogin)
+			auth.POST("/oidc/:provider/callback", h.CompleteOIDCLogin)
 		}
 
 		// Protected routes
-		// user := v1.Group("/user")
-		// user.Use(h.AuthMiddleware())
-		// {
-		// 	user.GET("/profile", h.GetProfile)
-		// 	user.PUT("/profile", h.UpdateProfile)
-		// 	user.POST("/logout", h.LogoutUser)
-		// 	user.POST("/refresh", h.RefreshToken)
-		// }
+		user := v1.Group("/user")
+		user.Use(authz.Require(authz.Authenticated()))
+		{
+			// API keys need the profile scopes to read and write the profile
+			read := authz.Require(authz.APIKeyScope(authz.ScopeProfileRead))
+			write := authz.Require(authz.APIKeyScope(authz.ScopeProfileWrite))
+			user.GET("/profile", read, h.GetProfile)
+			user.PUT("/profile", write, h.UpdateProfile)
+			user.POST("/logout", h.LogoutSession)
+			user.POST("/verify-email", h.SendEmailVerification)
+			user.GET("/preferences", read, h.GetPreferences)
+			user.PUT("/preferences", write, h.UpdatePreferences)
+			user.GET("/metadata/public", read, h.GetPublicMetadata)
+			user.PUT("/metadata/public", write, h.UpdatePublicMetadata)
+
+			// Credentials and the account itself can only be managed from a
+			// login session
+			credentials := user.Group("", authz.Require(authz.RejectAPIKeys()))
+			credentials.DELETE("/profile", h.DeleteAccount)
+			credentials.POST("/mfa/totp", h.EnrollTOTP)
+			credentials.POST("/mfa/totp/confirm", h.ConfirmTOTP)
+			credentials.POST("/mfa/totp/disable", h.DisableTOTP)
+			credentials.POST("/mfa/recovery-codes", h.RegenerateRecoveryCodes)
+			credentials.GET("/api-keys", h.ListAPIKeys)
+			credentials.POST("/api-keys", h.CreateAPIKey)
+			credentials.DELETE("/api-keys/:key_id", h.RevokeAPIKey)
+			credentials.GET("/identities", h.ListIdentities)
+			credentials.POST("/identities/:provider/authorize", h.StartOIDCLink)
+			credentials.POST("/identities/:provider", h.LinkOIDCIdentity)
+			credentials.DELETE("/identities/:identity_id", h.UnlinkIdentity)
+		}
+
+		// Public profiles
+		v1.GET("/users/:user_id", h.GetPublicProfile)
+
+		// Admin routes
+		admin := v1.Group("/admin/users")
+		admin.Use(authz.Require(authz.RequireRole(model.RoleAdmin)))
+		{
+			admin.GET("", h.ListUsers)
+			admin.GET("/:user_id", h.GetUser)
+			admin.PUT("/:user_id/role", h.ChangeRole)
+			admin.POST("/:user_id/activate", h.ActivateUser)
+			admin.POST("/:user_id/deactivate", h.DeactivateUser)
+			admin.POST("/:user_id/logout", h.ForceLogout)
+			admin.GET("/:user_id/metadata/private", h.GetPrivateMetadata)
+			admin.PUT("/:user_id/metadata/private", h.UpdatePrivateMetadata)
+		}
 	}
 }
 
+// JWKS serves the public half of the token signing keys
+func (h *Handler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
+
 // RegisterRequest represents the JSON payload for user registration
 type RegisterRequest struct {
 	Email    string  `json:"email" binding:"required,email"`
@@ -69,32 +131,19 @@ func (h *Handler) RegisterUser(c *gin.Context) {
 		return
 	}
 
-	// Hash the password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process password"})
-		return
-	}
-
 	// Create user model
 	user := &model.User{
-		Email:             req.Email,
-		EncryptedPassword: string(hashedPassword),
-		FullName:          req.FullName,
-		Role:              model.RoleUser, // Default role
-		IsActive:          true,
-		Timezone:          req.Timezone,
+		Email:    req.Email,
+		FullName: req.FullName,
+		Role:     model.RoleUser, // Default role
+		IsActive: true,
+		Timezone: req.Timezone,
 	}
 
 	// Call controller to register user
-	registeredUser, err := h.ctrl.RegisterUser(c.Request.Context(), user)
+	registeredUser, err := h.ctrl.RegisterUser(c.Request.Context(), user, req.Password)
 	if err != nil {
-		// Check if it's a duplicate email error
-		if err.Error() == "user with this email already exists" {
-			c.JSON(http.StatusConflict, gin.H{"error": "User with this email already exists"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register user"})
+		apperr.Respond(c, err)
 		return
 	}
 
@@ -124,38 +173,249 @@ func (h *Handler) LoginUser(c *gin.Context) {
 		return
 	}
 
-	// Create user model with login credentials
-	user := &model.User{
-		Email:             loginReq.Email,
-		EncryptedPassword: loginReq.Password,
-	}
-
 	// Call controller to authenticate user
-	loggedInUser, err := h.ctrl.LoginUser(c.Request.Context(), user)
+	loggedInUser, err := h.ctrl.LoginUser(c.Request.Context(), loginReq.Email, loginReq.Password, c.ClientIP())
 	if err != nil {
-		if err.Error() == "user not found" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process login"})
+		apperr.Respond(c, err)
+		return
+	}
+
+	h.respondWithLogin(c, loggedInUser)
+}
+
+// respondWithLogin starts a session for a user whose first factor has been
+// checked. Users with two-factor authentication get a challenge instead
+func (h *Handler) respondWithLogin(c *gin.Context, loggedInUser *model.User) {
+	if loggedInUser.TOTPEnabled {
+		challenge, err := h.ctrl.CreateLoginChallenge(c.Request.Context(), loggedInUser)
+		if err != nil {
+			apperr.Respond(c, err)
+			return
 		}
+		c.JSON(http.StatusOK, challenge)
 		return
 	}
 
-	// Verify password
-	err = bcrypt.CompareHashAndPassword([]byte(loggedInUser.EncryptedPassword), []byte(loginReq.Password))
+	h.respondWithTokens(c, loggedInUser)
+}
+
+// LoginMFA completes a login challenge with a TOTP or recovery code
+func (h *Handler) LoginMFA(c *gin.Context) {
+	var req model.MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	loggedInUser, err := h.ctrl.CompleteLogin(c.Request.Context(), req.ChallengeToken, req.Code, req.RecoveryCode, c.ClientIP())
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+		apperr.Respond(c, err)
+		return
+	}
+
+	h.respondWithTokens(c, loggedInUser)
+}
+
+// respondWithTokens starts a session for a user who has logged in
+func (h *Handler) respondWithTokens(c *gin.Context, loggedInUser *model.User) {
+	tokens, err := h.ctrl.IssueTokens(c.Request.Context(), loggedInUser)
+	if err != nil {
+		apperr.Respond(c, err)
 		return
 	}
 
-	// For now, just return the user without tokens
-	// In a real implementation, you would generate JWT tokens here
 	response := model.UserResponse{
-		UserId:   loggedInUser.UserID,
-		FullName: loggedInUser.FullName,
-		Email:    loggedInUser.Email,
-		Role:     string(loggedInUser.Role),
+		UserId:       loggedInUser.UserID,
+		FullName:     loggedInUser.FullName,
+		Email:        loggedInUser.Email,
+		Role:         string(loggedInUser.Role),
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
 	}
 
 	c.JSON(http.StatusOK, response)
 }
+
+// RefreshToken exchanges a refresh token for a new access/refresh token pair
+func (h *Handler) RefreshToken(c *gin.Context) {
+	var req model.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	tokens, err := h.ctrl.RefreshToken(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// LogoutUser revokes the session belonging to the given refresh token
+func (h *Handler) LogoutUser(c *gin.Context) {
+	var req model.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	if err := h.ctrl.LogoutUser(c.Request.Context(), req.RefreshToken); err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetProfile returns the profile of the authenticated user
+func (h *Handler) GetProfile(c *gin.Context) {
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+
+	profile, err := h.ctrl.GetProfile(c.Request.Context(), claims.UserID)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// UpdateProfile changes the profile of the authenticated user
+func (h *Handler) UpdateProfile(c *gin.Context) {
+	var req model.ProfileUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+	profile, err := h.ctrl.UpdateProfile(c.Request.Context(), claims.UserID, &req)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// DeleteAccount removes the authenticated user and their ratings
+func (h *Handler) DeleteAccount(c *gin.Context) {
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+
+	if err := h.ctrl.DeleteAccount(c.Request.Context(), claims.UserID); err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// LogoutSession revokes the session of the access token used for the request
+func (h *Handler) LogoutSession(c *gin.Context) {
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+
+	if err := h.ctrl.LogoutSession(c.Request.Context(), claims.SessionID); err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ForgotPassword sends a password reset link to the given email. The
+// response does not reveal whether the email is registered.
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req model.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	if err := h.ctrl.RequestPasswordReset(c.Request.Context(), req.Email); err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "if the email is registered, a reset link has been sent"})
+}
+
+// ResetPassword sets a new password using a token from ForgotPassword
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req model.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	if err := h.ctrl.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// VerifyEmail confirms the email of the user a verification token was sent to
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var req model.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	if err := h.ctrl.VerifyEmail(c.Request.Context(), req.Token); err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SendEmailVerification sends a new verification link to the authenticated user
+func (h *Handler) SendEmailVerification(c *gin.Context) {
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+
+	if err := h.ctrl.SendEmailVerification(c.Request.Context(), claims.UserID); err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
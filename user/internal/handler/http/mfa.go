@@ -0,0 +1,87 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/authz"
+	"github.com/abhishek622/moviedock/user/pkg/model"
+	"github.com/gin-gonic/gin"
+)
+
+// EnrollTOTP generates a TOTP secret for the authenticated user
+func (h *Handler) EnrollTOTP(c *gin.Context) {
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+
+	enrollment, err := h.ctrl.EnrollTOTP(c.Request.Context(), claims.UserID)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmTOTP enables two-factor authentication and returns recovery codes
+func (h *Handler) ConfirmTOTP(c *gin.Context) {
+	req, ok := bindCode(c)
+	if !ok {
+		return
+	}
+
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+	codes, err := h.ctrl.ConfirmTOTP(c.Request.Context(), claims.UserID, req.Code, c.ClientIP())
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// DisableTOTP turns off two-factor authentication for the authenticated user
+func (h *Handler) DisableTOTP(c *gin.Context) {
+	req, ok := bindCode(c)
+	if !ok {
+		return
+	}
+
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+	if err := h.ctrl.DisableTOTP(c.Request.Context(), claims.UserID, req.Code, c.ClientIP()); err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the authenticated user
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	req, ok := bindCode(c)
+	if !ok {
+		return
+	}
+
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+	codes, err := h.ctrl.RegenerateRecoveryCodes(c.Request.Context(), claims.UserID, req.Code, c.ClientIP())
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+func bindCode(c *gin.Context) (*model.TOTPCodeRequest, bool) {
+	var req model.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return nil, false
+	}
+
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return nil, false
+	}
+	return &req, true
+}
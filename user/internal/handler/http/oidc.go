@@ -0,0 +1,161 @@
+package http
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/authz"
+	"github.com/abhishek622/moviedock/user/internal/controller/user"
+	"github.com/abhishek622/moviedock/user/pkg/model"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// oidcStateCookie holds a hash of the state of a login at an identity
+	// provider. Callbacks are only accepted from the browser holding it, so
+	// that nobody can have a victim complete a login they started.
+	oidcStateCookie = "oidc_state"
+	// oidcStateCookieMaxAge is how long the login state is valid, in seconds.
+	oidcStateCookieMaxAge = 10 * 60
+)
+
+// OIDCProviders lists the identity providers users can log in with
+func (h *Handler) OIDCProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, model.OIDCProviders{Providers: h.ctrl.OIDCProviders()})
+}
+
+// StartOIDCLogin returns the URL to send the user to for logging in with an
+// identity provider
+func (h *Handler) StartOIDCLogin(c *gin.Context) {
+	authorization, err := h.ctrl.StartOIDCLogin(c.Request.Context(), c.Param("provider"), nil)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	setOIDCStateCookie(c, authorization.State)
+	c.JSON(http.StatusOK, authorization)
+}
+
+// CompleteOIDCLogin logs in the user an identity provider returned with
+func (h *Handler) CompleteOIDCLogin(c *gin.Context) {
+	req, ok := bindOIDCCallback(c)
+	if !ok {
+		return
+	}
+
+	loggedInUser, err := h.ctrl.CompleteOIDCLogin(c.Request.Context(), c.Param("provider"), req.Code, req.State)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	h.respondWithLogin(c, loggedInUser)
+}
+
+// ListIdentities returns the external identities linked to the
+// authenticated user
+func (h *Handler) ListIdentities(c *gin.Context) {
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+
+	identities, err := h.ctrl.ListIdentities(c.Request.Context(), claims.UserID)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"identities": identities})
+}
+
+// StartOIDCLink returns the URL to send the authenticated user to for
+// linking an identity provider to their account
+func (h *Handler) StartOIDCLink(c *gin.Context) {
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+
+	authorization, err := h.ctrl.StartOIDCLogin(c.Request.Context(), c.Param("provider"), &claims.UserID)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	setOIDCStateCookie(c, authorization.State)
+	c.JSON(http.StatusOK, authorization)
+}
+
+// LinkOIDCIdentity links the identity a provider returned with to the
+// authenticated user
+func (h *Handler) LinkOIDCIdentity(c *gin.Context) {
+	req, ok := bindOIDCCallback(c)
+	if !ok {
+		return
+	}
+
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+	identity, err := h.ctrl.LinkOIDCIdentity(c.Request.Context(), claims.UserID, c.Param("provider"), req.Code, req.State)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, identity)
+}
+
+// UnlinkIdentity removes an external identity from the authenticated user
+func (h *Handler) UnlinkIdentity(c *gin.Context) {
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+
+	if err := h.ctrl.UnlinkIdentity(c.Request.Context(), claims.UserID, c.Param("identity_id")); err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// bindOIDCCallback binds the parameters a provider returned with, whose
+// state has to match the cookie set when the login started. The cookie is
+// cleared either way, as each state can only be used once.
+func bindOIDCCallback(c *gin.Context) (*model.OIDCCallbackRequest, bool) {
+	var req model.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return nil, false
+	}
+
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return nil, false
+	}
+
+	cookie, _ := c.Cookie(oidcStateCookie)
+	clearOIDCStateCookie(c)
+	if subtle.ConstantTimeCompare([]byte(cookie), []byte(hashOIDCState(req.State))) != 1 {
+		apperr.Respond(c, user.ErrInvalidLoginState)
+		return nil, false
+	}
+	return &req, true
+}
+
+func setOIDCStateCookie(c *gin.Context, state string) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, hashOIDCState(state), oidcStateCookieMaxAge, "/api/v1", "", isHTTPS(c), true)
+}
+
+func clearOIDCStateCookie(c *gin.Context) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, "", -1, "/api/v1", "", isHTTPS(c), true)
+}
+
+func hashOIDCState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
+
+// isHTTPS reports whether the client reached the service over HTTPS,
+// possibly through a proxy terminating TLS.
+func isHTTPS(c *gin.Context) bool {
+	return c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+}
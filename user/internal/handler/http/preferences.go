@@ -0,0 +1,125 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/authz"
+	"github.com/abhishek622/moviedock/user/pkg/model"
+	"github.com/gin-gonic/gin"
+)
+
+// GetPreferences returns the preferences of the authenticated user
+func (h *Handler) GetPreferences(c *gin.Context) {
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+
+	preferences, err := h.ctrl.GetPreferences(c.Request.Context(), claims.UserID)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// UpdatePreferences replaces the preferences of the authenticated user
+func (h *Handler) UpdatePreferences(c *gin.Context) {
+	var req model.Preferences
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+	preferences, err := h.ctrl.UpdatePreferences(c.Request.Context(), claims.UserID, &req)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// GetPublicMetadata returns the public metadata of the authenticated user
+func (h *Handler) GetPublicMetadata(c *gin.Context) {
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+
+	profile, err := h.ctrl.GetProfile(c.Request.Context(), claims.UserID)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile.PublicMetadata)
+}
+
+// UpdatePublicMetadata replaces the public metadata of the authenticated user
+func (h *Handler) UpdatePublicMetadata(c *gin.Context) {
+	metadata, ok := bindMetadata(c)
+	if !ok {
+		return
+	}
+
+	claims, _ := authz.ClaimsFromContext(c.Request.Context())
+	metadata, err := h.ctrl.UpdatePublicMetadata(c.Request.Context(), claims.UserID, metadata)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, metadata)
+}
+
+// GetPublicProfile returns the public profile of any user
+func (h *Handler) GetPublicProfile(c *gin.Context) {
+	profile, err := h.ctrl.GetPublicProfile(c.Request.Context(), c.Param("user_id"))
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// GetPrivateMetadata returns the private metadata of a user
+func (h *Handler) GetPrivateMetadata(c *gin.Context) {
+	metadata, err := h.ctrl.GetPrivateMetadata(c.Request.Context(), c.Param("user_id"))
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, metadata)
+}
+
+// UpdatePrivateMetadata replaces the private metadata of a user
+func (h *Handler) UpdatePrivateMetadata(c *gin.Context) {
+	metadata, ok := bindMetadata(c)
+	if !ok {
+		return
+	}
+
+	metadata, err := h.ctrl.UpdatePrivateMetadata(c.Request.Context(), c.Param("user_id"), metadata)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, metadata)
+}
+
+// bindMetadata reads a JSON object from the request body, responding with
+// an error if it is not one.
+func bindMetadata(c *gin.Context) (model.Metadata, bool) {
+	var metadata model.Metadata
+	if err := c.ShouldBindJSON(&metadata); err != nil || metadata == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return nil, false
+	}
+	return metadata, true
+}
@@ -0,0 +1,46 @@
+// Package local provides a notifier for development that records messages
+// in a file or the service log instead of delivering them.
+package local
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/abhishek622/moviedock/user/internal/notifier"
+)
+
+// Notifier appends messages to a file, or logs them when no file is set.
+type Notifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+// New creates a local notifier writing to path. An empty path logs messages.
+func New(path string) *Notifier {
+	return &Notifier{path: path}
+}
+
+// Notify records msg.
+func (n *Notifier) Notify(_ context.Context, msg notifier.Message) error {
+	if n.path == "" {
+		log.Printf("Notification to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+		return nil
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC1123Z), msg.To, msg.Subject, msg.Body)
+	return err
+}
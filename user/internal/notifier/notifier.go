@@ -0,0 +1,18 @@
+// Package notifier defines how the user service delivers messages, such as
+// password reset links, to users.
+package notifier
+
+import "context"
+
+// Message is a message addressed to a single user.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages to users. Implementations may send email, push
+// to a queue or, during development, just record the message locally.
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
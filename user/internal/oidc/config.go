@@ -0,0 +1,65 @@
+package oidc
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+var defaultScopes = []string{"openid", "email", "profile"}
+
+// Config describes an OpenID Connect provider users can log in with.
+type Config struct {
+	// Name identifies the provider in URLs and linked identities, e.g. "google".
+	Name string
+	// Issuer is the issuer URL; the provider metadata is discovered below it.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is where the provider sends the user back after logging in.
+	RedirectURL string
+	Scopes      []string
+}
+
+// ConfigsFromEnv reads the providers listed in OIDC_PROVIDERS (comma
+// separated). Each provider NAME is configured with OIDC_<NAME>_ISSUER,
+// OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET, OIDC_<NAME>_SCOPES
+// (comma separated, default "openid,email,profile") and
+// OIDC_<NAME>_REDIRECT_URL (default <appURL>/auth/oidc/<name>/callback).
+func ConfigsFromEnv(appURL string) ([]Config, error) {
+	var configs []Config
+	for _, name := range splitList(os.Getenv("OIDC_PROVIDERS")) {
+		name = strings.ToLower(name)
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+
+		cfg := Config{
+			Name:         name,
+			Issuer:       strings.TrimSuffix(os.Getenv(prefix+"ISSUER"), "/"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       splitList(os.Getenv(prefix + "SCOPES")),
+		}
+		if cfg.Issuer == "" || cfg.ClientID == "" {
+			return nil, fmt.Errorf("oidc provider %s: %sISSUER and %sCLIENT_ID are required", name, prefix, prefix)
+		}
+		if cfg.RedirectURL == "" {
+			cfg.RedirectURL = strings.TrimSuffix(appURL, "/") + "/auth/oidc/" + name + "/callback"
+		}
+		if len(cfg.Scopes) == 0 {
+			cfg.Scopes = defaultScopes
+		}
+		configs = append(configs, cfg)
+	}
+	return configs, nil
+}
+
+func splitList(s string) []string {
+	var res []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			res = append(res, part)
+		}
+	}
+	return res
+}
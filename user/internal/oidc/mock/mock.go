@@ -0,0 +1,251 @@
+// Package mock implements a minimal OpenID Connect provider for local
+// development and integration tests. It approves every authorization
+// request without asking: the user is taken from the login_hint parameter,
+// or a default test user if there is none.
+package mock
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abhishek622/moviedock/pkg/auth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	codeTTL    = time.Minute
+	idTokenTTL = 5 * time.Minute
+
+	defaultEmail = "test.user@example.com"
+)
+
+// Config configures the mock provider.
+type Config struct {
+	// Issuer is the URL the provider is reachable at.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// UnverifiedEmails marks the emails of all users as unverified.
+	UnverifiedEmails bool
+}
+
+type authorization struct {
+	clientID      string
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+	email         string
+	expiresAt     time.Time
+}
+
+// Server is a mock OpenID Connect provider.
+type Server struct {
+	cfg Config
+	key *auth.Key
+	sk  ed25519.PrivateKey
+	mux *http.ServeMux
+
+	mu    sync.Mutex
+	codes map[string]*authorization
+}
+
+// New creates a mock provider with a fresh signing key.
+func New(cfg Config) (*Server, error) {
+	pub, sk, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	kid := make([]byte, 8)
+	if _, err := rand.Read(kid); err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		cfg:   cfg,
+		key:   &auth.Key{ID: hex.EncodeToString(kid), Algorithm: auth.AlgorithmEdDSA, PublicKey: pub},
+		sk:    sk,
+		mux:   http.NewServeMux(),
+		codes: map[string]*authorization{},
+	}
+	s.mux.HandleFunc("GET /.well-known/openid-configuration", s.discovery)
+	s.mux.HandleFunc("GET /jwks", s.jwks)
+	s.mux.HandleFunc("GET /authorize", s.authorize)
+	s.mux.HandleFunc("POST /token", s.token)
+	return s, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                s.cfg.Issuer,
+		"authorization_endpoint":                s.cfg.Issuer + "/authorize",
+		"token_endpoint":                        s.cfg.Issuer + "/token",
+		"jwks_uri":                              s.cfg.Issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{auth.AlgorithmEdDSA},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *Server) jwks(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, auth.JWKS{Keys: []auth.JWK{s.key.JWK()}})
+}
+
+// authorize approves the request and redirects back with a code.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectURI, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || !redirectURI.IsAbs() {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if q.Get("client_id") != s.cfg.ClientID {
+		http.Error(w, "unknown client_id", http.StatusBadRequest)
+		return
+	}
+
+	back := redirectURI.Query()
+	back.Set("state", q.Get("state"))
+	switch {
+	case q.Get("response_type") != "code":
+		back.Set("error", "unsupported_response_type")
+	case q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256":
+		back.Set("error", "invalid_request")
+		back.Set("error_description", "PKCE with S256 is required")
+	default:
+		email := strings.ToLower(q.Get("login_hint"))
+		if email == "" {
+			email = defaultEmail
+		}
+		code, err := randomString()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		s.mu.Lock()
+		s.codes[code] = &authorization{
+			clientID:      s.cfg.ClientID,
+			redirectURI:   q.Get("redirect_uri"),
+			codeChallenge: q.Get("code_challenge"),
+			nonce:         q.Get("nonce"),
+			email:         email,
+			expiresAt:     time.Now().Add(codeTTL),
+		}
+		s.mu.Unlock()
+		back.Set("code", code)
+	}
+
+	redirectURI.RawQuery = back.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+// token redeems a code for an ID token.
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, "unsupported_grant_type")
+		return
+	}
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != s.cfg.ClientID || clientSecret != s.cfg.ClientSecret {
+		tokenError(w, "invalid_client")
+		return
+	}
+
+	s.mu.Lock()
+	code := r.PostForm.Get("code")
+	grant, ok := s.codes[code]
+	delete(s.codes, code)
+	s.mu.Unlock()
+
+	if !ok || time.Now().After(grant.expiresAt) ||
+		grant.clientID != clientID ||
+		grant.redirectURI != r.PostForm.Get("redirect_uri") ||
+		grant.codeChallenge != codeChallenge(r.PostForm.Get("code_verifier")) {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	idToken, err := s.idToken(grant)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	accessToken, err := randomString()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(idTokenTTL.Seconds()),
+		"id_token":     idToken,
+	})
+}
+
+func (s *Server) idToken(grant *authorization) (string, error) {
+	now := time.Now()
+	// Subjects are stable per email, like those of a real provider.
+	sum := sha256.Sum256([]byte(grant.email))
+	name, _, _ := strings.Cut(grant.email, "@")
+
+	claims := jwt.MapClaims{
+		"iss":            s.cfg.Issuer,
+		"sub":            hex.EncodeToString(sum[:16]),
+		"aud":            grant.clientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(idTokenTTL).Unix(),
+		"email":          grant.email,
+		"email_verified": !s.cfg.UnverifiedEmails,
+		"name":           name,
+	}
+	if grant.nonce != "" {
+		claims["nonce"] = grant.nonce
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = s.key.ID
+	return token.SignedString(s.sk)
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func codeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
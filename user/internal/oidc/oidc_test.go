@@ -0,0 +1,96 @@
+package oidc
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/abhishek622/moviedock/pkg/auth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// staticKeys is a key set holding a single key.
+type staticKeys struct {
+	key *auth.Key
+}
+
+func (s staticKeys) Key(kid string) (*auth.Key, error) {
+	if kid != s.key.ID {
+		return nil, auth.ErrUnknownKey
+	}
+	return s.key, nil
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	pub, sk, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := staticKeys{&auth.Key{ID: "test", Algorithm: auth.AlgorithmEdDSA, PublicKey: pub}}
+	p := NewProvider(Config{Name: "test", Issuer: "https://idp.example.com", ClientID: "moviedock"}, nil)
+
+	valid := func() jwt.MapClaims {
+		now := time.Now()
+		return jwt.MapClaims{
+			"iss":            "https://idp.example.com",
+			"sub":            "subject",
+			"aud":            "moviedock",
+			"iat":            now.Unix(),
+			"exp":            now.Add(time.Minute).Unix(),
+			"nonce":          "nonce",
+			"email":          "Film.Fan@Example.com",
+			"email_verified": true,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(jwt.MapClaims)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(jwt.MapClaims) {}},
+		{name: "wrong issuer", modify: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, wantErr: true},
+		{name: "wrong audience", modify: func(c jwt.MapClaims) { c["aud"] = "another-client" }, wantErr: true},
+		{name: "nonce mismatch", modify: func(c jwt.MapClaims) { c["nonce"] = "other" }, wantErr: true},
+		{name: "missing nonce", modify: func(c jwt.MapClaims) { delete(c, "nonce") }, wantErr: true},
+		{name: "expired", modify: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-2 * idTokenLeeway).Unix() }, wantErr: true},
+		{name: "missing subject", modify: func(c jwt.MapClaims) { delete(c, "sub") }, wantErr: true},
+		{
+			name:   "several audiences authorized for us",
+			modify: func(c jwt.MapClaims) { c["aud"], c["azp"] = []string{"moviedock", "other"}, "moviedock" },
+		},
+		{
+			name:    "several audiences authorized for another party",
+			modify:  func(c jwt.MapClaims) { c["aud"], c["azp"] = []string{"moviedock", "other"}, "other" },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			tt.modify(claims)
+			token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+			token.Header["kid"] = "test"
+			idToken, err := token.SignedString(sk)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			identity, err := p.verify(keys, idToken, "nonce")
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidIDToken) {
+					t.Errorf("got error %v, want %v", err, ErrInvalidIDToken)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if identity.Subject != "subject" || identity.Email != "film.fan@example.com" || !identity.EmailVerified {
+				t.Errorf("got identity %+v", identity)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Package password hashes and verifies user passwords. New hashes use the
+// configured algorithm, while hashes produced by any supported algorithm or
+// older parameters still verify and are reported by NeedsRehash so that
+// they can be upgraded on the next successful login.
+package password
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Supported algorithms.
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+// ErrUnsupportedHash is returned when verifying a hash of an unknown format.
+var ErrUnsupportedHash = errors.New("unsupported password hash")
+
+// Hasher hashes passwords and verifies them against stored hashes.
+type Hasher interface {
+	// Hash returns the encoded hash of password.
+	Hash(password string) (string, error)
+	// Verify reports whether password matches the encoded hash.
+	Verify(hash, password string) (bool, error)
+	// NeedsRehash reports whether hash was produced by another algorithm or
+	// with other parameters than the ones currently configured.
+	NeedsRehash(hash string) bool
+}
+
+// Config selects the algorithm and cost used for new hashes.
+type Config struct {
+	Algorithm  string
+	BcryptCost int
+	Argon2id   Argon2idParams
+}
+
+// DefaultConfig returns the configuration used when nothing is overridden.
+func DefaultConfig() Config {
+	return Config{
+		Algorithm:  AlgorithmArgon2id,
+		BcryptCost: bcrypt.DefaultCost,
+		Argon2id:   DefaultArgon2idParams,
+	}
+}
+
+// ConfigFromEnv reads PASSWORD_HASH_ALGORITHM, PASSWORD_BCRYPT_COST,
+// PASSWORD_ARGON2_MEMORY (KiB), PASSWORD_ARGON2_ITERATIONS and
+// PASSWORD_ARGON2_PARALLELISM, falling back to DefaultConfig.
+func ConfigFromEnv() (Config, error) {
+	cfg := DefaultConfig()
+	if v := os.Getenv("PASSWORD_HASH_ALGORITHM"); v != "" {
+		cfg.Algorithm = strings.ToLower(v)
+	}
+
+	ints := []struct {
+		name string
+		set  func(uint64)
+		bits int
+	}{
+		{"PASSWORD_BCRYPT_COST", func(n uint64) { cfg.BcryptCost = int(n) }, 8},
+		{"PASSWORD_ARGON2_MEMORY", func(n uint64) { cfg.Argon2id.Memory = uint32(n) }, 32},
+		{"PASSWORD_ARGON2_ITERATIONS", func(n uint64) { cfg.Argon2id.Iterations = uint32(n) }, 32},
+		{"PASSWORD_ARGON2_PARALLELISM", func(n uint64) { cfg.Argon2id.Parallelism = uint8(n) }, 8},
+	}
+	for _, i := range ints {
+		v := os.Getenv(i.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseUint(v, 10, i.bits)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid %s: %w", i.name, err)
+		}
+		i.set(n)
+	}
+
+	return cfg, nil
+}
+
+// New creates a hasher producing hashes as configured by cfg and verifying
+// hashes of every supported algorithm.
+func New(cfg Config) (Hasher, error) {
+	if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	if err := cfg.Argon2id.validate(); err != nil {
+		return nil, err
+	}
+
+	algorithms := []algorithm{
+		argon2idAlgorithm{cfg.Argon2id},
+		bcryptAlgorithm{cfg.BcryptCost},
+	}
+	for _, a := range algorithms {
+		if a.name() == cfg.Algorithm {
+			return &hasher{current: a, algorithms: algorithms}, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported password hash algorithm %q", cfg.Algorithm)
+}
+
+// algorithm is a single hashing scheme.
+type algorithm interface {
+	name() string
+	// owns reports whether the encoded hash belongs to this algorithm.
+	owns(hash string) bool
+	hash(password string) (string, error)
+	verify(hash, password string) (bool, error)
+	// outdated reports whether an owned hash uses other parameters than configured.
+	outdated(hash string) bool
+}
+
+type hasher struct {
+	current    algorithm
+	algorithms []algorithm
+}
+
+func (h *hasher) Hash(password string) (string, error) {
+	return h.current.hash(password)
+}
+
+func (h *hasher) Verify(hash, password string) (bool, error) {
+	for _, a := range h.algorithms {
+		if a.owns(hash) {
+			return a.verify(hash, password)
+		}
+	}
+	return false, ErrUnsupportedHash
+}
+
+func (h *hasher) NeedsRehash(hash string) bool {
+	return !h.current.owns(hash) || h.current.outdated(hash)
+}
@@ -0,0 +1,105 @@
+package password
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// cheapArgon2id keeps the tests fast.
+var cheapArgon2id = Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func newHasher(t *testing.T, algorithm string, bcryptCost int, argon Argon2idParams) Hasher {
+	t.Helper()
+	h, err := New(Config{Algorithm: algorithm, BcryptCost: bcryptCost, Argon2id: argon})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestVerify(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmArgon2id, AlgorithmBcrypt} {
+		t.Run(algorithm, func(t *testing.T) {
+			h := newHasher(t, algorithm, bcrypt.MinCost, cheapArgon2id)
+			hash, err := h.Hash("correct horse battery staple")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := h.Verify(hash, "correct horse battery staple"); !ok || err != nil {
+				t.Errorf("Verify of the right password = %v, %v", ok, err)
+			}
+			if ok, err := h.Verify(hash, "Tr0ub4dor&3"); ok || err != nil {
+				t.Errorf("Verify of a wrong password = %v, %v", ok, err)
+			}
+			if h.NeedsRehash(hash) {
+				t.Error("a fresh hash needs rehashing")
+			}
+		})
+	}
+}
+
+func TestVerifyUnsupportedHash(t *testing.T) {
+	h := newHasher(t, AlgorithmArgon2id, bcrypt.MinCost, cheapArgon2id)
+	if _, err := h.Verify("$1$salt$md5crypt", "password"); !errors.Is(err, ErrUnsupportedHash) {
+		t.Errorf("got error %v, want %v", err, ErrUnsupportedHash)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	stronger := cheapArgon2id
+	stronger.Iterations++
+
+	tests := []struct {
+		name string
+		// old produces the stored hash, current checks it.
+		old, current Hasher
+		want         bool
+	}{
+		{
+			name:    "same argon2id parameters",
+			old:     newHasher(t, AlgorithmArgon2id, bcrypt.MinCost, cheapArgon2id),
+			current: newHasher(t, AlgorithmArgon2id, bcrypt.MinCost, cheapArgon2id),
+		},
+		{
+			name:    "other argon2id parameters",
+			old:     newHasher(t, AlgorithmArgon2id, bcrypt.MinCost, cheapArgon2id),
+			current: newHasher(t, AlgorithmArgon2id, bcrypt.MinCost, stronger),
+			want:    true,
+		},
+		{
+			name:    "bcrypt when argon2id is configured",
+			old:     newHasher(t, AlgorithmBcrypt, bcrypt.MinCost, cheapArgon2id),
+			current: newHasher(t, AlgorithmArgon2id, bcrypt.MinCost, cheapArgon2id),
+			want:    true,
+		},
+		{
+			name:    "argon2id when bcrypt is configured",
+			old:     newHasher(t, AlgorithmArgon2id, bcrypt.MinCost, cheapArgon2id),
+			current: newHasher(t, AlgorithmBcrypt, bcrypt.MinCost, cheapArgon2id),
+			want:    true,
+		},
+		{
+			name:    "other bcrypt cost",
+			old:     newHasher(t, AlgorithmBcrypt, bcrypt.MinCost, cheapArgon2id),
+			current: newHasher(t, AlgorithmBcrypt, bcrypt.MinCost+1, cheapArgon2id),
+			want:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := tt.old.Hash("password")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tt.current.NeedsRehash(hash); got != tt.want {
+				t.Errorf("NeedsRehash = %v, want %v", got, tt.want)
+			}
+			// Outdated hashes still verify until they are upgraded.
+			if ok, err := tt.current.Verify(hash, "password"); !ok || err != nil {
+				t.Errorf("Verify = %v, %v", ok, err)
+			}
+		})
+	}
+}
@@ -1,5 +1,12 @@
 package repository
 
-import "errors"
+import "github.com/abhishek622/moviedock/pkg/apperr"
 
-var ErrNotFound = errors.New("not found")
+var ErrNotFound = apperr.New(apperr.NotFound, "not found")
+
+// ErrAlreadyExists is returned when a record violates a uniqueness constraint.
+var ErrAlreadyExists = apperr.New(apperr.AlreadyExists, "already exists")
+
+// ErrTokenRevoked is returned when a refresh token was revoked or already
+// rotated by a concurrent request.
+var ErrTokenRevoked = apperr.New(apperr.Conflict, "token revoked")
@@ -3,11 +3,15 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/abhishek622/moviedock/pkg/apperr"
+	"github.com/abhishek622/moviedock/pkg/pagination"
 	"github.com/abhishek622/moviedock/user/internal/repository"
 	"github.com/abhishek622/moviedock/user/pkg/model"
 	_ "github.com/jackc/pgx/v5/stdlib"
@@ -49,19 +53,10 @@ func New() (*Repository, error) {
 	return &Repository{db: db}, nil
 }
 
+// GetByEmail retrieves a user by email, ignoring case. Emails are stored
+// as typed but unique regardless of case.
 func (r *Repository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
-	var user model.User
-	var lastLogin, createdAt, updatedAt sql.NullTime
-	var metadata map[string]interface{}
-
-	query := `SELECT user_id, full_name, email, encrypted_password, role, is_active, timezone, 
-             last_login, metadata, created_at, updated_at FROM users WHERE email = $1`
-
-	err := r.db.QueryRowContext(ctx, query, email).Scan(
-		&user.UserID, &user.FullName, &user.Email, &user.EncryptedPassword, &user.Role,
-		&user.IsActive, &user.Timezone, &lastLogin, &metadata, &createdAt, &updatedAt,
-	)
-
+	user, err := scanUser(r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE lower(email) = lower($1)`, email))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, repository.ErrNotFound
@@ -69,54 +64,104 @@ func (r *Repository) GetByEmail(ctx context.Context, email string) (*model.User,
 		return nil, fmt.Errorf("error getting user by email: %w", err)
 	}
 
-	if lastLogin.Valid {
-		user.LastLogin = &lastLogin.Time
+	return user, nil
+}
+
+// userColumns lists the columns read by scanUser.
+const userColumns = `user_id, full_name, email, encrypted_password, role, is_active, is_verified,
+             verified_at, totp_enabled, totp_secret, totp_last_step, timezone, preferences, public_metadata,
+             private_metadata, last_login, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row rowScanner) (*model.User, error) {
+	var user model.User
+	var fullName, timezone, totpSecret sql.NullString
+	var verifiedAt, lastLogin sql.NullTime
+	var totpLastStep sql.NullInt64
+	var preferences, publicMetadata, privateMetadata []byte
+
+	err := row.Scan(
+		&user.UserID, &fullName, &user.Email, &user.EncryptedPassword, &user.Role, &user.IsActive,
+		&user.IsVerified, &verifiedAt, &user.TOTPEnabled, &totpSecret, &totpLastStep, &timezone,
+		&preferences, &publicMetadata, &privateMetadata, &lastLogin, &user.CreatedAt, &user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSONB columns are scanned as raw bytes and decoded here
+	if err := json.Unmarshal(preferences, &user.Preferences); err != nil {
+		return nil, fmt.Errorf("error decoding preferences: %w", err)
+	}
+	if err := json.Unmarshal(publicMetadata, &user.PublicMetadata); err != nil {
+		return nil, fmt.Errorf("error decoding public metadata: %w", err)
+	}
+	if err := json.Unmarshal(privateMetadata, &user.PrivateMetadata); err != nil {
+		return nil, fmt.Errorf("error decoding private metadata: %w", err)
+	}
+
+	user.FullName = fullName.String
+	user.TOTPSecret = totpSecret.String
+	if totpLastStep.Valid {
+		user.TOTPLastStep = &totpLastStep.Int64
 	}
-	if createdAt.Valid {
-		user.CreatedAt = createdAt.Time
+	if timezone.Valid {
+		user.Timezone = &timezone.String
 	}
-	if updatedAt.Valid {
-		user.UpdatedAt = updatedAt.Time
+	if verifiedAt.Valid {
+		user.VerifiedAt = &verifiedAt.Time
+	}
+	if lastLogin.Valid {
+		user.LastLogin = &lastLogin.Time
 	}
 
 	return &user, nil
 }
 
-func (r *Repository) GetUser(ctx context.Context, user_id string) (*model.UserResponse, error) {
-	var user model.UserResponse
+// GetByID retrieves a user by id.
+func (r *Repository) GetByID(ctx context.Context, id string) (*model.User, error) {
+	user, err := scanUser(r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE user_id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("error getting user by id: %w", apperr.FromPostgres(err))
+	}
 
-	err := r.db.QueryRowContext(ctx, `SELECT user_id, full_name, email, role FROM users WHERE user_id = $1`,
-		user_id).Scan(&user.UserId, &user.FullName, &user.Email, &user.Role)
+	return user, nil
+}
 
+// UpdateProfile applies the non-nil fields of update to a user.
+func (r *Repository) UpdateProfile(ctx context.Context, id string, update *model.ProfileUpdate) (*model.User, error) {
+	user, err := scanUser(r.db.QueryRowContext(ctx,
+		`UPDATE users
+         SET full_name = COALESCE($2, full_name),
+             timezone = COALESCE($3, timezone)
+         WHERE user_id = $1
+         RETURNING `+userColumns,
+		id, update.FullName, update.Timezone,
+	))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, repository.ErrNotFound
 		}
-		return nil, fmt.Errorf("error getting user by id: %w", err)
+		return nil, fmt.Errorf("error updating user: %w", apperr.FromPostgres(err))
 	}
 
-	return &user, nil
+	return user, nil
 }
 
-func (r *Repository) Put(ctx context.Context, id string, user *model.User) error {
-	_, err := r.db.ExecContext(ctx,
-		`INSERT INTO users (user_id, full_name, email, role, is_active, timezone, last_login, metadata, created_at, updated_at)
-         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-         ON CONFLICT (user_id) DO UPDATE
-           SET full_name = EXCLUDED.full_name,
-               email = EXCLUDED.email,
-               role = EXCLUDED.role,
-               is_active = EXCLUDED.is_active,
-               timezone = EXCLUDED.timezone,
-               last_login = EXCLUDED.last_login,
-               metadata = EXCLUDED.metadata,
-               updated_at = EXCLUDED.updated_at`,
-		id, user.FullName, user.Email, user.Role, user.IsActive, user.Timezone, user.LastLogin, user.Metadata, user.CreatedAt, user.UpdatedAt,
-	)
-	return err
+func (r *Repository) RegisterUser(ctx context.Context, user *model.User) (*model.User, error) {
+	if err := insertUser(ctx, r.db, user); err != nil {
+		return nil, err
+	}
+	return user, nil
 }
 
-func (r *Repository) RegisterUser(ctx context.Context, user *model.User) (*model.User, error) {
+func insertUser(ctx context.Context, q queryRower, user *model.User) error {
 	query := `
 		INSERT INTO users (
 			full_name, email, encrypted_password, role, is_active, timezone
@@ -124,7 +169,7 @@ func (r *Repository) RegisterUser(ctx context.Context, user *model.User) (*model
 		RETURNING user_id, created_at, updated_at
 	`
 
-	err := r.db.QueryRowContext(
+	err := q.QueryRowContext(
 		ctx,
 		query,
 		user.FullName,
@@ -136,44 +181,903 @@ func (r *Repository) RegisterUser(ctx context.Context, user *model.User) (*model
 	).Scan(&user.UserID, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
-		return nil, fmt.Errorf("error creating user: %w", err)
+		if apperr.Is(apperr.FromPostgres(err), apperr.AlreadyExists) {
+			return repository.ErrAlreadyExists
+		}
+		return fmt.Errorf("error creating user: %w", err)
 	}
 
-	return user, nil
+	return nil
 }
 
+// Delete removes a user together with their tokens.
 func (r *Repository) Delete(ctx context.Context, id string) error {
-	_, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE user_id = $1", id)
-	return err
+	res, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE user_id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// CreateToken stores a refresh token. A new family is started when
+// token.FamilyID is empty.
+func (r *Repository) CreateToken(ctx context.Context, token *model.UserToken) error {
+	return r.insertToken(ctx, r.db, token)
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
 }
 
-// LoginUser retrieves a user by email for login purposes
-func (r *Repository) LoginUser(ctx context.Context, user *model.User) (*model.User, error) {
+func (r *Repository) insertToken(ctx context.Context, q queryRower, token *model.UserToken) error {
 	query := `
-		SELECT user_id, full_name, email, encrypted_password, role, is_active, timezone, created_at, updated_at
-		FROM users
-		WHERE email = $1
+		INSERT INTO user_tokens (user_id, family_id, encrypted_token, expires_at)
+		VALUES ($1, COALESCE(NULLIF($2, '')::uuid, gen_random_uuid()), $3, $4)
+		RETURNING token_id, family_id, issued_at, created_at
 	`
 
-	var u model.User
-	err := r.db.QueryRowContext(ctx, query, user.Email).Scan(
-		&u.UserID,
-		&u.FullName,
-		&u.Email,
-		&u.EncryptedPassword,
-		&u.Role,
-		&u.IsActive,
-		&u.Timezone,
-		&u.CreatedAt,
-		&u.UpdatedAt,
+	err := q.QueryRowContext(ctx, query, token.UserID, token.FamilyID, token.EncryptedToken, token.ExpiresAt).
+		Scan(&token.TokenID, &token.FamilyID, &token.IssuedAt, &token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating token: %w", err)
+	}
+
+	return nil
+}
+
+// GetTokenByHash retrieves a refresh token by the hash of its value.
+func (r *Repository) GetTokenByHash(ctx context.Context, hash string) (*model.UserToken, error) {
+	var token model.UserToken
+	var replacedBy sql.NullString
+	var revokedAt sql.NullTime
+
+	query := `SELECT token_id, user_id, family_id, encrypted_token, issued_at, expires_at, revoked,
+             replaced_by, revoked_at, created_at FROM user_tokens WHERE encrypted_token = $1`
+
+	err := r.db.QueryRowContext(ctx, query, hash).Scan(
+		&token.TokenID, &token.UserID, &token.FamilyID, &token.EncryptedToken, &token.IssuedAt,
+		&token.ExpiresAt, &token.Revoked, &replacedBy, &revokedAt, &token.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("error getting token: %w", err)
+	}
+
+	if replacedBy.Valid {
+		token.ReplacedBy = &replacedBy.String
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+
+	return &token, nil
+}
+
+// RotateToken revokes the token identified by oldTokenID and stores next in
+// its place. It returns repository.ErrTokenRevoked if the old token has
+// already been revoked, which happens when two requests race on the same
+// refresh token.
+func (r *Repository) RotateToken(ctx context.Context, oldTokenID string, next *model.UserToken) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := r.insertToken(ctx, tx, next); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE user_tokens SET revoked = TRUE, revoked_at = now(), replaced_by = $2
+         WHERE token_id = $1 AND revoked = FALSE`,
+		oldTokenID, next.TokenID,
+	)
+	if err != nil {
+		return fmt.Errorf("error revoking token: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return repository.ErrTokenRevoked
+	}
+
+	return tx.Commit()
+}
+
+// RevokeTokenFamily revokes every token descending from the same login.
+func (r *Repository) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE user_tokens SET revoked = TRUE, revoked_at = now()
+         WHERE family_id = $1 AND revoked = FALSE`,
+		familyID,
+	)
+	return err
+}
+
+// CreateActionToken stores a single-use token, discarding any unused token
+// the user holds for the same purpose.
+func (r *Repository) CreateActionToken(ctx context.Context, token *model.ActionToken) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM user_action_tokens WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`,
+		token.UserID, token.Purpose,
+	)
+	if err != nil {
+		return fmt.Errorf("error discarding action tokens: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO user_action_tokens (user_id, purpose, token_hash, expires_at)
+         VALUES ($1, $2, $3, $4)
+         RETURNING token_id, created_at`,
+		token.UserID, token.Purpose, token.TokenHash, token.ExpiresAt,
+	).Scan(&token.TokenID, &token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating action token: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// VerifyEmail redeems an email verification token and marks its user as
+// verified. It returns repository.ErrNotFound if the token is unknown,
+// expired or already used.
+func (r *Repository) VerifyEmail(ctx context.Context, tokenHash string) (string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	userID, err := consumeActionToken(ctx, tx, tokenHash, model.TokenPurposeEmailVerification)
+	if err != nil {
+		return "", err
+	}
+
+	if err := markVerified(ctx, tx, userID); err != nil {
+		return "", err
+	}
+
+	return userID, tx.Commit()
+}
+
+// ResetPassword redeems a password reset token, replaces the password of its
+// user and revokes all of their refresh tokens. Receiving the token proves
+// ownership of the email, so the user is marked as verified as well.
+func (r *Repository) ResetPassword(ctx context.Context, tokenHash, encryptedPassword string) (string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	userID, err := consumeActionToken(ctx, tx, tokenHash, model.TokenPurposePasswordReset)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE users SET encrypted_password = $2 WHERE user_id = $1`, userID, encryptedPassword)
+	if err != nil {
+		return "", fmt.Errorf("error updating password: %w", err)
+	}
+
+	if err := markVerified(ctx, tx, userID); err != nil {
+		return "", err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE user_tokens SET revoked = TRUE, revoked_at = now()
+         WHERE user_id = $1 AND revoked = FALSE`,
+		userID,
 	)
+	if err != nil {
+		return "", fmt.Errorf("error revoking tokens: %w", err)
+	}
+
+	return userID, tx.Commit()
+}
 
+// consumeActionToken marks a valid token as used and returns its user.
+func consumeActionToken(ctx context.Context, tx *sql.Tx, tokenHash string, purpose model.TokenPurpose) (string, error) {
+	var userID string
+	err := tx.QueryRowContext(ctx,
+		`UPDATE user_action_tokens SET used_at = now()
+         WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > now()
+         RETURNING user_id`,
+		tokenHash, purpose,
+	).Scan(&userID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("user not found")
+			return "", repository.ErrNotFound
+		}
+		return "", fmt.Errorf("error redeeming action token: %w", err)
+	}
+	return userID, nil
+}
+
+func markVerified(ctx context.Context, tx *sql.Tx, userID string) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE users SET is_verified = TRUE, verified_at = COALESCE(verified_at, now()) WHERE user_id = $1`,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("error verifying user: %w", err)
+	}
+	return nil
+}
+
+// UpdatePassword replaces the password hash of a user.
+func (r *Repository) UpdatePassword(ctx context.Context, userID, encryptedPassword string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET encrypted_password = $2 WHERE user_id = $1`, userID, encryptedPassword)
+	return err
+}
+
+// RecordLogin sets the last login time of a user.
+func (r *Repository) RecordLogin(ctx context.Context, userID string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET last_login = now() WHERE user_id = $1`, userID)
+	return err
+}
+
+// LoginLockedUntil returns the latest time any of the keys is locked out
+// until, or the zero time if none is locked.
+func (r *Repository) LoginLockedUntil(ctx context.Context, keys ...string) (time.Time, error) {
+	var lockedUntil sql.NullTime
+	err := r.db.QueryRowContext(ctx,
+		`SELECT MAX(locked_until) FROM login_attempts WHERE attempt_key = ANY($1) AND locked_until > now()`,
+		keys,
+	).Scan(&lockedUntil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error checking login lockout: %w", err)
+	}
+	return lockedUntil.Time, nil
+}
+
+// RecordLoginFailure counts a failed login for key and returns the number of
+// failures since the key was last quiet for the given window.
+func (r *Repository) RecordLoginFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	var failures int
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO login_attempts (attempt_key, failures, last_failure_at)
+         VALUES ($1, 1, now())
+         ON CONFLICT (attempt_key) DO UPDATE
+           SET failures = CASE
+                 WHEN login_attempts.last_failure_at < now() - make_interval(secs => $2) THEN 1
+                 ELSE login_attempts.failures + 1
+               END,
+               last_failure_at = now()
+         RETURNING failures`,
+		key, window.Seconds(),
+	).Scan(&failures)
+	if err != nil {
+		return 0, fmt.Errorf("error recording login failure: %w", err)
+	}
+	return failures, nil
+}
+
+// LockLogin locks key out until the given time.
+func (r *Repository) LockLogin(ctx context.Context, key string, until time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE login_attempts SET locked_until = $2 WHERE attempt_key = $1`, key, until)
+	return err
+}
+
+// ClearLoginFailures forgets the failed logins of key.
+func (r *Repository) ClearLoginFailures(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM login_attempts WHERE attempt_key = $1`, key)
+	return err
+}
+
+// CreateAuditEvent stores an audit event.
+func (r *Repository) CreateAuditEvent(ctx context.Context, event *model.AuditEvent) error {
+	details, err := json.Marshal(event.Details)
+	if err != nil {
+		return err
+	}
+	if event.Details == nil {
+		details = []byte("{}")
+	}
+
+	err = r.db.QueryRowContext(ctx,
+		`INSERT INTO audit_events (event_type, user_id, ip, details)
+         VALUES ($1, $2, NULLIF($3, ''), $4)
+         RETURNING event_id, created_at`,
+		event.Type, event.UserID, event.IP, details,
+	).Scan(&event.EventID, &event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating audit event: %w", err)
+	}
+	return nil
+}
+
+// userSorts maps the orders of user listings to their sort expressions,
+// which end with a unique column.
+var userSorts = map[string][]string{
+	"created_at": {"created_at", "user_id"},
+	"email":      {"email"},
+}
+
+// userKey returns the values of the sort expressions of an order for u.
+func userKey(sort string) func(*model.User) []any {
+	if sort == "email" {
+		return func(u *model.User) []any { return []any{u.Email} }
+	}
+	return func(u *model.User) []any { return []any{u.CreatedAt, u.UserID} }
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns and the backslash
+// escaping them.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike returns s as a LIKE pattern matching s literally.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// ListUsers returns the page of users matching filter it asks for,
+// together with the total number of matches.
+func (r *Repository) ListUsers(ctx context.Context, filter model.UserFilter) ([]*model.User, pagination.Info, int, error) {
+	exprs, ok := userSorts[filter.Page.Sort]
+	if !ok {
+		return nil, pagination.Info{}, 0, pagination.ErrInvalidSort
+	}
+
+	var where []string
+	var args []any
+	if filter.Query != "" {
+		args = append(args, "%"+escapeLike(filter.Query)+"%")
+		where = append(where, fmt.Sprintf("(email ILIKE $%d OR full_name ILIKE $%d)", len(args), len(args)))
+	}
+	if filter.Role != "" {
+		args = append(args, filter.Role)
+		where = append(where, fmt.Sprintf("role = $%d", len(args)))
+	}
+	if filter.IsActive != nil {
+		args = append(args, *filter.IsActive)
+		where = append(where, fmt.Sprintf("is_active = $%d", len(args)))
+	}
+
+	countQuery := `SELECT count(*) FROM users`
+	if len(where) > 0 {
+		countQuery += " WHERE " + strings.Join(where, " AND ")
+	}
+	var total int
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, pagination.Info{}, 0, fmt.Errorf("error counting users: %w", err)
+	}
+
+	cond, orderBy := filter.Page.SQL(exprs, len(args)+1)
+	if cond != "" {
+		values, err := filter.Page.Values()
+		if err != nil {
+			return nil, pagination.Info{}, 0, err
+		}
+		if len(values) != len(exprs) {
+			return nil, pagination.Info{}, 0, pagination.ErrInvalidCursor
+		}
+		args = append(args, values...)
+		where = append(where, cond)
+	}
+
+	query := `SELECT ` + userColumns + ` FROM users`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, filter.Page.Limit+1)
+	query += fmt.Sprintf(" ORDER BY %s LIMIT $%d", orderBy, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, pagination.Info{}, 0, fmt.Errorf("error listing users: %w", apperr.FromPostgres(err))
+	}
+	defer rows.Close()
+
+	var users []*model.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, pagination.Info{}, 0, err
 		}
-		return nil, fmt.Errorf("error retrieving user: %w", err)
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, pagination.Info{}, 0, err
 	}
 
-	return &u, nil
+	users, info, err := pagination.Page(filter.Page, users, userKey(filter.Page.Sort))
+	if err != nil {
+		return nil, pagination.Info{}, 0, err
+	}
+	return users, info, total, nil
+}
+
+// UpdateRole changes the role of a user.
+func (r *Repository) UpdateRole(ctx context.Context, id string, role model.Role) (*model.User, error) {
+	return r.updateUser(ctx, `UPDATE users SET role = $2 WHERE user_id = $1 RETURNING `+userColumns, id, role)
+}
+
+// SetActive activates or deactivates a user.
+func (r *Repository) SetActive(ctx context.Context, id string, active bool) (*model.User, error) {
+	return r.updateUser(ctx, `UPDATE users SET is_active = $2 WHERE user_id = $1 RETURNING `+userColumns, id, active)
+}
+
+// UpdatePreferences replaces the preferences of a user.
+func (r *Repository) UpdatePreferences(ctx context.Context, id string, preferences *model.Preferences) (*model.User, error) {
+	return r.updateJSON(ctx, "preferences", id, preferences)
+}
+
+// UpdatePublicMetadata replaces the public metadata of a user.
+func (r *Repository) UpdatePublicMetadata(ctx context.Context, id string, metadata model.Metadata) (*model.User, error) {
+	return r.updateJSON(ctx, "public_metadata", id, metadata)
+}
+
+// UpdatePrivateMetadata replaces the private metadata of a user.
+func (r *Repository) UpdatePrivateMetadata(ctx context.Context, id string, metadata model.Metadata) (*model.User, error) {
+	return r.updateJSON(ctx, "private_metadata", id, metadata)
+}
+
+// updateJSON replaces a JSONB column of a user. column must be a constant.
+func (r *Repository) updateJSON(ctx context.Context, column, id string, value any) (*model.User, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return r.updateUser(ctx, `UPDATE users SET `+column+` = $2 WHERE user_id = $1 RETURNING `+userColumns, id, b)
+}
+
+func (r *Repository) updateUser(ctx context.Context, query string, args ...any) (*model.User, error) {
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("error updating user: %w", apperr.FromPostgres(err))
+	}
+	return user, nil
+}
+
+// RevokeUserTokens revokes every refresh token of a user, ending all of
+// their sessions.
+func (r *Repository) RevokeUserTokens(ctx context.Context, userID string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE user_tokens SET revoked = TRUE, revoked_at = now()
+         WHERE user_id = $1 AND revoked = FALSE`,
+		userID,
+	)
+	return err
+}
+
+// GetActionToken retrieves a token that is neither used nor expired.
+func (r *Repository) GetActionToken(ctx context.Context, tokenHash string, purpose model.TokenPurpose) (*model.ActionToken, error) {
+	token := model.ActionToken{TokenHash: tokenHash, Purpose: purpose}
+	err := r.db.QueryRowContext(ctx,
+		`SELECT token_id, user_id, expires_at, created_at FROM user_action_tokens
+         WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > now()`,
+		tokenHash, purpose,
+	).Scan(&token.TokenID, &token.UserID, &token.ExpiresAt, &token.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("error getting action token: %w", err)
+	}
+	return &token, nil
+}
+
+// ConsumeActionToken marks a token as used. It returns repository.ErrNotFound
+// if the token is unknown, expired or already used.
+func (r *Repository) ConsumeActionToken(ctx context.Context, tokenHash string, purpose model.TokenPurpose) (string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	userID, err := consumeActionToken(ctx, tx, tokenHash, purpose)
+	if err != nil {
+		return "", err
+	}
+	return userID, tx.Commit()
+}
+
+// SetTOTPSecret stores a secret pending confirmation. Two-factor
+// authentication stays disabled until EnableTOTP is called.
+func (r *Repository) SetTOTPSecret(ctx context.Context, userID, secret string) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE users SET totp_secret = $2, totp_enabled = FALSE, totp_last_step = NULL
+         WHERE user_id = $1 AND totp_enabled = FALSE`,
+		userID, secret,
+	)
+	if err != nil {
+		return fmt.Errorf("error setting totp secret: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return repository.ErrAlreadyExists
+	}
+	return nil
+}
+
+// UseTOTPStep records the time step of an accepted code. It returns
+// repository.ErrTokenRevoked if the step, or a later one, was already used.
+func (r *Repository) UseTOTPStep(ctx context.Context, userID string, step int64) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE users SET totp_last_step = $2
+         WHERE user_id = $1 AND (totp_last_step IS NULL OR totp_last_step < $2)`,
+		userID, step,
+	)
+	if err != nil {
+		return fmt.Errorf("error recording totp step: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return repository.ErrTokenRevoked
+	}
+	return nil
+}
+
+// EnableTOTP turns on two-factor authentication and replaces the recovery
+// codes of a user.
+func (r *Repository) EnableTOTP(ctx context.Context, userID string, recoveryCodeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET totp_enabled = TRUE WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("error enabling totp: %w", err)
+	}
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DisableTOTP turns off two-factor authentication and removes the secret
+// and recovery codes of a user.
+func (r *Repository) DisableTOTP(ctx context.Context, userID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE users SET totp_enabled = FALSE, totp_secret = NULL, totp_last_step = NULL WHERE user_id = $1`,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("error disabling totp: %w", err)
+	}
+	if err := replaceRecoveryCodes(ctx, tx, userID, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReplaceRecoveryCodes invalidates the recovery codes of a user and stores new ones.
+func (r *Repository) ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID string, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("error deleting recovery codes: %w", err)
+	}
+	for _, hash := range codeHashes {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`,
+			userID, hash,
+		)
+		if err != nil {
+			return fmt.Errorf("error creating recovery code: %w", err)
+		}
+	}
+	return nil
+}
+
+// UseRecoveryCode marks a recovery code as used. It returns
+// repository.ErrNotFound if the user has no such unused code.
+func (r *Repository) UseRecoveryCode(ctx context.Context, userID, codeHash string) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE user_recovery_codes SET used_at = now()
+         WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`,
+		userID, codeHash,
+	)
+	if err != nil {
+		return fmt.Errorf("error using recovery code: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// apiKeyColumns lists the columns read by scanAPIKey. Scopes are read as
+// JSON since database/sql cannot scan Postgres arrays.
+const apiKeyColumns = `key_id, user_id, name, prefix, key_hash, array_to_json(scopes), expires_at, last_used_at,
+             revoked_at, created_at`
+
+func scanAPIKey(row rowScanner) (*model.APIKey, error) {
+	var key model.APIKey
+	var scopes []byte
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+
+	err := row.Scan(
+		&key.KeyID, &key.UserID, &key.Name, &key.Prefix, &key.KeyHash, &scopes,
+		&expiresAt, &lastUsedAt, &revokedAt, &key.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(scopes, &key.Scopes); err != nil {
+		return nil, fmt.Errorf("error decoding api key scopes: %w", err)
+	}
+
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return &key, nil
+}
+
+// CreateAPIKey stores a new API key.
+func (r *Repository) CreateAPIKey(ctx context.Context, key *model.APIKey) error {
+	if key.Scopes == nil {
+		key.Scopes = []string{}
+	}
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+         VALUES ($1, $2, $3, $4, $5, $6)
+         RETURNING key_id, created_at`,
+		key.UserID, key.Name, key.Prefix, key.KeyHash, key.Scopes, key.ExpiresAt,
+	).Scan(&key.KeyID, &key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating api key: %w", err)
+	}
+	return nil
+}
+
+// ListAPIKeys returns the API keys of a user, newest first.
+func (r *Repository) ListAPIKeys(ctx context.Context, userID string) ([]*model.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error listing api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []*model.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// GetAPIKeyByHash retrieves an API key by the hash of its value.
+func (r *Repository) GetAPIKeyByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1`, hash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("error getting api key: %w", err)
+	}
+	return key, nil
+}
+
+// RevokeAPIKey revokes an API key of a user. It returns
+// repository.ErrNotFound if the user has no such active key.
+func (r *Repository) RevokeAPIKey(ctx context.Context, userID, keyID string) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE api_keys SET revoked_at = now() WHERE key_id = $1 AND user_id = $2 AND revoked_at IS NULL`,
+		keyID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("error revoking api key: %w", apperr.FromPostgres(err))
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// TouchAPIKey records the use of an API key. To avoid a write on every
+// request the time is only updated once per interval.
+func (r *Repository) TouchAPIKey(ctx context.Context, keyID string, interval time.Duration) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE api_keys SET last_used_at = now()
+         WHERE key_id = $1 AND (last_used_at IS NULL OR last_used_at < now() - make_interval(secs => $2))`,
+		keyID, interval.Seconds(),
+	)
+	return err
+}
+
+// CreateOIDCLoginState stores the state of a login with an OpenID Connect
+// provider, discarding expired ones.
+func (r *Repository) CreateOIDCLoginState(ctx context.Context, state *model.OIDCLoginState) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM oidc_login_states WHERE expires_at < now()`); err != nil {
+		return fmt.Errorf("error discarding oidc login states: %w", err)
+	}
+
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO oidc_login_states (state_hash, provider, nonce, code_verifier, user_id, expires_at)
+         VALUES ($1, $2, $3, $4, $5, $6)
+         RETURNING created_at`,
+		state.StateHash, state.Provider, state.Nonce, state.CodeVerifier, state.UserID, state.ExpiresAt,
+	).Scan(&state.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating oidc login state: %w", err)
+	}
+	return nil
+}
+
+// ConsumeOIDCLoginState removes and returns an unexpired login state. It
+// returns repository.ErrNotFound if there is none for the provider.
+func (r *Repository) ConsumeOIDCLoginState(ctx context.Context, stateHash, provider string) (*model.OIDCLoginState, error) {
+	var state model.OIDCLoginState
+	var userID sql.NullString
+	err := r.db.QueryRowContext(ctx,
+		`DELETE FROM oidc_login_states
+         WHERE state_hash = $1 AND provider = $2 AND expires_at > now()
+         RETURNING state_hash, provider, nonce, code_verifier, user_id, expires_at, created_at`,
+		stateHash, provider,
+	).Scan(&state.StateHash, &state.Provider, &state.Nonce, &state.CodeVerifier, &userID, &state.ExpiresAt, &state.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("error redeeming oidc login state: %w", err)
+	}
+
+	if userID.Valid {
+		state.UserID = &userID.String
+	}
+	return &state, nil
+}
+
+// GetByIdentity retrieves the user an external identity is linked to.
+func (r *Repository) GetByIdentity(ctx context.Context, provider, subject string) (*model.User, error) {
+	user, err := scanUser(r.db.QueryRowContext(ctx,
+		`SELECT `+userColumns+` FROM users
+         WHERE user_id = (SELECT user_id FROM user_identities WHERE provider = $1 AND subject = $2)`,
+		provider, subject,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("error getting user by identity: %w", err)
+	}
+
+	return user, nil
+}
+
+// RegisterUserWithIdentity creates a user together with their first linked
+// identity, marking the user verified if the provider vouched for the email.
+func (r *Repository) RegisterUserWithIdentity(ctx context.Context, user *model.User, identity *model.UserIdentity, verified bool) (*model.User, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := insertUser(ctx, tx, user); err != nil {
+		return nil, err
+	}
+	identity.UserID = user.UserID
+	if err := insertIdentity(ctx, tx, identity); err != nil {
+		return nil, err
+	}
+	if verified {
+		if err := markVerified(ctx, tx, user.UserID); err != nil {
+			return nil, err
+		}
+		user.IsVerified = true
+	}
+
+	return user, tx.Commit()
+}
+
+// LinkIdentity links an external identity to an existing user. It returns
+// repository.ErrAlreadyExists if the identity is linked to a user already
+// or the user has an identity at the provider.
+func (r *Repository) LinkIdentity(ctx context.Context, identity *model.UserIdentity) error {
+	return insertIdentity(ctx, r.db, identity)
+}
+
+func insertIdentity(ctx context.Context, q queryRower, identity *model.UserIdentity) error {
+	err := q.QueryRowContext(ctx,
+		`INSERT INTO user_identities (user_id, provider, subject, email)
+         VALUES ($1, $2, $3, NULLIF($4, ''))
+         RETURNING identity_id, created_at`,
+		identity.UserID, identity.Provider, identity.Subject, identity.Email,
+	).Scan(&identity.IdentityID, &identity.CreatedAt)
+	if err != nil {
+		if apperr.Is(apperr.FromPostgres(err), apperr.AlreadyExists) {
+			return repository.ErrAlreadyExists
+		}
+		return fmt.Errorf("error linking identity: %w", err)
+	}
+	return nil
+}
+
+// ListIdentities returns the identities linked to a user.
+func (r *Repository) ListIdentities(ctx context.Context, userID string) ([]*model.UserIdentity, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT identity_id, user_id, provider, subject, COALESCE(email, ''), created_at
+         FROM user_identities WHERE user_id = $1 ORDER BY created_at`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error listing identities: %w", err)
+	}
+	defer rows.Close()
+
+	identities := []*model.UserIdentity{}
+	for rows.Next() {
+		var identity model.UserIdentity
+		err := rows.Scan(&identity.IdentityID, &identity.UserID, &identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, &identity)
+	}
+	return identities, rows.Err()
+}
+
+// DeleteIdentity unlinks an identity from a user.
+func (r *Repository) DeleteIdentity(ctx context.Context, userID, identityID string) error {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM user_identities WHERE identity_id = $1 AND user_id = $2`,
+		identityID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("error unlinking identity: %w", apperr.FromPostgres(err))
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
 }
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_user_tokens_family_id;
+DROP INDEX IF EXISTS idx_user_tokens_encrypted_token;
+CREATE INDEX IF NOT EXISTS idx_user_tokens_encrypted_token ON user_tokens (encrypted_token);
+
+ALTER TABLE user_tokens
+  DROP COLUMN IF EXISTS revoked_at,
+  DROP COLUMN IF EXISTS replaced_by,
+  DROP COLUMN IF EXISTS family_id;
@@ -0,0 +1,10 @@
+-- refresh tokens are rotated on every use; all tokens descending from one login
+-- share a family so that replaying a rotated token can revoke the whole chain
+ALTER TABLE user_tokens
+  ADD COLUMN IF NOT EXISTS family_id UUID NOT NULL DEFAULT gen_random_uuid(),
+  ADD COLUMN IF NOT EXISTS replaced_by UUID REFERENCES user_tokens(token_id) ON DELETE SET NULL,
+  ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMPTZ;
+
+DROP INDEX IF EXISTS idx_user_tokens_encrypted_token;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_tokens_encrypted_token ON user_tokens (encrypted_token);
+CREATE INDEX IF NOT EXISTS idx_user_tokens_family_id ON user_tokens (family_id);
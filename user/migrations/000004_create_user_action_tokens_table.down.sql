@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS user_action_tokens;
+
+ALTER TABLE users
+  DROP COLUMN IF EXISTS verified_at,
+  DROP COLUMN IF EXISTS is_verified;
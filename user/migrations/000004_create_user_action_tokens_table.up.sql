@@ -0,0 +1,17 @@
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS is_verified BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN IF NOT EXISTS verified_at TIMESTAMPTZ;
+
+-- single-use tokens mailed to users for password resets and email verification;
+-- only the sha256 of the token is stored
+CREATE TABLE IF NOT EXISTS user_action_tokens (
+  token_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+  purpose TEXT NOT NULL CHECK (purpose IN ('password_reset', 'email_verification')),
+  token_hash TEXT NOT NULL UNIQUE,
+  expires_at TIMESTAMPTZ NOT NULL,
+  used_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_action_tokens_user_purpose ON user_action_tokens (user_id, purpose);
@@ -0,0 +1,21 @@
+-- failed logins are counted per email and per client IP; a key is locked out
+-- once it exceeds its allowance and the counter is forgotten after a quiet period
+CREATE TABLE IF NOT EXISTS login_attempts (
+  attempt_key TEXT PRIMARY KEY,          -- "email:<address>" or "ip:<address>"
+  failures INTEGER NOT NULL DEFAULT 0,
+  last_failure_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  locked_until TIMESTAMPTZ
+);
+
+-- security relevant events such as lockouts
+CREATE TABLE IF NOT EXISTS audit_events (
+  event_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  event_type TEXT NOT NULL,
+  user_id UUID REFERENCES users(user_id) ON DELETE SET NULL,
+  ip TEXT,
+  details JSONB NOT NULL DEFAULT '{}'::jsonb,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_user_id ON audit_events (user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events (created_at);
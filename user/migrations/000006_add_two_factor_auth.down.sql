@@ -0,0 +1,11 @@
+DELETE FROM user_action_tokens WHERE purpose = 'mfa_challenge';
+ALTER TABLE user_action_tokens DROP CONSTRAINT IF EXISTS user_action_tokens_purpose_check;
+ALTER TABLE user_action_tokens ADD CONSTRAINT user_action_tokens_purpose_check
+  CHECK (purpose IN ('password_reset', 'email_verification'));
+
+DROP TABLE IF EXISTS user_recovery_codes;
+
+ALTER TABLE users
+  DROP COLUMN IF EXISTS totp_last_step,
+  DROP COLUMN IF EXISTS totp_enabled,
+  DROP COLUMN IF EXISTS totp_secret;
@@ -0,0 +1,22 @@
+-- TOTP secret is set on enrollment and only used once totp_enabled is true;
+-- totp_last_step is the time step of the last accepted code, to prevent replays
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS totp_secret TEXT,
+  ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN IF NOT EXISTS totp_last_step BIGINT;
+
+-- single-use codes for when the authenticator is lost; only the sha256 is stored
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+  code_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+  code_hash TEXT NOT NULL,
+  used_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON user_recovery_codes (user_id);
+
+-- logins of users with two-factor authentication are completed with a challenge token
+ALTER TABLE user_action_tokens DROP CONSTRAINT IF EXISTS user_action_tokens_purpose_check;
+ALTER TABLE user_action_tokens ADD CONSTRAINT user_action_tokens_purpose_check
+  CHECK (purpose IN ('password_reset', 'email_verification', 'mfa_challenge'));
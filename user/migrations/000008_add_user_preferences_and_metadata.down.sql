@@ -0,0 +1,5 @@
+ALTER TABLE users
+  DROP COLUMN IF EXISTS preferences,
+  DROP COLUMN IF EXISTS public_metadata,
+  ALTER COLUMN private_metadata DROP NOT NULL;
+ALTER TABLE users RENAME COLUMN private_metadata TO metadata;
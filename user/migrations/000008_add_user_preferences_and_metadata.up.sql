@@ -0,0 +1,8 @@
+-- metadata is split by audience: preferences and public_metadata are managed
+-- by the user, private_metadata only by the service and admins
+ALTER TABLE users RENAME COLUMN metadata TO private_metadata;
+UPDATE users SET private_metadata = '{}'::jsonb WHERE private_metadata IS NULL;
+ALTER TABLE users
+  ALTER COLUMN private_metadata SET NOT NULL,
+  ADD COLUMN IF NOT EXISTS public_metadata JSONB NOT NULL DEFAULT '{}'::jsonb,
+  ADD COLUMN IF NOT EXISTS preferences JSONB NOT NULL DEFAULT '{}'::jsonb;
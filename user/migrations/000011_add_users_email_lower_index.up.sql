@@ -0,0 +1,4 @@
+-- emails are matched case-insensitively, so they must be unique regardless
+-- of case; accounts differing only in the case of their email have to be
+-- merged before this migration
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users (lower(email));
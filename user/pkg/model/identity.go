@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// UserIdentity links a user to their account at an OpenID Connect provider.
+type UserIdentity struct {
+	IdentityID string    `json:"identity_id" db:"identity_id"` // UUID
+	UserID     string    `json:"user_id" db:"user_id"`         // UUID
+	Provider   string    `json:"provider" db:"provider"`
+	Subject    string    `json:"-" db:"subject"` // sub claim, unique per provider
+	Email      string    `json:"email,omitempty" db:"email"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// OIDCLoginState is kept between sending a user to a provider and their
+// return. A set UserID links the identity to that user instead of logging in.
+type OIDCLoginState struct {
+	StateHash    string    `json:"-" db:"state_hash"` // sha256 of the state sent to the provider
+	Provider     string    `json:"provider" db:"provider"`
+	Nonce        string    `json:"-" db:"nonce"`
+	CodeVerifier string    `json:"-" db:"code_verifier"`
+	UserID       *string   `json:"user_id,omitempty" db:"user_id"` // UUID
+	ExpiresAt    time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// OIDCAuthorization is where to send the user to log in with a provider.
+type OIDCAuthorization struct {
+	AuthorizationURL string `json:"authorization_url"`
+	// State is the state carried by the URL, which the handler binds to
+	// the browser starting the login.
+	State string `json:"-"`
+}
+
+// OIDCCallbackRequest carries the parameters a provider returned the user with.
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
+
+// OIDCProviders lists the providers users can log in with.
+type OIDCProviders struct {
+	Providers []string `json:"providers"`
+}
@@ -0,0 +1,28 @@
+package model
+
+// Preferences are the settings a user chooses for themselves. They are only
+// visible to the user.
+type Preferences struct {
+	FavoriteGenres []string       `json:"favorite_genres" validate:"max=20,dive,min=1,max=50"`
+	Locale         string         `json:"locale,omitempty" validate:"omitempty,bcp47_language_tag"`
+	ContentFilters ContentFilters `json:"content_filters"`
+}
+
+// ContentFilters restrict which titles are shown to a user.
+type ContentFilters struct {
+	HideAdult    bool     `json:"hide_adult"`
+	MaxAgeRating string   `json:"max_age_rating,omitempty" validate:"omitempty,oneof=G PG PG-13 R NC-17"`
+	HiddenGenres []string `json:"hidden_genres" validate:"max=20,dive,min=1,max=50"`
+}
+
+// Metadata is free-form JSON attached to a user. Public metadata is shown
+// on the user's public profile; private metadata is never returned to the
+// user and can only be changed by admins.
+type Metadata map[string]any
+
+// PublicProfile is the view of a user visible to everyone.
+type PublicProfile struct {
+	UserID         string   `json:"user_id"`
+	FullName       string   `json:"full_name"`
+	PublicMetadata Metadata `json:"public_metadata"`
+}
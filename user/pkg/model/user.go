@@ -1,6 +1,10 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"github.com/abhishek622/moviedock/pkg/pagination"
+)
 
 type Role string
 
@@ -11,27 +15,169 @@ const (
 )
 
 type User struct {
-	UserID            string                 `json:"user_id" db:"user_id"` // UUID
-	Email             string                 `json:"email" db:"email"`
-	EncryptedPassword string                 `json:"password" db:"encrypted_password"` // omit from JSON responses
-	FullName          string                 `json:"full_name,omitempty" db:"full_name"`
-	Role              Role                   `json:"role" db:"role"`
-	IsActive          bool                   `json:"is_active" db:"is_active"`
-	Timezone          *string                `json:"timezone,omitempty" db:"timezone"`
-	Metadata          map[string]interface{} `json:"metadata,omitempty" db:"metadata"` // JSONB
-	LastLogin         *time.Time             `json:"last_login,omitempty" db:"last_login"`
-	CreatedAt         time.Time              `json:"created_at" db:"created_at"`
-	UpdatedAt         time.Time              `json:"updated_at" db:"updated_at"`
+	UserID            string      `json:"user_id" db:"user_id"` // UUID
+	Email             string      `json:"email" db:"email"`
+	EncryptedPassword string      `json:"password" db:"encrypted_password"` // omit from JSON responses
+	FullName          string      `json:"full_name,omitempty" db:"full_name"`
+	Role              Role        `json:"role" db:"role"`
+	IsActive          bool        `json:"is_active" db:"is_active"`
+	IsVerified        bool        `json:"is_verified" db:"is_verified"`
+	VerifiedAt        *time.Time  `json:"verified_at,omitempty" db:"verified_at"`
+	TOTPEnabled       bool        `json:"totp_enabled" db:"totp_enabled"`
+	TOTPSecret        string      `json:"-" db:"totp_secret"`
+	TOTPLastStep      *int64      `json:"-" db:"totp_last_step"`
+	Timezone          *string     `json:"timezone,omitempty" db:"timezone"`
+	Preferences       Preferences `json:"preferences" db:"preferences"`                   // JSONB
+	PublicMetadata    Metadata    `json:"public_metadata,omitempty" db:"public_metadata"` // JSONB
+	PrivateMetadata   Metadata    `json:"-" db:"private_metadata"`                        // JSONB
+	LastLogin         *time.Time  `json:"last_login,omitempty" db:"last_login"`
+	CreatedAt         time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time   `json:"updated_at" db:"updated_at"`
 }
 
 type UserToken struct {
-	TokenID        string    `json:"token_id" db:"token_id"` // UUID
-	UserID         string    `json:"user_id" db:"user_id"`   // UUID
-	EncryptedToken string    `json:"-" db:"encrypted_token"` // omit from JSON responses
-	IssuedAt       time.Time `json:"issued_at" db:"issued_at"`
-	ExpiresAt      time.Time `json:"expires_at" db:"expires_at"`
-	Revoked        bool      `json:"revoked" db:"revoked"`
-	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	TokenID        string     `json:"token_id" db:"token_id"` // UUID
+	UserID         string     `json:"user_id" db:"user_id"`   // UUID
+	FamilyID       string     `json:"family_id" db:"family_id"`
+	EncryptedToken string     `json:"-" db:"encrypted_token"` // sha256 of the opaque refresh token
+	IssuedAt       time.Time  `json:"issued_at" db:"issued_at"`
+	ExpiresAt      time.Time  `json:"expires_at" db:"expires_at"`
+	Revoked        bool       `json:"revoked" db:"revoked"`
+	ReplacedBy     *string    `json:"replaced_by,omitempty" db:"replaced_by"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// TokenPair is a short-lived access token together with the opaque refresh
+// token that can be exchanged for the next pair.
+type TokenPair struct {
+	AccessToken      string    `json:"token"`
+	RefreshToken     string    `json:"refresh_token"`
+	AccessExpiresAt  time.Time `json:"expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// TokenPurpose is what a single-use action token may be redeemed for.
+type TokenPurpose string
+
+const (
+	TokenPurposePasswordReset     TokenPurpose = "password_reset"
+	TokenPurposeEmailVerification TokenPurpose = "email_verification"
+	TokenPurposeMFAChallenge      TokenPurpose = "mfa_challenge"
+)
+
+// ActionToken is a single-use token sent to a user by email.
+type ActionToken struct {
+	TokenID   string       `json:"token_id" db:"token_id"` // UUID
+	UserID    string       `json:"user_id" db:"user_id"`   // UUID
+	Purpose   TokenPurpose `json:"purpose" db:"purpose"`
+	TokenHash string       `json:"-" db:"token_hash"` // sha256 of the token sent to the user
+	ExpiresAt time.Time    `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time   `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+}
+
+// AuditEventType identifies a security relevant event.
+type AuditEventType string
+
+const (
+	AuditEventLoginLockout    AuditEventType = "login.lockout"
+	AuditEventRoleChanged     AuditEventType = "user.role_changed"
+	AuditEventUserActivated   AuditEventType = "user.activated"
+	AuditEventUserDeactivated AuditEventType = "user.deactivated"
+	AuditEventForcedLogout    AuditEventType = "user.forced_logout"
+	AuditEventTOTPEnabled     AuditEventType = "user.totp_enabled"
+	AuditEventTOTPDisabled    AuditEventType = "user.totp_disabled"
+	AuditEventRecoveryCode    AuditEventType = "user.recovery_code_used"
+	AuditEventIdentityLinked  AuditEventType = "user.identity_linked"
+	AuditEventIdentityRemoved AuditEventType = "user.identity_unlinked"
+)
+
+// AuditEvent records a security relevant event.
+type AuditEvent struct {
+	EventID   string         `json:"event_id" db:"event_id"` // UUID
+	Type      AuditEventType `json:"event_type" db:"event_type"`
+	UserID    *string        `json:"user_id,omitempty" db:"user_id"` // UUID
+	IP        string         `json:"ip,omitempty" db:"ip"`
+	Details   map[string]any `json:"details,omitempty" db:"details"` // JSONB
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// TOTPEnrollment is returned when a user starts enrolling an authenticator.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// RecoveryCodes are shown to the user once; only their hashes are stored.
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// LoginChallenge is returned by login instead of tokens when the user has
+// two-factor authentication enabled.
+type LoginChallenge struct {
+	MFARequired    bool      `json:"mfa_required"`
+	ChallengeToken string    `json:"challenge_token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+// MFALoginRequest completes a login with either a TOTP code or a recovery code.
+type MFALoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required_without=RecoveryCode"`
+	RecoveryCode   string `json:"recovery_code" validate:"required_without=Code"`
+}
+
+// APIKey is a personal API key. The key itself is only returned on creation.
+type APIKey struct {
+	KeyID      string     `json:"key_id" db:"key_id"`   // UUID
+	UserID     string     `json:"user_id" db:"user_id"` // UUID
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	KeyHash    string     `json:"-" db:"key_hash"` // sha256 of the key
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreatedAPIKey is returned once when a key is created.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"omitempty,dive,required"`
+	ExpiresInDays *int     `json:"expires_in_days,omitempty" validate:"omitempty,min=1,max=365"`
+}
+
+type IntrospectAPIKeyRequest struct {
+	APIKey string `json:"api_key" validate:"required"`
 }
 
 type UserLogin struct {
@@ -39,6 +185,74 @@ type UserLogin struct {
 	Password string `json:"password" validate:"required,min=6"`
 }
 
+// UserProfile is the view of a user returned to the user themselves.
+type UserProfile struct {
+	UserID         string     `json:"user_id"`
+	Email          string     `json:"email"`
+	FullName       string     `json:"full_name"`
+	Role           Role       `json:"role"`
+	IsActive       bool       `json:"is_active"`
+	IsVerified     bool       `json:"is_verified"`
+	MFAEnabled     bool       `json:"mfa_enabled"`
+	Timezone       *string    `json:"timezone,omitempty"`
+	PublicMetadata Metadata   `json:"public_metadata"`
+	LastLogin      *time.Time `json:"last_login,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// PublicProfile returns the user's public profile.
+func (u *User) PublicProfile() *PublicProfile {
+	return &PublicProfile{
+		UserID:         u.UserID,
+		FullName:       u.FullName,
+		PublicMetadata: u.PublicMetadata,
+	}
+}
+
+// Profile returns the user's profile.
+func (u *User) Profile() *UserProfile {
+	return &UserProfile{
+		UserID:         u.UserID,
+		Email:          u.Email,
+		FullName:       u.FullName,
+		Role:           u.Role,
+		IsActive:       u.IsActive,
+		IsVerified:     u.IsVerified,
+		MFAEnabled:     u.TOTPEnabled,
+		Timezone:       u.Timezone,
+		PublicMetadata: u.PublicMetadata,
+		LastLogin:      u.LastLogin,
+		CreatedAt:      u.CreatedAt,
+		UpdatedAt:      u.UpdatedAt,
+	}
+}
+
+// ProfileUpdate holds the profile fields a user may change. Nil fields are left unchanged.
+type ProfileUpdate struct {
+	FullName *string `json:"full_name,omitempty" validate:"omitempty,min=1,max=200"`
+	Timezone *string `json:"timezone,omitempty" validate:"omitempty,timezone"`
+}
+
+// UserFilter selects the users returned by an admin listing.
+type UserFilter struct {
+	Query    string // matched against email and full name
+	Role     Role
+	IsActive *bool
+	Page     pagination.Request
+}
+
+// UserList is a page of users. Total counts all users matching the filter.
+type UserList struct {
+	Users []*UserProfile `json:"users"`
+	Total int            `json:"total"`
+	pagination.Info
+}
+
+type UpdateRoleRequest struct {
+	Role Role `json:"role" validate:"required,oneof=user admin"`
+}
+
 type UserResponse struct {
 	UserId       string `json:"user_id"`
 	FullName     string `json:"full_name"`